@@ -0,0 +1,295 @@
+package main
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/compat"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/jsonschema"
+	"github.com/helpfulengineering/open-knowledge-framework/migration"
+)
+
+var compatCommand = command{
+	name:    "compat",
+	args:    "[-report text|json] old [new]",
+	summary: "Classify the schema changes between two git revisions or schema directories (new defaults to this build), failing on breaking changes without a migration step.",
+	run:     runCompat,
+}
+
+// schemaTags : the struct tags whose schemas are compared when schemas are
+// generated rather than read from a directory.
+var schemaTags = []string{"yaml", "json"}
+
+func runCompat(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	report := fs.String("report", "text", "report `format`: text or json")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || (*report != "text" && *report != "json") {
+		fs.Usage()
+		return exitUsage
+	}
+	old, err := loadSchemas(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "okf compat: %v\n", err)
+		return exitUnreadable
+	}
+	// The new schemas default to those of this build.
+	newer := currentSchemas()
+	if fs.NArg() == 2 {
+		if newer, err = loadSchemas(fs.Arg(1)); err != nil {
+			fmt.Fprintf(stderr, "okf compat: %v\n", err)
+			return exitUnreadable
+		}
+	}
+	comparisons := compat.CompareSets(old, newer)
+	unmigrated := make([][]compat.Change, len(comparisons))
+	for i, cmp := range comparisons {
+		steps, err := compatSteps(cmp)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf compat: %s: %v\n", cmp.Name, err)
+		}
+		unmigrated[i] = cmp.Unmigrated(steps)
+	}
+	if *report == "json" {
+		type comparison struct {
+			compat.Comparison
+			Unmigrated []compat.Change `json:"unmigrated,omitempty"`
+		}
+		out := make([]comparison, len(comparisons))
+		for i, cmp := range comparisons {
+			out[i] = comparison{cmp, unmigrated[i]}
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(out)
+	} else {
+		err = writeCompatText(stdout, comparisons, unmigrated)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "okf compat: %v\n", err)
+		return exitFailure
+	}
+	for _, changes := range unmigrated {
+		if len(changes) > 0 {
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+// compatSteps : return the migration steps of this build between the
+// versions of cmp, whose name ends in the kind compared.
+func compatSteps(cmp compat.Comparison) ([]migration.Step, error) {
+	if cmp.OldVersion == "" || cmp.NewVersion == "" {
+		return nil, nil
+	}
+	kind := cmp.Name[strings.LastIndex(cmp.Name, "/")+1:]
+	return document.Migrations().Between(kind, cmp.OldVersion, cmp.NewVersion)
+}
+
+func writeCompatText(w io.Writer, comparisons []compat.Comparison, unmigrated [][]compat.Change) error {
+	for i, cmp := range comparisons {
+		if len(cmp.Changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s: version %s to %s\n", cmp.Name, cmp.OldVersion, cmp.NewVersion)
+		for _, ch := range cmp.Changes {
+			fmt.Fprintf(w, "  %s\n", ch)
+		}
+		if n := len(unmigrated[i]); n > 0 {
+			fmt.Fprintf(w, "%s: %d breaking changes without a migration step:\n", cmp.Name, n)
+			for _, ch := range unmigrated[i] {
+				fmt.Fprintf(w, "  %s\n", ch)
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d schemas compared\n", len(comparisons))
+	return err
+}
+
+// currentSchemas : return the schemas of every kind in this build, named
+// "<tag>/<kind>".
+func currentSchemas() map[string]*jsonschema.Schema {
+	schemas := map[string]*jsonschema.Schema{}
+	for _, tag := range schemaTags {
+		for _, k := range document.Kinds() {
+			schemas[tag+"/"+k.Name] = kindSchema(k, tag)
+		}
+	}
+	return schemas
+}
+
+// loadSchemas : read the schemas in the directory source, as written by
+// "okf schema -o", or generate those of the git revision source with its own
+// okf command, for each of schemaTags.
+func loadSchemas(source string) (map[string]*jsonschema.Schema, error) {
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return readSchemas(source)
+	}
+	dir, err := ioutil.TempDir("", "okf-compat-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	if err := exportRevision(source, src); err != nil {
+		return nil, err
+	}
+	if err := writeGoMod(src); err != nil {
+		return nil, fmt.Errorf("building %s: %v", source, err)
+	}
+	out := filepath.Join(dir, "schemas")
+	for _, tag := range schemaTags {
+		cmd := exec.Command("go", "run", "-mod=mod", "./cmd/okf", "schema", "-tag", tag, "-o", filepath.Join(out, tag))
+		cmd.Dir = src
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("generating the schemas of %s: %v\n%s", source, err, output)
+		}
+	}
+	return readSchemas(out)
+}
+
+// readSchemas : read the *.schema.json files under dir, named by their path
+// relative to dir without the suffix, e.g. "yaml/okw".
+func readSchemas(dir string) (map[string]*jsonschema.Schema, error) {
+	schemas := map[string]*jsonschema.Schema{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".schema.json") {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var s jsonschema.Schema
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		schemas[filepath.ToSlash(strings.TrimSuffix(rel, ".schema.json"))] = &s
+		return nil
+	})
+	if err == nil && len(schemas) == 0 {
+		err = fmt.Errorf("%s: no *.schema.json files", dir)
+	}
+	return schemas, err
+}
+
+// writeGoMod : write a go.mod and go.sum to dir, the files of a revision,
+// requiring the modules this okf was built with, unless the revision has a
+// go.mod of its own. The repository has no go.mod, so this is what lets a
+// revision be built the way this okf was.
+func writeGoMod(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		return nil
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path == "" || info.Main.Path == "command-line-arguments" {
+		return fmt.Errorf("the revision has no go.mod, and this okf was not built as a module to take one from")
+	}
+	var mod, sum strings.Builder
+	fmt.Fprintf(&mod, "module %s\n\ngo %s\n", info.Main.Path, goVersion())
+	for _, dep := range info.Deps {
+		fmt.Fprintf(&mod, "\nrequire %s %s\n", dep.Path, dep.Version)
+		m := dep
+		if dep.Replace != nil {
+			m = dep.Replace
+			target := m.Path
+			if m.Version != "" {
+				target += " " + m.Version
+			}
+			fmt.Fprintf(&mod, "replace %s => %s\n", dep.Path, target)
+		}
+		if m.Sum != "" {
+			fmt.Fprintf(&sum, "%s %s %s\n", m.Path, m.Version, m.Sum)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod.String()), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "go.sum"), []byte(sum.String()), 0644)
+}
+
+// goVersion : the language version of the Go release okf was built with,
+// e.g. "1.21" for go1.21.5.
+func goVersion() string {
+	v := strings.TrimPrefix(runtime.Version(), "go")
+	if parts := strings.SplitN(v, ".", 3); len(parts) >= 2 {
+		return parts[0] + "." + strings.TrimFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	}
+	return "1.12"
+}
+
+// exportRevision : write the files of git revision rev to dir.
+func exportRevision(rev, dir string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", rev)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	extractErr := extractTar(stdout, dir)
+	// Drain the archive so that git can exit if extraction stopped early.
+	io.Copy(ioutil.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s is neither a directory nor a git revision: %s", rev, strings.TrimSpace(stderr.String()))
+	}
+	return extractErr
+}
+
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.FromSlash(h.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path %q in archive", h.Name)
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(h.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+)
+
+var convertCommand = command{
+	name:    "convert",
+	args:    "[-kind kind] [-to format] [-o file] [-keep-unknown] [-canonical] [-compact] file",
+	summary: "Re-encode a record file in another format.",
+	run:     runConvert,
+}
+
+func runConvert(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	kindName := fs.String("kind", "", "record `kind` (default inferred from the input path)")
+	to := fs.String("to", "", "output `format` (default inferred from -o)")
+	output := fs.String("o", "", "output `file` (default standard output)")
+	var opts codec.Options
+	fs.BoolVar(&opts.KeepUnknown, "keep-unknown", false, "keep keys the specification does not define instead of dropping them")
+	fs.BoolVar(&opts.Canonical, "canonical", false, "sort keys instead of using the specification's field order")
+	fs.BoolVar(&opts.Compact, "compact", false, "omit empty strings, zeros, false and empty lists and mappings")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 || (*to == "" && *output == "") {
+		fs.Usage()
+		return exitUsage
+	}
+	input := fs.Arg(0)
+	k, err := kindFor(*kindName, input)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf convert: %v\n", err)
+		return exitUsage
+	}
+	from, err := codec.FormatFromPath(input)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf convert: %v\n", err)
+		return exitUsage
+	}
+	var format codec.Format
+	if *to != "" {
+		format, err = codec.ParseFormat(*to)
+	} else {
+		format, err = codec.FormatFromPath(*output)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "okf convert: %v\n", err)
+		return exitUsage
+	}
+
+	data, err := ioutil.ReadFile(input)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf convert: %v\n", err)
+		return exitUnreadable
+	}
+	data, report, err := upgrade(k, data, from)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf convert: %s: %v\n", input, err)
+		return exitFailure
+	}
+	if report.Changed() {
+		fmt.Fprintf(stderr, "okf convert: %s: upgrading %s version %s to %s\n", input, report.Kind, report.From, report.To)
+	}
+	if !opts.KeepUnknown {
+		unknown, err := codec.UnknownKeys(data, from, k.New())
+		if err == nil {
+			for _, key := range unknown {
+				fmt.Fprintf(stderr, "okf convert: %s: dropping unknown key %s\n", input, key)
+			}
+		}
+	}
+	if deprecations, err := codec.Decode(data, from, k.New()); err == nil {
+		for _, d := range deprecations {
+			fmt.Fprintf(stderr, "okf convert: %s: %s\n", input, d)
+		}
+	}
+	converted, err := codec.Convert(data, from, format, k.New(), opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf convert: %s: %v\n", input, err)
+		return exitFailure
+	}
+	if *output == "" {
+		_, err = stdout.Write(converted)
+	} else {
+		err = ioutil.WriteFile(*output, converted, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "okf convert: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/damlgen"
+)
+
+var damlCommand = command{
+	name:    "daml",
+	args:    "[-o dir] [-name name] [-sdk version] [-compact] [kind ...]",
+	summary: "Generate a DAML project with a module for each kind (default all).",
+	run:     runDAML,
+}
+
+func runDAML(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	outputDir := fs.String("o", "daml", "project `directory`")
+	project := damlgen.Project{Namespace: "OpenKnowledge"}
+	fs.StringVar(&project.Name, "name", "open-knowledge-framework", "project `name` written to daml.yaml")
+	fs.StringVar(&project.Version, "version", "0.0.1", "project `version` written to daml.yaml")
+	fs.StringVar(&project.SDKVersion, "sdk", damlgen.DefaultSDKVersion, "DAML SDK `version` written to daml.yaml")
+	fs.BoolVar(&project.Compact, "compact", false, "declare fields that compact YAML and JSON may omit as Optional")
+	scripts := fs.Bool("scripts", true, "generate a Daml Script per kind that registers its sample record")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	kinds, err := selectKinds(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "okf daml: %v\n", err)
+		return exitUsage
+	}
+	for _, k := range kinds {
+		schema := damlgen.Schema{
+			Module:  strings.ToUpper(k.Name),
+			Root:    k.New(),
+			TypeMap: k.TypeMap(),
+		}
+		if *scripts {
+			schema.Samples = []interface{}{k.Sample()}
+		}
+		project.Schemas = append(project.Schemas, schema)
+	}
+	paths, err := project.Write(*outputDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf daml: %v\n", err)
+		return exitFailure
+	}
+	for _, path := range paths {
+		fmt.Fprintln(stdout, path)
+	}
+	return exitOK
+}
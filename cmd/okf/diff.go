@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/diff"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+)
+
+var diffCommand = command{
+	name:    "diff",
+	args:    "[-kind kind] [-report text|patch] old new",
+	summary: "Show what changed between two versions of a record, field by field or as a JSON Patch; exits 1 if they differ.",
+	run:     runDiff,
+}
+
+var patchCommand = command{
+	name:    "patch",
+	args:    "[-kind kind] [-o file] record patch",
+	summary: "Apply a JSON Patch to a record, writing the result only if it is valid.",
+	run:     runPatch,
+}
+
+func runDiff(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	kindName := fs.String("kind", "", "record `kind` (default inferred from the old path)")
+	report := fs.String("report", "text", "report `format`: text, or patch for an RFC 6902 JSON Patch")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 || (*report != "text" && *report != "patch") {
+		fs.Usage()
+		return exitUsage
+	}
+	k, err := kindFor(*kindName, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "okf diff: %v\n", err)
+		return exitUsage
+	}
+	kindOf := func(string) (document.Kind, error) { return k, nil }
+	var records [2]interface{}
+	for i := range records {
+		if _, records[i], err = readRecord(fs.Arg(i), kindOf); err != nil {
+			fmt.Fprintf(stderr, "okf diff: %v\n", err)
+			return exitUnreadable
+		}
+	}
+	// The text names fields by their YAML keys, and the patch applies to the
+	// JSON encoding.
+	format := codec.YAML
+	if *report == "patch" {
+		format = codec.JSON
+	}
+	changes, err := diff.Records(records[0], records[1], format)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf diff: %v\n", err)
+		return exitFailure
+	}
+	if *report == "patch" {
+		enc := json.NewEncoder(stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diff.Patch(changes))
+	} else {
+		err = diff.WriteText(stdout, changes)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "okf diff: %v\n", err)
+		return exitFailure
+	}
+	if len(changes) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runPatch(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	kindName := fs.String("kind", "", "record `kind` (default inferred from the record path)")
+	output := fs.String("o", "", "output `file`, in the format its extension names (default standard output, in the record's format)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+	input := fs.Arg(0)
+	k, err := kindFor(*kindName, input)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %v\n", err)
+		return exitUsage
+	}
+	format, err := codec.FormatFromPath(input)
+	if *output != "" && err == nil {
+		format, err = codec.FormatFromPath(*output)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %v\n", err)
+		return exitUsage
+	}
+	_, record, err := readRecord(input, func(string) (document.Kind, error) { return k, nil })
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %v\n", err)
+		return exitUnreadable
+	}
+	data, err := ioutil.ReadFile(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %v\n", err)
+		return exitUnreadable
+	}
+	ops, err := diff.ParsePatch(data)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %s: %v\n", fs.Arg(1), err)
+		return exitUnreadable
+	}
+	patched, result, err := diff.ApplyRecord(validation.New(), k, record, ops)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %s: %v\n", fs.Arg(1), err)
+		return exitFailure
+	}
+	if result.Status != validation.Valid {
+		result.Path = input + " (patched)"
+		fmt.Fprintln(stderr, "okf patch: the patched record is invalid; nothing written")
+		validation.WriteText(stderr, []validation.Result{result})
+		return exitFailure
+	}
+	out, err := codec.Marshal(patched, format)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %v\n", err)
+		return exitFailure
+	}
+	if *output == "" {
+		_, err = stdout.Write(out)
+	} else {
+		err = ioutil.WriteFile(*output, out, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "okf patch: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+)
+
+var explainCommand = command{
+	name:    "explain",
+	args:    "kind[.path]",
+	summary: "Print the specification's documentation of a field, e.g. okw.location.what_3_words.",
+	run:     runExplain,
+}
+
+func runExplain(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(stderr, "okf explain: expected one field, e.g. okw.location.what_3_words\n")
+		return exitUsage
+	}
+	name := fs.Arg(0)
+	kindName, path := name, ""
+	if i := strings.Index(name, "."); i >= 0 {
+		kindName, path = name[:i], name[i+1:]
+	}
+	k, err := document.Lookup(kindName)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf explain: %v\n", err)
+		return exitUsage
+	}
+	if path == "" {
+		fmt.Fprintf(stdout, "%s: %s\n", k.Name, k.Title)
+	} else {
+		doc, ok := k.Docs.Lookup(path)
+		if !ok {
+			fmt.Fprintf(stderr, "okf explain: %s has no field %q\n", k.Name, path)
+			return exitFailure
+		}
+		fmt.Fprintf(stdout, "%s (%s)\n", name, doc.Type)
+		for _, part := range []struct{ label, text string }{
+			{"Definition", doc.Definition},
+			{"Format", doc.Format},
+			{"Note", doc.Note},
+		} {
+			if part.text != "" {
+				fmt.Fprintf(stdout, "  %s: %s\n", part.label, part.text)
+			}
+		}
+	}
+	children := k.Docs.Children(path)
+	if len(children) > 0 {
+		fmt.Fprintln(stdout, "Fields:")
+		for _, child := range children {
+			doc := k.Docs[child]
+			fmt.Fprintf(stdout, "  %s.%s (%s)\n", k.Name, child, doc.Type)
+		}
+	}
+	return exitOK
+}
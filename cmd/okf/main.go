@@ -0,0 +1,131 @@
+// Command okf is the Open Knowledge Framework tool. It writes sample records,
+// validates and converts record files, and generates JSON Schema and DAML from
+// the specifications.
+//
+// Usage:
+//
+//	okf <command> [flags] [arguments]
+//
+// Run "okf help" for the list of commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes shared by every command.
+const (
+	exitOK         = 0
+	exitFailure    = 1 // the command ran but failed, e.g. a record is invalid
+	exitUsage      = 2 // bad command line
+	exitUnreadable = 3 // an input file could not be read
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c command, args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	sampleCommand,
+	templateCommand,
+	exampleCommand,
+	validateCommand,
+	staleCommand,
+	searchCommand,
+	convertCommand,
+	migrateCommand,
+	schemaCommand,
+	compatCommand,
+	diffCommand,
+	patchCommand,
+	serveCommand,
+	damlCommand,
+	explainCommand,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		if len(args) > 1 {
+			if c, ok := lookup(args[1]); ok {
+				return c.run(c, []string{"-h"}, stdout, stdout)
+			}
+		}
+		usage(stdout)
+		return exitOK
+	}
+	c, ok := lookup(name)
+	if !ok {
+		fmt.Fprintf(stderr, "okf: unknown command %q\n", name)
+		usage(stderr)
+		return exitUsage
+	}
+	return c.run(c, args[1:], stdout, stderr)
+}
+
+func lookup(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: okf <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "okf help <command>" for the flags of a command.`)
+}
+
+// newFlagSet : return a flag set for c that reports errors and usage to stderr.
+func newFlagSet(c command, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: okf %s %s\n\n%s\n", c.name, c.args, c.summary)
+		if hasFlags(fs) {
+			fmt.Fprintln(stderr)
+			fmt.Fprintln(stderr, "Flags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	n := 0
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+// parseFlags : parse args into fs, returning the exit code to stop with if
+// parsing did not succeed.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
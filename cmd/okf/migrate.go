@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/migration"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+)
+
+var migrateCommand = command{
+	name:    "migrate",
+	args:    "[-kind kind] [-to version] [-n] path ...",
+	summary: "Upgrade record files, or every record file under the given directories, to a later version of their specification.",
+	run:     runMigrate,
+}
+
+func runMigrate(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	kindName := fs.String("kind", "", "record `kind` of every file (default inferred from each path)")
+	target := fs.String("to", "", "target `version` (default the latest)")
+	dryRun := fs.Bool("n", false, "report the changes without rewriting any file")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	kindOf := document.Detect
+	if *kindName != "" {
+		k, err := document.Lookup(*kindName)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf migrate: %v\n", err)
+			return exitUsage
+		}
+		kindOf = func(string) (document.Kind, error) { return k, nil }
+	}
+	files, err := validation.CollectFiles(fs.Args(), kindOf)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf migrate: %v\n", err)
+		return exitUnreadable
+	}
+	code := exitOK
+	for _, path := range files {
+		report, deprecations, err := migrateFile(path, kindOf, *target, *dryRun)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf migrate: %v\n", err)
+			code = exitFailure
+			continue
+		}
+		if report.Changed() {
+			fmt.Fprintf(stdout, "%s: %s version %s to %s\n", path, report.Kind, report.From, report.To)
+		}
+		for _, s := range report.Steps {
+			for _, c := range s.Changes {
+				fmt.Fprintf(stdout, "  %s\n", c)
+			}
+		}
+		for _, d := range deprecations {
+			fmt.Fprintf(stdout, "%s: %s: renamed %q to %q\n", path, d.Path, d.Key, d.Replacement)
+		}
+	}
+	return code
+}
+
+// migrateFile : upgrade the record file at path to the target version and
+// rewrite it, unless dryRun is set or there is nothing to change. When the
+// target is the latest version, legacy keys the document still uses are
+// corrected too. Other keys, including ones the specification does not
+// define, are written back in their original order; YAML comments are not
+// kept.
+func migrateFile(path string, kindOf validation.KindFunc, target string, dryRun bool) (migration.Report, []codec.Deprecation, error) {
+	k, err := kindOf(path)
+	if err != nil {
+		return migration.Report{}, nil, err
+	}
+	format, err := codec.FormatFromPath(path)
+	if err != nil {
+		return migration.Report{}, nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return migration.Report{}, nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return migration.Report{}, nil, err
+	}
+	tree, err := codec.DecodeTree(data, format)
+	if err != nil {
+		return migration.Report{}, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	registry := document.Migrations()
+	doc, report, err := registry.Migrate(migration.Document{Kind: k.Name, Format: format, Tree: tree}, target)
+	if err != nil {
+		return report, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	var deprecations []codec.Deprecation
+	if report.To == registry.Latest(k.Name) {
+		doc.Tree, deprecations = codec.Upgrade(doc.Tree, reflect.TypeOf(k.New()), format)
+	}
+	if dryRun || (!report.Changed() && len(deprecations) == 0) {
+		return report, deprecations, nil
+	}
+	data, err = codec.Marshal(doc.Tree, format)
+	if err != nil {
+		return report, deprecations, fmt.Errorf("%s: %v", path, err)
+	}
+	return report, deprecations, ioutil.WriteFile(path, data, info.Mode())
+}
+
+// upgrade : return data, a document of kind k in format f, upgraded to the
+// latest version of k, together with the migration report.
+func upgrade(k document.Kind, data []byte, f codec.Format) ([]byte, migration.Report, error) {
+	tree, err := codec.DecodeTree(data, f)
+	if err != nil {
+		// Leave the error to the typed decoder.
+		return data, migration.Report{}, nil
+	}
+	doc, report, err := document.Migrations().Migrate(migration.Document{Kind: k.Name, Format: f, Tree: tree}, "")
+	if err != nil || !report.Changed() {
+		return data, report, err
+	}
+	data, err = codec.Marshal(doc.Tree, f)
+	return data, report, err
+}
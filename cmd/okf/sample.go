@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+)
+
+var sampleCommand = command{
+	name:    "sample",
+	args:    "[-o dir] [-format yaml,json] [-compact] [kind ...]",
+	summary: "Write the sample record of each kind (default all) to <dir>/<kind>/<kind>.<format>.",
+	run:     runSample,
+}
+
+func runSample(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	outputDir := fs.String("o", "samples", "output `directory`")
+	formatList := fs.String("format", "yaml,json", "comma separated output `formats`")
+	compact := fs.Bool("compact", false, "omit empty strings, zeros, false and empty lists and mappings")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	formats, err := codec.ParseFormats(*formatList)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf sample: %v\n", err)
+		return exitUsage
+	}
+	kinds, err := selectKinds(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "okf sample: %v\n", err)
+		return exitUsage
+	}
+	write := codec.WriteFile
+	if *compact {
+		write = codec.WriteFileCompact
+	}
+	for _, k := range kinds {
+		record := k.Sample()
+		for _, f := range formats {
+			path := filepath.Join(*outputDir, k.Name, k.Name+f.Ext())
+			if err := write(path, record); err != nil {
+				fmt.Fprintf(stderr, "okf sample: %v\n", err)
+				return exitFailure
+			}
+			fmt.Fprintln(stdout, path)
+		}
+	}
+	return exitOK
+}
+
+// selectKinds : return the kinds named on the command line, or every kind if none are.
+func selectKinds(names []string) ([]document.Kind, error) {
+	if len(names) == 0 {
+		return document.Kinds(), nil
+	}
+	var kinds []document.Kind
+	for _, name := range names {
+		k, err := document.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, k)
+	}
+	return kinds, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/jsonschema"
+)
+
+var schemaCommand = command{
+	name:    "schema",
+	args:    "[-o dir] [-tag yaml|json] [kind ...]",
+	summary: "Generate the JSON Schema of each kind (default all).",
+	run:     runSchema,
+}
+
+func runSchema(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	outputDir := fs.String("o", "", "write <kind>.schema.json files to `directory` (default standard output, as one object keyed by kind unless a single kind is named)")
+	tag := fs.String("tag", "json", "struct `tag` naming the properties: yaml or json")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *tag != "yaml" && *tag != "json" {
+		fmt.Fprintf(stderr, "okf schema: -tag must be yaml or json, not %q\n", *tag)
+		return exitUsage
+	}
+	kinds, err := selectKinds(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "okf schema: %v\n", err)
+		return exitUsage
+	}
+	if *outputDir == "" {
+		return writeSchemas(kinds, len(fs.Args()) == 1, *tag, stdout, stderr)
+	}
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		fmt.Fprintf(stderr, "okf schema: %v\n", err)
+		return exitFailure
+	}
+	for _, k := range kinds {
+		data, err := json.MarshalIndent(kindSchema(k, *tag), "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "okf schema: %s: %v\n", k.Name, err)
+			return exitFailure
+		}
+		path := filepath.Join(*outputDir, k.Name+".schema.json")
+		if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			fmt.Fprintf(stderr, "okf schema: %v\n", err)
+			return exitFailure
+		}
+		fmt.Fprintln(stdout, path)
+	}
+	return exitOK
+}
+
+// writeSchemas : write a single JSON document to w: the schema of the only
+// kind if one was named, or else an object mapping each kind's name to its
+// schema.
+func writeSchemas(kinds []document.Kind, named bool, tag string, w, stderr io.Writer) int {
+	var v interface{}
+	if named {
+		v = kindSchema(kinds[0], tag)
+	} else {
+		schemas := map[string]*jsonschema.Schema{}
+		for _, k := range kinds {
+			schemas[k.Name] = kindSchema(k, tag)
+		}
+		v = schemas
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "okf schema: %v\n", err)
+		return exitFailure
+	}
+	w.Write(append(data, '\n'))
+	return exitOK
+}
+
+// kindSchema : return the JSON Schema of k with properties named by tag.
+func kindSchema(k document.Kind, tag string) *jsonschema.Schema {
+	s := jsonschema.Reflect(k.New(), tag, k.Docs)
+	s.Title = k.Title
+	s.SpecVersion = k.Version
+	return s
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/jsonschema"
+)
+
+func TestSchemaStdout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runSchema(schemaCommand, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("okf schema: exit %d: %s", code, stderr.String())
+	}
+	var schemas map[string]*jsonschema.Schema
+	if err := json.Unmarshal(stdout.Bytes(), &schemas); err != nil {
+		t.Fatalf("okf schema did not write one JSON object: %v", err)
+	}
+	for _, k := range document.Kinds() {
+		if s := schemas[k.Name]; s == nil || s.Title != k.Title {
+			t.Errorf("okf schema: %s: %+v, want the schema titled %q", k.Name, s, k.Title)
+		}
+	}
+
+	k := document.Kinds()[0]
+	stdout.Reset()
+	if code := runSchema(schemaCommand, []string{k.Name}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("okf schema %s: exit %d: %s", k.Name, code, stderr.String())
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(stdout.Bytes(), &s); err != nil || s.Title != k.Title {
+		t.Errorf("okf schema %s: %+v, %v, want the schema titled %q", k.Name, s, err, k.Title)
+	}
+}
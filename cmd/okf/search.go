@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/fulltext"
+	"github.com/helpfulengineering/open-knowledge-framework/geo"
+	"github.com/helpfulengineering/open-knowledge-framework/query"
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+)
+
+var searchCommand = command{
+	name:    "search",
+	args:    "[-kind kind | -registry] [-q query | -f file] [-near lat,lon [-radius distance]] [-box south,west,north,east] [-text words [-analyzer name]] [-explain] [-limit n] [-report text|json] [-o file] path ...",
+	summary: "Find the records that match a query, are within a distance of a point or inside a bounding box, or contain words, best first.",
+	run:     runSearch,
+}
+
+// searchHit : a record found by okf search.
+type searchHit struct {
+	Path      string   `json:"path"`
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Distance  *float64 `json:"distance_km,omitempty"`
+	Score     *float64 `json:"score,omitempty"`
+}
+
+var searchWriters = map[string]func(io.Writer, []searchHit) error{
+	"text": writeSearchText,
+	"json": writeSearchJSON,
+}
+
+func runSearch(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	kindName := fs.String("kind", "", "record `kind` of every file (default inferred from each path)")
+	q := fs.String("q", "", "find records that match `query`, e.g. 'process:laser_cutting AND status:Active'")
+	queryFile := fs.String("f", "", "read the query from `file`, in which lines starting with # are comments")
+	near := fs.String("near", "", "find records near `lat,lon`, in decimal degrees, nearest first")
+	radius := fs.String("radius", "", "with -near, find records within `distance`, in km, m or mi (default "+query.DefaultRadius+")")
+	box := fs.String("box", "", "find records inside the box `south,west,north,east`, in decimal degrees")
+	text := fs.String("text", "", "find records whose descriptions, products, equipment or materials contain any of `words`, best match first")
+	analyzerName := fs.String("analyzer", "english", "analyse text with the analyzer `name`: "+strings.Join(fulltext.AnalyzerNames(), " or "))
+	isRegistry := fs.Bool("registry", false, "search the registry rooted at the only path, by record identifier, keeping its full-text index up to date")
+	explain := fs.Bool("explain", false, "print how the query is evaluated instead of the records found")
+	limit := fs.Int("limit", 0, "list at most `n` records (default all)")
+	report := fs.String("report", "text", "report `format`: text or json")
+	output := fs.String("o", "", "write the report to `file` (default standard output)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 || (*q == "" && *queryFile == "" && *near == "" && *box == "" && *text == "") ||
+		(*q != "" && *queryFile != "") || (*isRegistry && (fs.NArg() != 1 || *kindName != "")) {
+		fs.Usage()
+		return exitUsage
+	}
+	write, ok := searchWriters[*report]
+	if !ok {
+		fmt.Fprintf(stderr, "okf search: unknown report format %q\n", *report)
+		return exitUsage
+	}
+	analyzer, err := fulltext.LookupAnalyzer(*analyzerName)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf search: %v\n", err)
+		return exitUsage
+	}
+	source := *q
+	if *queryFile != "" {
+		data, err := ioutil.ReadFile(*queryFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf search: %v\n", err)
+			return exitUnreadable
+		}
+		source = stripComments(string(data))
+	}
+	expr, err := query.Build(source, *near, *radius, *box)
+	if err == nil && expr == nil && *text == "" {
+		err = fmt.Errorf("empty query")
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "okf search: %v\n", err)
+		return exitUsage
+	}
+	var (
+		ix   *query.Index
+		tix  *fulltext.Index
+		code = exitOK
+	)
+	if *isRegistry {
+		ix, tix, err = openSearchRegistry(fs.Arg(0), analyzer)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf search: %v\n", err)
+			return exitUnreadable
+		}
+	} else {
+		kindOf := document.Detect
+		if *kindName != "" {
+			k, err := document.Lookup(*kindName)
+			if err != nil {
+				fmt.Fprintf(stderr, "okf search: %v\n", err)
+				return exitUsage
+			}
+			kindOf = func(string) (document.Kind, error) { return k, nil }
+		}
+		files, err := validation.CollectFiles(fs.Args(), kindOf)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf search: %v\n", err)
+			return exitUnreadable
+		}
+		ix, tix = query.NewIndex(), fulltext.NewIndex(analyzer)
+		for _, path := range files {
+			k, record, err := readRecord(path, kindOf)
+			if err == nil {
+				err = ix.Add(path, k.Name, record)
+			}
+			if err == nil && *text != "" {
+				err = tix.Add(path, k.Name, record)
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "okf search: %v\n", err)
+				code = exitUnreadable
+			}
+		}
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf search: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		w = f
+	}
+	plan := ix.Plan(expr)
+	if *explain {
+		if *text != "" {
+			fmt.Fprintf(w, "rank by full-text search for %q, analysed as %v\n", *text, analyzer.Analyze(*text))
+		}
+		if _, err := fmt.Fprint(w, plan); err != nil {
+			fmt.Fprintf(stderr, "okf search: %v\n", err)
+			return exitFailure
+		}
+		return code
+	}
+	found := plan.Run()
+	if *text != "" {
+		found = query.Rank(found, tix.Search(*text))
+	}
+	if *limit > 0 && len(found) > *limit {
+		found = found[:*limit]
+	}
+	hits := make([]searchHit, len(found))
+	for i, r := range found {
+		hits[i] = searchHit{Path: r.ID, Kind: r.Kind, Name: recordName(r.Record), Distance: r.Distance, Score: r.Score}
+		if p, ok := geo.PointOf(r.Record); ok {
+			hits[i].Latitude, hits[i].Longitude = &p.Lat, &p.Lon
+		}
+	}
+	if err := write(w, hits); err != nil {
+		fmt.Fprintf(stderr, "okf search: %v\n", err)
+		return exitFailure
+	}
+	return code
+}
+
+// openSearchRegistry : return the query and full-text indexes of the
+// registry rooted at root, after bringing its full-text index, kept in the
+// registry, up to date.
+func openSearchRegistry(root string, analyzer fulltext.Analyzer) (*query.Index, *fulltext.Index, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, nil, err
+	}
+	reg, err := registry.Open(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	tix, err := fulltext.Open(reg, analyzer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tix.Sync(); err != nil {
+		return nil, nil, err
+	}
+	ix, err := query.FromRegistry(reg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ix, tix.Index, nil
+}
+
+// stripComments : remove the lines of a query file that start with #.
+func stripComments(s string) string {
+	lines := strings.Split(s, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// recordName : return the name of record, or its title for kinds that have
+// one instead.
+func recordName(record interface{}) string {
+	tree, err := codec.ToTree(record, codec.YAML)
+	if err != nil {
+		return ""
+	}
+	root, _ := tree.(codec.Map)
+	for _, key := range []string{"name", "title"} {
+		if v, _ := root.Get(key); v != nil {
+			if s, ok := v.(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func writeSearchText(w io.Writer, hits []searchHit) error {
+	for _, h := range hits {
+		fmt.Fprint(w, h.Path)
+		if h.Distance != nil {
+			fmt.Fprintf(w, ": %s km", strconv.FormatFloat(*h.Distance, 'f', 1, 64))
+		}
+		if h.Score != nil {
+			fmt.Fprintf(w, " [%s]", strconv.FormatFloat(*h.Score, 'f', 2, 64))
+		}
+		if h.Name != "" {
+			fmt.Fprintf(w, " (%s)", h.Name)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(hits) == 1 {
+		_, err := fmt.Fprintln(w, "1 record found")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d records found\n", len(hits))
+	return err
+}
+
+func writeSearchJSON(w io.Writer, hits []searchHit) error {
+	if hits == nil {
+		hits = []searchHit{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(hits)
+}
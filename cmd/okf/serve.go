@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/helpfulengineering/open-knowledge-framework/server"
+)
+
+var serveCommand = command{
+	name:    "serve",
+	args:    "[-addr address] [-dir dir] [-require-if-match]",
+	summary: "Serve records over HTTP, at /facilities, /carriers and /designs, from memory or from a directory of record files.",
+	run:     runServe,
+}
+
+func runServe(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	addr := fs.String("addr", "localhost:8080", "listen on `address`")
+	dir := fs.String("dir", "", "keep records in the record files under `dir`, as a registry (default in memory, lost on exit)")
+	requireMatch := fs.Bool("require-if-match", false, "refuse to replace or delete a record without an If-Match header")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	var store server.Store = server.NewMemoryStore()
+	if *dir != "" {
+		s, err := server.NewDirStore(*dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf serve: %v\n", err)
+			return exitUnreadable
+		}
+		store = s
+	}
+	srv := server.New(store)
+	srv.RequireMatch = *requireMatch
+	fmt.Fprintf(stderr, "okf serve: listening on http://%s/\n", *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		fmt.Fprintf(stderr, "okf serve: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
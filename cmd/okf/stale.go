@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+	"github.com/helpfulengineering/open-knowledge-framework/staleness"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+)
+
+var staleCommand = command{
+	name:    "stale",
+	args:    "[-kind kind] [-registry] [-older-than age] [-now time] [-report text|json|csv] [-o file] path ...",
+	summary: "List the records not verified recently, grouped by contact, for re-verification.",
+	run:     runStale,
+}
+
+var staleWriters = map[string]func(io.Writer, staleness.Report) error{
+	"text": writeStaleText,
+	"json": writeStaleJSON,
+	"csv":  writeStaleCSV,
+}
+
+func runStale(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	kindName := fs.String("kind", "", "record `kind` of every file (default inferred from each path)")
+	olderThan := fs.String("older-than", "90d", "list records last verified longer ago than `age`, e.g. 30d, 6w or 72h")
+	nowFlag := fs.String("now", "", "measure ages from `time`, an RFC 3339 time or a date (default the current time)")
+	report := fs.String("report", "text", "report `format`: text, json or csv")
+	output := fs.String("o", "", "write the report to `file` (default standard output)")
+	isRegistry := fs.Bool("registry", false, "check the registry rooted at the only path, taking the details of referenced contacts from any record in it")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 || (*isRegistry && fs.NArg() != 1) {
+		fs.Usage()
+		return exitUsage
+	}
+	write, ok := staleWriters[*report]
+	if !ok {
+		fmt.Fprintf(stderr, "okf stale: unknown report format %q\n", *report)
+		return exitUsage
+	}
+	threshold, err := staleness.ParseAge(*olderThan)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf stale: %v\n", err)
+		return exitUsage
+	}
+	now := time.Now().UTC()
+	if *nowFlag != "" {
+		if now, err = staleness.ParseTime(*nowFlag); err != nil {
+			fmt.Fprintf(stderr, "okf stale: invalid -now %q\n", *nowFlag)
+			return exitUsage
+		}
+	}
+	kindOf := document.Detect
+	if *kindName != "" {
+		k, err := document.Lookup(*kindName)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf stale: %v\n", err)
+			return exitUsage
+		}
+		kindOf = func(string) (document.Kind, error) { return k, nil }
+	}
+	files, err := validation.CollectFiles(fs.Args(), kindOf)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf stale: %v\n", err)
+		return exitUnreadable
+	}
+	var reg *registry.Registry
+	if *isRegistry {
+		if _, err := os.Stat(fs.Arg(0)); err != nil {
+			fmt.Fprintf(stderr, "okf stale: %v\n", err)
+			return exitUnreadable
+		}
+		if reg, err = registry.Open(fs.Arg(0)); err != nil {
+			fmt.Fprintf(stderr, "okf stale: %v\n", err)
+			return exitUnreadable
+		}
+	}
+	code := exitOK
+	var records []staleness.Record
+	for _, path := range files {
+		r, err := inspectFile(path, kindOf, reg)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf stale: %v\n", err)
+			code = exitUnreadable
+			continue
+		}
+		records = append(records, r)
+	}
+	result := staleness.Check(records, now, threshold)
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf stale: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := write(w, result); err != nil {
+		fmt.Fprintf(stderr, "okf stale: %v\n", err)
+		return exitFailure
+	}
+	if code == exitOK && result.Stale > 0 {
+		code = exitFailure
+	}
+	return code
+}
+
+// inspectFile : decode the record file at path and return what the report
+// needs from it. If reg is not nil, the record's references are first
+// resolved in it; those it cannot resolve are left as they are, and
+// reported by okf validate -registry.
+func inspectFile(path string, kindOf validation.KindFunc, reg *registry.Registry) (staleness.Record, error) {
+	k, record, err := readRecord(path, kindOf)
+	if err != nil {
+		return staleness.Record{}, err
+	}
+	if reg != nil {
+		if err := reg.Resolve(record); err != nil {
+			if _, ok := err.(registry.RefErrors); !ok {
+				return staleness.Record{}, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+	return staleness.Inspect(path, k.Name, record)
+}
+
+func writeStaleText(w io.Writer, report staleness.Report) error {
+	for _, g := range report.Groups {
+		fmt.Fprintf(w, "%s\n", g.Contact)
+		for _, d := range g.Contact.Details() {
+			fmt.Fprintf(w, "  %s\n", d)
+		}
+		for _, r := range g.Records {
+			fmt.Fprintf(w, "  - %s: %s", r.Path, staleAge(r))
+			if r.Name != "" {
+				fmt.Fprintf(w, " (%s", r.Name)
+				if r.Status != "" {
+					fmt.Fprintf(w, ", %s", r.Status)
+				}
+				fmt.Fprint(w, ")")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d of %d records not verified in the last %d days\n", report.Stale, report.Checked, report.Threshold)
+	return err
+}
+
+// staleAge : describe when r was last verified.
+func staleAge(r staleness.Record) string {
+	switch {
+	case r.Problem == "" && r.Age == 1:
+		return fmt.Sprintf("verified %s, 1 day ago", r.Verified)
+	case r.Problem == "":
+		return fmt.Sprintf("verified %s, %d days ago", r.Verified, r.Age)
+	case r.Verified != "":
+		return fmt.Sprintf("%s: %q", r.Problem, r.Verified)
+	}
+	return r.Problem
+}
+
+func writeStaleJSON(w io.Writer, report staleness.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// writeStaleCSV : write a row per stale record with its contact's details,
+// for use in a spreadsheet.
+func writeStaleCSV(w io.Writer, report staleness.Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"contact", "contact_person", "affiliation", "email", "landline", "mobile", "whatsapp", "website",
+		"path", "kind", "name", "status", "verified", "age_days", "problem"})
+	for _, g := range report.Groups {
+		c := g.Contact
+		name := c.Name
+		if name == "" {
+			name = c.Ref
+		}
+		for _, r := range g.Records {
+			age := ""
+			if r.Problem == "" {
+				age = strconv.Itoa(r.Age)
+			}
+			cw.Write([]string{name, c.ContactPerson, c.Affiliation, c.Email, c.Landline, c.Mobile, c.WhatsApp, c.Website,
+				r.Path, r.Kind, r.Name, r.Status, r.Verified, age, r.Problem})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/helpfulengineering/open-knowledge-framework/migration"
+	"github.com/helpfulengineering/open-knowledge-framework/skeleton"
+)
+
+var templateCommand = command{
+	name:    "template",
+	args:    "[-o dir] [kind ...]",
+	summary: "Write an empty, annotated YAML record of each kind (default all) to fill in.",
+	run:     runTemplate,
+}
+
+func runTemplate(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	outputDir := fs.String("o", "", "write <kind>.template.yaml files to `directory` (default standard output)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	kinds, err := selectKinds(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "okf template: %v\n", err)
+		return exitUsage
+	}
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			fmt.Fprintf(stderr, "okf template: %v\n", err)
+			return exitFailure
+		}
+	}
+	for i, k := range kinds {
+		record := k.New()
+		migration.Stamp(record, k.Version)
+		data := skeleton.Generate(record, k.Title, k.Docs)
+		if *outputDir == "" {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			stdout.Write(data)
+			continue
+		}
+		path := filepath.Join(*outputDir, k.Name+".template.yaml")
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			fmt.Fprintf(stderr, "okf template: %v\n", err)
+			return exitFailure
+		}
+		fmt.Fprintln(stdout, path)
+	}
+	return exitOK
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+)
+
+var validateCommand = command{
+	name:    "validate",
+	args:    "[-kind kind] [-registry] [-j n] [-report text|json|junit|sarif] [-o file] path ...",
+	summary: "Check record files, or every record file under the given directories, against their specification.",
+	run:     runValidate,
+}
+
+var reportWriters = map[string]func(io.Writer, []validation.Result) error{
+	"text":  validation.WriteText,
+	"json":  validation.WriteJSON,
+	"junit": validation.WriteJUnit,
+	"sarif": validation.WriteSARIF,
+}
+
+func runValidate(c command, args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet(c, stderr)
+	kindName := fs.String("kind", "", "record `kind` of every file (default inferred from each path)")
+	workers := fs.Int("j", runtime.NumCPU(), "validate up to `n` files in parallel")
+	report := fs.String("report", "text", "report `format`: text, json, junit or sarif")
+	output := fs.String("o", "", "write the report to `file` (default standard output)")
+	isRegistry := fs.Bool("registry", false, "validate the registry rooted at the only path, also reporting references no record in it defines")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 || (*isRegistry && fs.NArg() != 1) {
+		fs.Usage()
+		return exitUsage
+	}
+	write, ok := reportWriters[*report]
+	if !ok {
+		fmt.Fprintf(stderr, "okf validate: unknown report format %q\n", *report)
+		return exitUsage
+	}
+	kindOf := document.Detect
+	if *kindName != "" {
+		k, err := document.Lookup(*kindName)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf validate: %v\n", err)
+			return exitUsage
+		}
+		kindOf = func(string) (document.Kind, error) { return k, nil }
+	}
+
+	files, err := validation.CollectFiles(fs.Args(), kindOf)
+	if err != nil {
+		fmt.Fprintf(stderr, "okf validate: %v\n", err)
+		return exitUnreadable
+	}
+	results := validation.ValidateFiles(files, *workers, kindOf)
+	if *isRegistry {
+		if _, err := os.Stat(fs.Arg(0)); err != nil {
+			fmt.Fprintf(stderr, "okf validate: %v\n", err)
+			return exitUnreadable
+		}
+		reg, err := registry.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "okf validate: %v\n", err)
+			return exitUnreadable
+		}
+		for i := range results {
+			checkRefs(reg, &results[i], kindOf)
+		}
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "okf validate: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := write(w, results); err != nil {
+		fmt.Fprintf(stderr, "okf validate: %v\n", err)
+		return exitFailure
+	}
+
+	s := validation.Summarize(results)
+	switch {
+	case s.Unreadable > 0:
+		return exitUnreadable
+	case s.Invalid > 0:
+		return exitFailure
+	}
+	return exitOK
+}
+
+// checkRefs : add a violation to r, the result of validating a file of
+// reg, for each reference in its record that reg cannot resolve. Files that
+// could not be decoded are left alone.
+func checkRefs(reg *registry.Registry, r *validation.Result, kindOf validation.KindFunc) {
+	if r.Status == validation.Unreadable || r.Error != "" {
+		return
+	}
+	_, record, err := readRecord(r.Path, kindOf)
+	if err != nil {
+		return
+	}
+	refs, ok := reg.Resolve(record).(registry.RefErrors)
+	if !ok {
+		return
+	}
+	data, _ := ioutil.ReadFile(r.Path)
+	format, _ := codec.FormatFromPath(r.Path)
+	for _, e := range refs {
+		r.Violations = append(r.Violations, validation.Violation{
+			Path:    e.Path,
+			Rule:    "ref",
+			Param:   e.Ref,
+			Message: e.Reason,
+			Line:    validation.Locate(data, format, e.Path),
+		})
+	}
+	r.Status = validation.Invalid
+}
+
+// kindFor : return the kind named by the -kind flag, or infer it from path.
+func kindFor(name, path string) (document.Kind, error) {
+	if name != "" {
+		return document.Lookup(name)
+	}
+	return document.Detect(path)
+}
+
+// readRecord : decode the record file at path, of the kind given by kindOf,
+// upgrading older versions of its specification.
+func readRecord(path string, kindOf validation.KindFunc) (document.Kind, interface{}, error) {
+	k, err := kindOf(path)
+	if err != nil {
+		return k, nil, err
+	}
+	f, err := codec.FormatFromPath(path)
+	if err != nil {
+		return k, nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return k, nil, err
+	}
+	record := k.New()
+	if _, _, err := document.Migrations().Decode(k.Name, data, f, record); err != nil {
+		return k, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return k, record, nil
+}
@@ -0,0 +1,215 @@
+// Command okfdoc generates the field documentation registry of a
+// specification package from the doc comments of its types.
+//
+// It is run by go generate in the package directory:
+//
+//	//go:generate go run ../../cmd/okfdoc -type OKW
+//
+// and writes a FieldDocs variable, keyed by YAML path from the root type, to
+// fielddocs.go. A field is documented by a comment that starts with its name
+// followed by a colon, either above it or at the end of its line; a field
+// without one falls back to the comment of its type.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("okfdoc: ")
+	typeName := flag.String("type", "", "root `type` of the specification")
+	output := flag.String("o", "fielddocs.go", "output `file`")
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != filepath.Base(*output)
+	}, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		log.Fatalf("expected one package in the current directory, found %d", len(pkgs))
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	g := &generator{types: map[string]*ast.TypeSpec{}, docs: map[string]string{}, fields: spec.Docs{}}
+	for _, file := range pkg.Files {
+		for _, d := range file.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gd.Specs {
+				ts := s.(*ast.TypeSpec)
+				g.types[ts.Name.Name] = ts
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				if text, ok := docText(ts.Name.Name, doc); ok {
+					g.docs[ts.Name.Name] = text
+				}
+			}
+		}
+	}
+	if _, ok := g.types[*typeName]; !ok {
+		log.Fatalf("type %s not found", *typeName)
+	}
+	g.walk(*typeName, "", map[string]bool{})
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by okfdoc -type %s; DO NOT EDIT.\n\n", *typeName)
+	fmt.Fprintf(&b, "package %s\n\n", pkg.Name)
+	fmt.Fprintf(&b, "import \"github.com/helpfulengineering/open-knowledge-framework/spec\"\n\n")
+	fmt.Fprintf(&b, "// FieldDocs : documentation of the fields of %s, keyed by YAML path.\n", *typeName)
+	fmt.Fprintf(&b, "var FieldDocs = spec.Docs{\n")
+	paths := make([]string, 0, len(g.fields))
+	for path := range g.fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		d := g.fields[path]
+		fmt.Fprintf(&b, "\t%s: {\n", strconv.Quote(path))
+		fmt.Fprintf(&b, "\t\tType: %s,\n", strconv.Quote(d.Type))
+		for _, part := range []struct{ name, value string }{{"Definition", d.Definition}, {"Format", d.Format}, {"Note", d.Note}} {
+			if part.value != "" {
+				fmt.Fprintf(&b, "\t\t%s: %s,\n", part.name, strconv.Quote(part.value))
+			}
+		}
+		fmt.Fprintf(&b, "\t},\n")
+	}
+	fmt.Fprintf(&b, "}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type generator struct {
+	types map[string]*ast.TypeSpec
+	// docs : comment text of the package's types, keyed by type name.
+	docs   map[string]string
+	fields spec.Docs
+}
+
+// walk : document the fields of the struct type name, found at path.
+func (g *generator) walk(name, path string, visiting map[string]bool) {
+	ts, ok := g.types[name]
+	if !ok || visiting[name] {
+		return
+	}
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(s)
+		}
+		yamlName := strings.Split(tag.Get("yaml"), ",")[0]
+		inline := strings.Contains(tag.Get("yaml"), ",inline")
+		typeName := baseType(f.Type)
+		for _, ident := range f.Names {
+			if !ident.IsExported() || yamlName == "-" {
+				continue
+			}
+			if inline {
+				g.walk(typeName, path, visiting)
+				continue
+			}
+			key := yamlName
+			if key == "" {
+				key = strings.ToLower(ident.Name)
+			}
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			text, ok := docText(ident.Name, f.Doc)
+			if !ok {
+				text, ok = docText(ident.Name, f.Comment)
+			}
+			if !ok {
+				text = g.docs[typeName]
+			}
+			doc := spec.ParseDoc(text)
+			doc.Type = types.ExprString(f.Type)
+			g.fields[fieldPath] = doc
+			g.walk(typeName, fieldPath, visiting)
+		}
+	}
+}
+
+// baseType : the name of the package-local type at the bottom of a type
+// expression such as []*Agent, or "" if there is none.
+func baseType(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return baseType(e.X)
+	case *ast.ArrayType:
+		return baseType(e.Elt)
+	}
+	return ""
+}
+
+// docText : the text of a comment that documents name, i.e. starts with
+// "name :". Lines of commented-out code, recognisable by their struct tags,
+// are left out.
+func docText(name string, cg *ast.CommentGroup) (string, bool) {
+	if cg == nil {
+		return "", false
+	}
+	var lines []string
+	for _, line := range strings.Split(cg.Text(), "\n") {
+		if strings.Contains(line, "`") {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	text := strings.TrimSpace(strings.Join(lines, " "))
+	rest := strings.TrimPrefix(text, name)
+	if rest == text {
+		return "", false
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, ":") {
+		return "", false
+	}
+	return strings.TrimSpace(rest[1:]), true
+}
@@ -0,0 +1,154 @@
+// Package codec reads and writes Open Knowledge Framework records in the
+// supported serialisation formats.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Format : a serialisation format for records.
+type Format string
+
+const (
+	// YAML : YAML 1.1 as written by gopkg.in/yaml.v2.
+	YAML Format = "yaml"
+	// JSON : indented JSON.
+	JSON Format = "json"
+)
+
+// Formats : return the supported formats.
+func Formats() []Format {
+	return []Format{YAML, JSON}
+}
+
+// Ext : return the file extension, including the dot, used for the format.
+func (f Format) Ext() string {
+	return "." + string(f)
+}
+
+// ParseFormat : return the Format named by s, accepting common aliases such as "yml".
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "yaml", "yml":
+		return YAML, nil
+	case "json":
+		return JSON, nil
+	}
+	return "", fmt.Errorf("unsupported format %q", s)
+}
+
+// ParseFormats : parse a comma separated list of formats.
+func ParseFormats(s string) ([]Format, error) {
+	var formats []Format
+	for _, name := range strings.Split(s, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		f, err := ParseFormat(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
+
+// FormatFromPath : return the Format implied by the extension of path.
+func FormatFromPath(path string) (Format, error) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return "", fmt.Errorf("%s: no file extension to infer the format from", path)
+	}
+	f, err := ParseFormat(ext)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+// Marshal : encode v in the given format.
+func Marshal(v interface{}, f Format) ([]byte, error) {
+	switch f {
+	case YAML:
+		return yaml.Marshal(v)
+	case JSON:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", f)
+}
+
+// Unmarshal : decode data in the given format into v. Fields whose keys are
+// missing are left unset, so compact documents decode to the same record.
+// Legacy keys are accepted as Decode does, without reporting them.
+func Unmarshal(data []byte, f Format, v interface{}) error {
+	_, err := Decode(data, f, v)
+	return err
+}
+
+func unmarshal(data []byte, f Format, v interface{}) error {
+	switch f {
+	case YAML:
+		return yaml.Unmarshal(data, v)
+	case JSON:
+		return json.Unmarshal(data, v)
+	}
+	return fmt.Errorf("unsupported format %q", f)
+}
+
+// ReadFile : decode the file at path into v, using the format implied by its extension.
+func ReadFile(path string, v interface{}) error {
+	f, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := Unmarshal(data, f, v); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// WriteFile : encode v to the file at path, using the format implied by its
+// extension. Missing parent directories are created.
+func WriteFile(path string, v interface{}) error {
+	return writeFile(path, v, Marshal)
+}
+
+// WriteFileCompact : like WriteFile, but omitting empty values as
+// MarshalCompact does.
+func WriteFileCompact(path string, v interface{}) error {
+	return writeFile(path, v, MarshalCompact)
+}
+
+func writeFile(path string, v interface{}, marshal func(interface{}, Format) ([]byte, error)) error {
+	f, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := marshal(v, f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"reflect"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// Compact : remove the empty values from tree, the encoding in format f of a
+// record of type t, and return it. A mapping loses every key whose value is
+// an empty string, zero, false, null, or a mapping or sequence that is empty
+// once compacted. Fields that t declares as pointers distinguish unknown
+// from empty, so they are only removed when null. Sequence elements are
+// compacted but kept, so that their positions do not change. A nil t
+// compacts every key alike.
+//
+// Compact output relies on decoding treating a missing key as unset: Unmarshal
+// leaves the field at its zero value, which is what was omitted.
+func Compact(tree interface{}, t reflect.Type, f Format) interface{} {
+	return compact(tree, t, string(f))
+}
+
+func compact(tree interface{}, t reflect.Type, tag string) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch tree := tree.(type) {
+	case Map:
+		fields := map[string]reflect.Type{}
+		if t != nil && t.Kind() == reflect.Struct {
+			for _, f := range spec.Fields(t, tag) {
+				fields[f.Key] = f.Type
+			}
+		}
+		out := tree[:0]
+		for _, item := range tree {
+			ft, ok := fields[item.Key]
+			if !ok && t != nil && t.Kind() == reflect.Map {
+				ft = t.Elem()
+			}
+			item.Value = compact(item.Value, ft, tag)
+			if ft != nil && ft.Kind() == reflect.Ptr {
+				if item.Value != nil {
+					out = append(out, item)
+				}
+			} else if !empty(item.Value) {
+				out = append(out, item)
+			}
+		}
+		return out
+	case []interface{}:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i := range tree {
+			tree[i] = compact(tree[i], elem, tag)
+		}
+	}
+	return tree
+}
+
+// empty : whether a compacted tree value carries no information.
+func empty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	case Map:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// MarshalCompact : encode v in the given format like Marshal, omitting the
+// values Compact removes.
+func MarshalCompact(v interface{}, f Format) ([]byte, error) {
+	tree, err := ToTree(v, f)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(Compact(tree, reflect.TypeOf(v), f), f)
+}
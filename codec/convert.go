@@ -0,0 +1,150 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// Options : how Convert re-encodes a record.
+type Options struct {
+	// KeepUnknown : carry keys that the record type does not define through to
+	// the output. They are dropped otherwise.
+	KeepUnknown bool
+	// Canonical : sort the keys of every mapping, instead of writing them in
+	// the order the specification declares them.
+	Canonical bool
+	// Compact : omit empty values, as MarshalCompact does.
+	Compact bool
+}
+
+// Convert : decode data in format from into record, which must be a pointer
+// to a typed record such as *okw.OKW, and encode it in format to. Decoding
+// into the typed record normalises the document: values take their
+// specified types and keys their specified names in the output format, with
+// legacy keys corrected.
+func Convert(data []byte, from, to Format, record interface{}, opts Options) ([]byte, error) {
+	if err := Unmarshal(data, from, record); err != nil {
+		return nil, err
+	}
+	if !opts.KeepUnknown && !opts.Canonical && !opts.Compact {
+		return Marshal(record, to)
+	}
+	out, err := ToTree(record, to)
+	if err != nil {
+		return nil, err
+	}
+	if opts.KeepUnknown {
+		in, err := DecodeTree(data, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range unknownFields(in, reflect.TypeOf(record), string(from), string(to), nil) {
+			out = insert(out, u.path, u.key, u.value)
+		}
+	}
+	if opts.Compact {
+		out = Compact(out, reflect.TypeOf(record), to)
+	}
+	if opts.Canonical {
+		SortKeys(out)
+	}
+	return Marshal(out, to)
+}
+
+// UnknownKeys : return the dotted paths of the keys in data that the type of
+// record does not define, i.e. the keys Convert drops unless KeepUnknown is set.
+func UnknownKeys(data []byte, f Format, record interface{}) ([]string, error) {
+	in, err := DecodeTree(data, f)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, u := range unknownFields(in, reflect.TypeOf(record), string(f), string(f), nil) {
+		keys = append(keys, PathString(append(u.path, u.key)))
+	}
+	return keys, nil
+}
+
+type unknownField struct {
+	// path : location of the enclosing mapping, named by the output tag.
+	path  []interface{}
+	key   string
+	value interface{}
+}
+
+// unknownFields : walk tree alongside type t, whose keys are named by the
+// from tag, and collect the keys t does not define.
+func unknownFields(tree interface{}, t reflect.Type, from, to string, path []interface{}) []unknownField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var found []unknownField
+	switch tree := tree.(type) {
+	case Map:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		fields := map[string]spec.Field{}
+		for _, f := range spec.Fields(t, from) {
+			fields[f.Key] = f
+			for _, key := range f.Legacy() {
+				fields[key] = f
+			}
+		}
+		for _, item := range tree {
+			f, ok := fields[item.Key]
+			if !ok {
+				found = append(found, unknownField{path: clonePath(path), key: item.Key, value: item.Value})
+				continue
+			}
+			found = append(found, unknownFields(item.Value, f.Type, from, to, append(path, spec.Key(f.StructField, to)))...)
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for i, v := range tree {
+			found = append(found, unknownFields(v, t.Elem(), from, to, append(path, i))...)
+		}
+	}
+	return found
+}
+
+func clonePath(path []interface{}) []interface{} {
+	return append([]interface{}(nil), path...)
+}
+
+// insert : set key to value in the mapping at path in tree, creating empty
+// mappings along the way where the encoder omitted them.
+func insert(tree interface{}, path []interface{}, key string, value interface{}) interface{} {
+	if len(path) == 0 {
+		m, _ := tree.(Map)
+		for i := range m {
+			if m[i].Key == key {
+				m[i].Value = value
+				return m
+			}
+		}
+		return append(m, MapItem{Key: key, Value: value})
+	}
+	switch p := path[0].(type) {
+	case string:
+		m, _ := tree.(Map)
+		for i := range m {
+			if m[i].Key == p {
+				m[i].Value = insert(m[i].Value, path[1:], key, value)
+				return m
+			}
+		}
+		return append(m, MapItem{Key: p, Value: insert(nil, path[1:], key, value)})
+	case int:
+		s, _ := tree.([]interface{})
+		if p < len(s) {
+			s[p] = insert(s[p], path[1:], key, value)
+		}
+		return s
+	}
+	panic(fmt.Sprintf("codec: invalid path element %v", path[0]))
+}
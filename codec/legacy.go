@@ -0,0 +1,103 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// Deprecation : a legacy key found in a document, i.e. a misspelt key that
+// has since been corrected.
+type Deprecation struct {
+	// Path : dotted path of the legacy key, e.g. "customer_reviews[0].indentifier".
+	Path string `json:"path"`
+	// Key : the legacy key, e.g. "indentifier".
+	Key string `json:"key"`
+	// Replacement : the key to write instead, e.g. "identifier".
+	Replacement string `json:"replacement"`
+}
+
+func (d Deprecation) String() string {
+	return fmt.Sprintf("%s: key %q is deprecated, use %q", d.Path, d.Key, d.Replacement)
+}
+
+// Decode : like Unmarshal, but also accepting the legacy keys declared by
+// the legacy tags of v's fields. A Deprecation is returned for each legacy
+// key found. Where a document gives both spellings, the corrected key wins.
+func Decode(data []byte, f Format, v interface{}) ([]Deprecation, error) {
+	tree, err := DecodeTree(data, f)
+	if err != nil {
+		// Let the typed decoder report the error in its own words.
+		return nil, unmarshal(data, f, v)
+	}
+	tree, deprecations := Upgrade(tree, reflect.TypeOf(v), f)
+	if len(deprecations) == 0 {
+		return nil, unmarshal(data, f, v)
+	}
+	data, err = Marshal(tree, f)
+	if err != nil {
+		return nil, err
+	}
+	return deprecations, unmarshal(data, f, v)
+}
+
+// Upgrade : rename the legacy keys in tree, a document of type t in format
+// f, to their corrected spelling, and return the result with a Deprecation
+// for each key renamed. Keys keep their position; a legacy key whose
+// corrected key is also present is dropped.
+func Upgrade(tree interface{}, t reflect.Type, f Format) (interface{}, []Deprecation) {
+	var deprecations []Deprecation
+	tree = upgrade(tree, t, string(f), nil, &deprecations)
+	return tree, deprecations
+}
+
+func upgrade(tree interface{}, t reflect.Type, tag string, path []interface{}, deprecations *[]Deprecation) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch tree := tree.(type) {
+	case Map:
+		if t.Kind() != reflect.Struct {
+			return tree
+		}
+		fields := map[string]spec.Field{}
+		legacy := map[string]spec.Field{}
+		for _, f := range spec.Fields(t, tag) {
+			fields[f.Key] = f
+			for _, key := range f.Legacy() {
+				legacy[key] = f
+			}
+		}
+		out := make(Map, 0, len(tree))
+		for _, item := range tree {
+			f, ok := fields[item.Key]
+			if !ok {
+				if f, ok = legacy[item.Key]; ok {
+					*deprecations = append(*deprecations, Deprecation{
+						Path:        PathString(append(clonePath(path), item.Key)),
+						Key:         item.Key,
+						Replacement: f.Key,
+					})
+					if _, present := tree.Get(f.Key); present {
+						continue
+					}
+					item.Key = f.Key
+				}
+			}
+			if ok {
+				item.Value = upgrade(item.Value, f.Type, tag, append(path, item.Key), deprecations)
+			}
+			out = append(out, item)
+		}
+		return out
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return tree
+		}
+		for i, v := range tree {
+			tree[i] = upgrade(v, t.Elem(), tag, append(path, i), deprecations)
+		}
+	}
+	return tree
+}
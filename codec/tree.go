@@ -0,0 +1,230 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A tree is a format-neutral decoded document: Map for mappings,
+// []interface{} for sequences, and string, bool, int64, float64 or nil for
+// scalars. Unlike map[string]interface{}, Map keeps keys in document order.
+
+// MapItem : a key and its value in a Map.
+type MapItem struct {
+	Key   string
+	Value interface{}
+}
+
+// Map : a mapping that keeps its keys in order when encoded.
+type Map []MapItem
+
+// Get : return the value of key and whether it is present.
+func (m Map) Get(key string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalYAML : encode m as a YAML mapping in key order.
+func (m Map) MarshalYAML() (interface{}, error) {
+	ms := make(yaml.MapSlice, len(m))
+	for i, item := range m {
+		ms[i] = yaml.MapItem{Key: item.Key, Value: item.Value}
+	}
+	return ms, nil
+}
+
+// MarshalJSON : encode m as a JSON object in key order.
+func (m Map) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, item := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(item.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := jsonMarshalNoEscape(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func jsonMarshalNoEscape(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// DecodeTree : decode data in the given format into a tree.
+func DecodeTree(data []byte, f Format) (interface{}, error) {
+	switch f {
+	case YAML:
+		// Decoding into a MapSlice makes yaml.v2 keep the order of nested
+		// mappings too; documents that are not mappings fall back to interface{}.
+		var ms yaml.MapSlice
+		if err := yaml.Unmarshal(data, &ms); err == nil {
+			if ms == nil {
+				return nil, nil
+			}
+			return normalize(ms), nil
+		}
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return normalize(v), nil
+	case JSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		v, err := decodeJSON(dec)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return nil, fmt.Errorf("invalid character after top-level value")
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", f)
+}
+
+// normalize : convert the generic values produced by yaml.v2 into a tree.
+// Mapping keys are read from a MapSlice first so that their order is kept.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		m := make(Map, len(v))
+		for i, item := range v {
+			m[i] = MapItem{Key: fmt.Sprint(item.Key), Value: normalize(item.Value)}
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(Map, 0, len(v))
+		for k, value := range v {
+			m = append(m, MapItem{Key: fmt.Sprint(k), Value: normalize(value)})
+		}
+		sort.Slice(m, func(i, j int) bool { return m[i].Key < m[j].Key })
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+		return v
+	case int:
+		return int64(v)
+	case uint64:
+		if v <= 1<<63-1 {
+			return int64(v)
+		}
+		return float64(v)
+	}
+	return v
+}
+
+func decodeJSON(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '{':
+			m := Map{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				m = append(m, MapItem{Key: keyTok.(string), Value: value})
+			}
+			_, err := dec.Token()
+			return m, err
+		case '[':
+			s := []interface{}{}
+			for dec.More() {
+				value, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				s = append(s, value)
+			}
+			_, err := dec.Token()
+			return s, err
+		}
+	case json.Number:
+		if i, err := tok.Int64(); err == nil {
+			return i, nil
+		}
+		return tok.Float64()
+	}
+	return tok, nil
+}
+
+// ToTree : encode v in format f and decode the result into a tree, so that
+// the tree holds exactly what the encoder would write.
+func ToTree(v interface{}, f Format) (interface{}, error) {
+	data, err := Marshal(v, f)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTree(data, f)
+}
+
+// SortKeys : sort the keys of every mapping in tree, in place.
+func SortKeys(tree interface{}) {
+	switch t := tree.(type) {
+	case Map:
+		sort.SliceStable(t, func(i, j int) bool { return t[i].Key < t[j].Key })
+		for _, item := range t {
+			SortKeys(item.Value)
+		}
+	case []interface{}:
+		for _, v := range t {
+			SortKeys(v)
+		}
+	}
+}
+
+// PathString : format a tree path of keys and indices as a dotted path, e.g. "customer_reviews[0].rating".
+func PathString(path []interface{}) string {
+	var b strings.Builder
+	for _, p := range path {
+		switch p := p.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", p)
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			fmt.Fprint(&b, p)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,315 @@
+// Package compat compares two versions of a specification's JSON Schema and
+// classifies each difference by its effect on stored records, so that a
+// careless change to a struct tag is caught before it breaks them.
+package compat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/jsonschema"
+	"github.com/helpfulengineering/open-knowledge-framework/migration"
+)
+
+// Class : how a change affects records and the programs that read them.
+type Class string
+
+const (
+	// Additive : records valid before are still valid, e.g. a new optional key.
+	Additive Class = "additive"
+	// Deprecating : records valid before are still read, but a key they use is
+	// now a legacy key that is no longer written.
+	Deprecating Class = "deprecating"
+	// Breaking : records valid before may no longer be, e.g. a removed key, a
+	// narrowed enum, a changed type or a new required key.
+	Breaking Class = "breaking"
+)
+
+// Change : a difference between two schemas.
+type Change struct {
+	// Path : dotted path of the property, with "[]" for list elements and
+	// ".*" for mapping values, e.g. "customer_reviews[].rating".
+	Path    string `json:"path"`
+	Class   Class  `json:"class"`
+	Message string `json:"message"`
+}
+
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("%s: %s: %s", c.Class, path, c.Message)
+}
+
+// Comparison : the changes from an old schema to a new one.
+type Comparison struct {
+	// Name : what was compared, e.g. "yaml/okw".
+	Name       string   `json:"name"`
+	OldVersion string   `json:"old_version"`
+	NewVersion string   `json:"new_version"`
+	Changes    []Change `json:"changes,omitempty"`
+}
+
+// Breaking : return the breaking changes.
+func (c Comparison) Breaking() []Change {
+	var breaking []Change
+	for _, ch := range c.Changes {
+		if ch.Class == Breaking {
+			breaking = append(breaking, ch)
+		}
+	}
+	return breaking
+}
+
+// Unmigrated : return the breaking changes that none of steps, the
+// migration steps from OldVersion to NewVersion, accounts for by declaring
+// the key changed, at or above the change's path, among its Keys. Advancing
+// the version alone accounts for nothing.
+func (c Comparison) Unmigrated(steps []migration.Step) []Change {
+	var unmigrated []Change
+	for _, ch := range c.Breaking() {
+		if !migrated(ch.Path, steps) {
+			unmigrated = append(unmigrated, ch)
+		}
+	}
+	return unmigrated
+}
+
+// migrated : whether one of steps declares the key at path, or one that
+// contains it.
+func migrated(path string, steps []migration.Step) bool {
+	if path == "" {
+		return false
+	}
+	for _, s := range steps {
+		for _, key := range s.Keys {
+			if path == key || strings.HasPrefix(path, key+".") || strings.HasPrefix(path, key+"[]") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Compare : return the changes from old to new, two root schemas as written
+// by jsonschema.Reflect. Descriptions and titles are ignored. A definition
+// shared by several properties is compared once, and its changes are
+// reported at the first path that reaches it.
+func Compare(name string, old, new *jsonschema.Schema) Comparison {
+	c := &comparer{old: old, new: new, seen: map[[2]string]bool{}}
+	c.compare("", old, new)
+	sort.SliceStable(c.changes, func(i, j int) bool { return c.changes[i].Path < c.changes[j].Path })
+	return Comparison{Name: name, OldVersion: version(old), NewVersion: version(new), Changes: c.changes}
+}
+
+// CompareSets : compare the schemas in old and new that have the same name.
+// A schema missing from new is a breaking change, one missing from old an
+// additive one. Comparisons are sorted by name.
+func CompareSets(old, new map[string]*jsonschema.Schema) []Comparison {
+	var names []string
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var comparisons []Comparison
+	for _, name := range names {
+		o, inOld := old[name]
+		n, inNew := new[name]
+		switch {
+		case !inNew:
+			comparisons = append(comparisons, Comparison{Name: name, OldVersion: version(o),
+				Changes: []Change{{Class: Breaking, Message: "schema removed"}}})
+		case !inOld:
+			comparisons = append(comparisons, Comparison{Name: name, NewVersion: version(n),
+				Changes: []Change{{Class: Additive, Message: "schema added"}}})
+		default:
+			comparisons = append(comparisons, Compare(name, o, n))
+		}
+	}
+	return comparisons
+}
+
+// version : the specification version of root schema s. Schemas written
+// before versions were recorded describe version 1.
+func version(s *jsonschema.Schema) string {
+	if s == nil || s.SpecVersion == "" {
+		return "1"
+	}
+	return s.SpecVersion
+}
+
+type comparer struct {
+	old, new *jsonschema.Schema
+	// seen : pairs of definitions already compared, so that recursive types
+	// terminate.
+	seen    map[[2]string]bool
+	changes []Change
+}
+
+func (c *comparer) add(path string, class Class, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Path: path, Class: class, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *comparer) compare(path string, o, n *jsonschema.Schema) {
+	o, oNull := unwrap(o)
+	n, nNull := unwrap(n)
+	if o.Ref != "" && n.Ref != "" {
+		pair := [2]string{o.Ref, n.Ref}
+		if c.seen[pair] {
+			return
+		}
+		c.seen[pair] = true
+	}
+	o, n = resolve(c.old, o), resolve(c.new, n)
+	switch {
+	case oNull && !nNull:
+		c.add(path, Breaking, "no longer accepts null")
+	case !oNull && nNull:
+		c.add(path, Additive, "now accepts null")
+	}
+	switch {
+	case o.Type == n.Type:
+	case n.Type == "":
+		c.add(path, Additive, "type %s is no longer constrained", o.Type)
+		return
+	case o.Type == "":
+		c.add(path, Breaking, "now restricted to type %s", n.Type)
+		return
+	default:
+		c.add(path, Breaking, "type changed from %s to %s", o.Type, n.Type)
+		return
+	}
+	c.compareEnums(path, o.Enum, n.Enum)
+	if o.Items != nil && n.Items != nil {
+		c.compare(path+"[]", o.Items, n.Items)
+	}
+	if o.AdditionalProperties != nil && n.AdditionalProperties != nil {
+		c.compare(join(path, "*"), o.AdditionalProperties, n.AdditionalProperties)
+	}
+	c.compareProperties(path, o, n)
+}
+
+func (c *comparer) compareEnums(path string, o, n []string) {
+	switch {
+	case len(o) == 0 && len(n) == 0:
+		return
+	case len(n) == 0:
+		c.add(path, Additive, "no longer restricted to %s", quoteAll(o))
+		return
+	case len(o) == 0:
+		c.add(path, Breaking, "now restricted to %s", quoteAll(n))
+		return
+	}
+	if removed := missing(o, n); len(removed) > 0 {
+		c.add(path, Breaking, "enum narrowed: %s removed", quoteAll(removed))
+	}
+	if added := missing(n, o); len(added) > 0 {
+		c.add(path, Additive, "enum widened: %s added", quoteAll(added))
+	}
+}
+
+func (c *comparer) compareProperties(path string, o, n *jsonschema.Schema) {
+	oRequired, nRequired := set(o.Required), set(n.Required)
+	for _, key := range sortedKeys(o.Properties) {
+		keyPath := join(path, key)
+		np, ok := n.Properties[key]
+		switch {
+		case !ok:
+			c.add(keyPath, Breaking, "key removed")
+			continue
+		case np.Deprecated && !o.Properties[key].Deprecated:
+			c.add(keyPath, Deprecating, "key deprecated")
+		case !np.Deprecated && o.Properties[key].Deprecated:
+			c.add(keyPath, Additive, "key no longer deprecated")
+		}
+		switch {
+		case nRequired[key] && !oRequired[key]:
+			c.add(keyPath, Breaking, "key is now required")
+		case oRequired[key] && !nRequired[key]:
+			c.add(keyPath, Additive, "key is no longer required")
+		}
+		c.compare(keyPath, o.Properties[key], np)
+	}
+	for _, key := range sortedKeys(n.Properties) {
+		if _, ok := o.Properties[key]; ok {
+			continue
+		}
+		if nRequired[key] {
+			c.add(join(path, key), Breaking, "new required key")
+		} else {
+			c.add(join(path, key), Additive, "new key")
+		}
+	}
+}
+
+// resolve : follow s's reference into the definitions of root.
+func resolve(root, s *jsonschema.Schema) *jsonschema.Schema {
+	const prefix = "#/definitions/"
+	if s.Ref == "" || !strings.HasPrefix(s.Ref, prefix) {
+		return s
+	}
+	if def, ok := root.Definitions[strings.TrimPrefix(s.Ref, prefix)]; ok {
+		return def
+	}
+	return s
+}
+
+// unwrap : return the schema a nullable anyOf wraps, and whether s accepts
+// null.
+func unwrap(s *jsonschema.Schema) (*jsonschema.Schema, bool) {
+	if len(s.AnyOf) != 2 || s.AnyOf[1].Type != "null" {
+		return s, false
+	}
+	return s.AnyOf[0], true
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]*jsonschema.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func set(values []string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+// missing : return the values of a that are not in b.
+func missing(a, b []string) []string {
+	in := set(b)
+	var out []string
+	for _, v := range a {
+		if !in[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
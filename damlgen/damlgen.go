@@ -0,0 +1,244 @@
+// Package damlgen generates a DAML project from the Go types that define the
+// specifications: a daml.yaml, one module per specification, and a Common
+// module holding the types the specifications share.
+package damlgen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// DefaultSDKVersion : the DAML SDK version written to daml.yaml when none is given.
+const DefaultSDKVersion = "2.8.0"
+
+// Schema : a specification to generate a module for.
+type Schema struct {
+	// Module : unqualified module name, e.g. "OKW".
+	Module string
+	// Root : a value, or pointer to a value, of the specification's root record type.
+	Root interface{}
+	// TypeMap : the DAML name of every named type reachable from Root, as
+	// returned by the specification's TypeMap function. Generation fails if a
+	// reachable type is missing from it. If nil, Go type names are used.
+	TypeMap map[string]interface{}
+	// Samples : records of the root type to register on the ledger in a
+	// generated Daml Script. No script is generated if there are none.
+	Samples []interface{}
+}
+
+// Project : a DAML project to generate.
+type Project struct {
+	Name       string
+	Version    string
+	SDKVersion string
+	// Namespace : prefix of every module name, e.g. "OpenKnowledge".
+	Namespace string
+	Schemas   []Schema
+	// Compact : declare the fields that compact YAML and JSON may omit, i.e.
+	// those that are not required and are not lists, as Optional, so that a
+	// missing key maps to None.
+	Compact bool
+}
+
+// build : collect the declarations of every schema into modules and move
+// the shared ones into the returned Common module.
+func (p Project) build() ([]*module, *module, error) {
+	modules := make([]*module, len(p.Schemas))
+	for i, s := range p.Schemas {
+		t := reflect.TypeOf(s.Root)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		m := &module{name: s.Module, decls: map[string]*decl{}, rootType: t, names: typeNames(s.TypeMap), compact: p.Compact}
+		if err := CheckTypeMap(t, s.TypeMap); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", s.Module, err)
+		}
+		if _, err := m.typeExpr(t); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", s.Module, err)
+		}
+		m.root = t.Name()
+		m.workflow = newWorkflow(t, m.optional)
+		modules[i] = m
+	}
+	common := extractCommon(modules)
+	resolveEnums(common, modules)
+	return modules, common, nil
+}
+
+// typeNames : invert a type map into DAML names keyed by Go type.
+func typeNames(typeMap map[string]interface{}) map[reflect.Type]string {
+	if typeMap == nil {
+		return nil
+	}
+	names := map[reflect.Type]string{}
+	for name, v := range typeMap {
+		names[spec.TypeOf(v)] = name
+	}
+	return names
+}
+
+// CheckTypeMap : report every named type the generated module would
+// declare that has no entry in typeMap, and every type reachable from root
+// that cannot be declared in DAML. The types declared are found by
+// generating the module, not from spec.NamedTypes, from which type maps are
+// usually derived. A nil typeMap is checked only for the latter, with Go
+// type names.
+func CheckTypeMap(root reflect.Type, typeMap map[string]interface{}) error {
+	for root.Kind() == reflect.Ptr {
+		root = root.Elem()
+	}
+	names := typeNames(typeMap)
+	var problems []string
+	if names != nil {
+		m := &module{decls: map[string]*decl{}, rootType: root, names: names, missing: map[reflect.Type]bool{}}
+		// Types that cannot be declared make generation stop early; they
+		// are reported below.
+		m.typeExpr(root)
+		for t := range m.missing {
+			problems = append(problems, fmt.Sprintf("type %s has no DAML mapping in the type map", t))
+		}
+	}
+	for _, t := range spec.NamedTypes(root, "daml") {
+		if err := declarable(t); err != nil {
+			problems = append(problems, fmt.Sprintf("type %s: %v", t, err))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Files : generate the project, returning file contents keyed by path
+// relative to the project root.
+func (p Project) Files() (map[string][]byte, error) {
+	modules, common, err := p.build()
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{
+		"daml.yaml": p.damlYAML(),
+	}
+	if len(common.decls) > 0 {
+		files[p.modulePath(common.name)] = p.render(common, nil)
+	}
+	for i, m := range modules {
+		files[p.modulePath(m.name)] = p.render(m, common)
+		if samples := p.Schemas[i].Samples; len(samples) > 0 {
+			script, err := p.renderScript(m, common, samples)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", m.name, err)
+			}
+			files[p.modulePath("Scripts."+m.name)] = script
+		}
+	}
+	return files, nil
+}
+
+// Enums : return the enums generated as DAML variants, keyed by unqualified
+// module name ("Common" for shared ones) and then by type name.
+func (p Project) Enums() (map[string]map[string]Enum, error) {
+	modules, common, err := p.build()
+	if err != nil {
+		return nil, err
+	}
+	enums := map[string]map[string]Enum{}
+	for _, m := range append(modules, common) {
+		for name, d := range m.decls {
+			if d.enum == nil {
+				continue
+			}
+			if enums[m.name] == nil {
+				enums[m.name] = map[string]Enum{}
+			}
+			enums[m.name][name] = *d.enum
+		}
+	}
+	return enums, nil
+}
+
+// Write : generate the project into dir, creating directories as needed.
+func (p Project) Write(dir string) ([]string, error) {
+	files, err := p.Files()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for i, path := range paths {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(full, files[path], 0644); err != nil {
+			return nil, err
+		}
+		paths[i] = full
+	}
+	return paths, nil
+}
+
+func (p Project) sdkVersion() string {
+	if p.SDKVersion == "" {
+		return DefaultSDKVersion
+	}
+	return p.SDKVersion
+}
+
+func (p Project) qualified(module string) string {
+	if p.Namespace == "" {
+		return module
+	}
+	return p.Namespace + "." + module
+}
+
+func (p Project) modulePath(module string) string {
+	return "daml/" + strings.Replace(p.qualified(module), ".", "/", -1) + ".daml"
+}
+
+func (p Project) damlYAML() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "sdk-version: %s\n", p.sdkVersion())
+	fmt.Fprintf(&b, "name: %s\n", p.Name)
+	fmt.Fprintf(&b, "source: daml\n")
+	fmt.Fprintf(&b, "version: %s\n", p.Version)
+	fmt.Fprintf(&b, "dependencies:\n")
+	fmt.Fprintf(&b, "  - daml-prim\n")
+	fmt.Fprintf(&b, "  - daml-stdlib\n")
+	for _, s := range p.Schemas {
+		if len(s.Samples) > 0 {
+			fmt.Fprintf(&b, "  - daml-script\n")
+			break
+		}
+	}
+	return b.Bytes()
+}
+
+// render : write module m, importing common if m uses any of its types.
+func (p Project) render(m *module, common *module) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "-- Code generated by okf daml. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "module %s where\n", p.qualified(m.name))
+	if common != nil && m.uses(common) {
+		fmt.Fprintf(&b, "\nimport %s\n", p.qualified(common.name))
+	}
+	for _, name := range m.order() {
+		fmt.Fprintf(&b, "\n%s", m.decls[name].text)
+	}
+	if m.workflow != nil {
+		fmt.Fprintf(&b, "\n%s", m.workflow.render())
+	}
+	return b.Bytes()
+}
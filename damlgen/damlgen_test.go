@@ -0,0 +1,58 @@
+package damlgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type secret struct {
+	Key string `daml:"key"`
+}
+
+type guarded struct {
+	Status Status `daml:"status"`
+	Secret secret `daml:"-"`
+}
+
+type tagged struct {
+	Tags map[string]string `daml:"tags"`
+}
+
+func TestCheckTypeMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    interface{}
+		typeMap map[string]interface{}
+		want    []string
+	}{
+		{"complete", Facility{}, map[string]interface{}{
+			"Facility": Facility{}, "Status": Status(""), "Phase": Phase(""), "Tier": Tier(""),
+		}, nil},
+		{"missing", &Facility{}, map[string]interface{}{"Facility": Facility{}, "Status": Status("")},
+			[]string{"type damlgen.Phase has no DAML mapping", "type damlgen.Tier has no DAML mapping"}},
+		// A type reached only through an omitted field is not declared.
+		{"omitted field", guarded{}, map[string]interface{}{"Guarded": guarded{}, "Status": Status("")}, nil},
+		{"no type map", Facility{}, nil, nil},
+		{"not declarable", tagged{}, nil, []string{"type damlgen.tagged: field Tags"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTypeMap(reflect.TypeOf(tt.root), tt.typeMap)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("CheckTypeMap: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("CheckTypeMap succeeded, want errors %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("CheckTypeMap: %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package damlgen
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// Enum : a Go enum type and the DAML variant generated for it. Its Values
+// are the mapping table between the spec's string values and the DAML
+// constructors.
+type Enum struct {
+	// Name : the DAML type name, which is also the Go type name.
+	Name   string
+	Values []EnumValue
+}
+
+// EnumValue : one value of an Enum.
+type EnumValue struct {
+	// Value : the value as written in YAML and JSON, e.g. "Temporary Closure".
+	Value string
+	// Constructor : the DAML constructor, e.g. TemporaryClosure.
+	Constructor string
+}
+
+// Constructor : return the DAML constructor for the spec value.
+func (e Enum) Constructor(value string) (string, bool) {
+	for _, v := range e.Values {
+		if v.Value == value {
+			return v.Constructor, true
+		}
+	}
+	return "", false
+}
+
+// Value : return the spec value for the DAML constructor.
+func (e Enum) Value(constructor string) (string, bool) {
+	for _, v := range e.Values {
+		if v.Constructor == constructor {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+// sanitize : turn a spec value into a DAML constructor name by upper-casing
+// the first letter of each word and dropping everything that is not an ASCII
+// letter or digit, e.g. "Temporary Closure" → "TemporaryClosure".
+func sanitize(value string) string {
+	var b []rune
+	upper := true
+	for _, r := range value {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b = append(b, r)
+	}
+	return string(b)
+}
+
+// newEnum : build the Enum for the Go type name and its spec values. Values
+// that sanitise to nothing, or to a name starting with a digit, are prefixed
+// with the type name; constructor clashes are resolved later by resolveEnums.
+func newEnum(name string, values []string) *Enum {
+	e := &Enum{Name: name}
+	for _, value := range values {
+		c := sanitize(value)
+		if c == "" || unicode.IsDigit(rune(c[0])) {
+			c = name + c
+		}
+		e.Values = append(e.Values, EnumValue{Value: value, Constructor: c})
+	}
+	return e
+}
+
+// resolveEnums : make the constructors of the enums unique within each
+// module, where they share a namespace with each other, with the record
+// constructors, and with the declarations of Common, which every module
+// imports. Common's enums are resolved first, against every module, so that
+// they read the same wherever they are used; each module's are then resolved
+// against its own declarations and Common's.
+func resolveEnums(common *module, modules []*module) {
+	common.resolveEnums(append([]*module{common}, modules...))
+	for _, m := range modules {
+		m.resolveEnums([]*module{common, m})
+	}
+}
+
+// resolveEnums : make the constructors of m's enums unique among the
+// declarations of scope, which includes m. A clashing constructor is
+// prefixed with its type name, and numbered if that is still not enough.
+// The enum declarations are rendered again afterwards, since their text
+// depends on the outcome.
+func (m *module) resolveEnums(scope []*module) {
+	count := map[string]int{}
+	types := map[string]bool{}
+	for _, s := range scope {
+		for name, d := range s.decls {
+			types[name] = true
+			if d.enum != nil {
+				for _, v := range d.enum.Values {
+					count[v.Constructor]++
+				}
+			}
+		}
+	}
+	for _, name := range m.order() {
+		d := m.decls[name]
+		if d.enum == nil {
+			continue
+		}
+		seen := map[string]bool{}
+		for i, v := range d.enum.Values {
+			c := v.Constructor
+			if count[c] > 1 || (types[c] && c != d.enum.Name) {
+				c = d.enum.Name + c
+			}
+			for n := 2; seen[c]; n++ {
+				c = d.enum.Name + v.Constructor + strconv.Itoa(n)
+			}
+			seen[c] = true
+			d.enum.Values[i].Constructor = c
+		}
+		d.text = renderEnum(d.enum)
+	}
+}
+
+// renderEnum : declare e as a DAML variant, with functions converting to
+// and from the spec values so they round-trip on the ledger as well.
+func renderEnum(e *Enum) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "data %s\n", e.Name)
+	for i, v := range e.Values {
+		sep := "|"
+		if i == 0 {
+			sep = "="
+		}
+		fmt.Fprintf(&b, "  %s %s\n", sep, v.Constructor)
+	}
+	fmt.Fprintf(&b, "  deriving (Eq, Show, Ord, Enum, Bounded)\n")
+
+	fn := lowerFirst(e.Name)
+	fmt.Fprintf(&b, "\n-- | The spec value of a %s.\n", e.Name)
+	fmt.Fprintf(&b, "%sToText : %s -> Text\n", fn, e.Name)
+	fmt.Fprintf(&b, "%sToText x = case x of\n", fn)
+	for _, v := range e.Values {
+		fmt.Fprintf(&b, "  %s -> %s\n", v.Constructor, strconv.Quote(v.Value))
+	}
+	fmt.Fprintf(&b, "\n-- | The %s with the given spec value, if any.\n", e.Name)
+	fmt.Fprintf(&b, "%sFromText : Text -> Optional %s\n", fn, e.Name)
+	fmt.Fprintf(&b, "%sFromText t = case t of\n", fn)
+	for _, v := range e.Values {
+		fmt.Fprintf(&b, "  %s -> Some %s\n", strconv.Quote(v.Value), v.Constructor)
+	}
+	fmt.Fprintf(&b, "  _ -> None\n")
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
@@ -0,0 +1,113 @@
+package damlgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Status string
+
+func (Status) EnumOptions() []string { return []string{"Active", "Closed"} }
+
+type Phase string
+
+func (Phase) EnumOptions() []string { return []string{"Active", "Planned", "2024"} }
+
+type Tier string
+
+func (Tier) EnumOptions() []string { return []string{"Gold", "Gold!", "Phase"} }
+
+type Facility struct {
+	Status Status `daml:"status"`
+	Phase  Phase  `daml:"phase"`
+	Tier   Tier   `daml:"tier"`
+}
+
+type Carrier struct {
+	Status Status `daml:"status"`
+}
+
+func constructors(e Enum) []string {
+	var cs []string
+	for _, v := range e.Values {
+		cs = append(cs, v.Constructor)
+	}
+	return cs
+}
+
+func TestResolveEnums(t *testing.T) {
+	p := Project{Name: "test", Version: "1.0.0", Namespace: "Test", Schemas: []Schema{
+		{Module: "Facility", Root: Facility{}},
+		{Module: "Carrier", Root: &Carrier{}},
+	}}
+	enums, err := p.Enums()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		module, name string
+		want         []string
+	}{
+		// Status is shared, and Active is also a constructor of Phase in
+		// Facility, which imports Common: Common's yields, so that it reads
+		// the same in Carrier.
+		{"Common", "Status", []string{"StatusActive", "Closed"}},
+		{"Facility", "Phase", []string{"Active", "Planned", "Phase2024"}},
+		// Gold twice within one enum is prefixed and numbered, and Phase
+		// names a type.
+		{"Facility", "Tier", []string{"TierGold", "TierGold2", "TierPhase"}},
+	}
+	for _, tt := range tests {
+		e, ok := enums[tt.module][tt.name]
+		if !ok {
+			t.Errorf("no enum %s in module %s: %v", tt.name, tt.module, enums)
+			continue
+		}
+		if got := constructors(e); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s.%s constructors = %v, want %v", tt.module, tt.name, got, tt.want)
+		}
+	}
+	if _, ok := enums["Carrier"]["Status"]; ok {
+		t.Errorf("Status declared in Carrier as well as in Common")
+	}
+
+	files, err := p.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	common := string(files["daml/Test/Common.daml"])
+	if !strings.Contains(common, "  = StatusActive\n  | Closed\n") {
+		t.Errorf("Common.daml does not declare the resolved constructors:\n%s", common)
+	}
+}
+
+func TestResolveEnumsDiffering(t *testing.T) {
+	// Enums of the same name but different values are not shared, and are
+	// resolved in each module on their own.
+	type other struct {
+		Status OtherStatus `daml:"status"`
+	}
+	p := Project{Name: "test", Version: "1.0.0", Namespace: "Test", Schemas: []Schema{
+		{Module: "Facility", Root: Facility{}},
+		{Module: "Other", Root: other{}},
+	}}
+	p.Schemas[1].TypeMap = map[string]interface{}{"Status": OtherStatus(""), "Other": other{}}
+	enums, err := p.Enums()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := enums["Common"]["Status"]; ok {
+		t.Fatalf("differing Status enums were shared: %v", enums["Common"]["Status"])
+	}
+	if got, want := constructors(enums["Facility"]["Status"]), []string{"StatusActive", "Closed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Facility.Status constructors = %v, want %v", got, want)
+	}
+	if got, want := constructors(enums["Other"]["Status"]), []string{"Active", "Retired"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Other.Status constructors = %v, want %v", got, want)
+	}
+}
+
+type OtherStatus string
+
+func (OtherStatus) EnumOptions() []string { return []string{"Active", "Retired"} }
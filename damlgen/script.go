@@ -0,0 +1,274 @@
+package damlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// renderScript : declare each sample as a DAML value of m's root record, and
+// a Daml Script that registers them all on the ledger, accepting the contact
+// role where the sample names a contact.
+func (p Project) renderScript(m *module, common *module, samples []interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "-- Code generated by okf daml. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "module %s where\n\n", p.qualified("Scripts."+m.name))
+	fmt.Fprintf(&b, "import Daml.Script\n")
+	if len(common.decls) > 0 {
+		fmt.Fprintf(&b, "import %s\n", p.qualified(common.name))
+	}
+	fmt.Fprintf(&b, "import %s\n", p.qualified(m.name))
+
+	names := make([]string, len(samples))
+	for i, sample := range samples {
+		v := reflect.ValueOf(sample)
+		for v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		names[i] = fmt.Sprintf("%sSample%d", strings.ToLower(m.name), i+1)
+		lit, err := m.literal(v, 0, common)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i+1, err)
+		}
+		fmt.Fprintf(&b, "\n%s : %s\n", names[i], m.root)
+		fmt.Fprintf(&b, "%s = %s\n", names[i], lit)
+	}
+
+	fmt.Fprintf(&b, "\n-- | Registers the sample %s records on the ledger.\n", m.root)
+	fmt.Fprintf(&b, "registerSamples : Script ()\n")
+	fmt.Fprintf(&b, "registerSamples = do\n")
+	w := m.workflow
+	for i, sample := range samples {
+		if w == nil {
+			break
+		}
+		v := reflect.ValueOf(sample)
+		for v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		n := i + 1
+		fmt.Fprintf(&b, "  %s%d <- allocateParty %s\n", w.owner, n, damlString(agentName(v, w.ownerField, "Owner")))
+		fmt.Fprintf(&b, "  registration%d <- submit %s%d do\n", n, w.owner, n)
+		fmt.Fprintf(&b, "    createCmd Registration with\n")
+		fmt.Fprintf(&b, "      %s = %s%d\n", w.owner, w.owner, n)
+		if w.contact != "" {
+			fmt.Fprintf(&b, "      %s = None\n", w.contact)
+		}
+		for _, o := range w.observers {
+			if o.list {
+				fmt.Fprintf(&b, "      %s = []\n", o.name)
+			} else {
+				fmt.Fprintf(&b, "      %s = None\n", o.name)
+			}
+		}
+		fmt.Fprintf(&b, "      record = %s\n", names[i])
+		if w.contact == "" || agentName(v, w.contactField, "") == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s%d <- allocateParty %s\n", w.contact, n, damlString(agentName(v, w.contactField, "")))
+		fmt.Fprintf(&b, "  invitation%d <- submit %s%d do\n", n, w.owner, n)
+		fmt.Fprintf(&b, "    exerciseCmd registration%d InviteContact with invitee = %s%d\n", n, w.contact, n)
+		fmt.Fprintf(&b, "  _ <- submit %s%d do\n", w.contact, n)
+		fmt.Fprintf(&b, "    exerciseCmd invitation%d AcceptContactRole\n", n)
+	}
+	fmt.Fprintf(&b, "  pure ()\n")
+	return b.Bytes(), nil
+}
+
+// agentName : the name of the Agent in the root field with Go name field,
+// or def if it has none.
+func agentName(root reflect.Value, field string, def string) string {
+	agent := root.FieldByName(field)
+	if !agent.IsValid() {
+		return def
+	}
+	if name := agent.FieldByName("Name"); name.IsValid() && name.Kind() == reflect.String && name.String() != "" {
+		return name.String()
+	}
+	return def
+}
+
+// literal : render v as a DAML expression of its generated type. Records
+// span several lines, with their fields indented two columns past indent.
+func (m *module) literal(v reflect.Value, indent int, common *module) (string, error) {
+	t := v.Type()
+	if d := m.lookup(t, common); d != nil && d.enum != nil {
+		c, ok := d.enum.Constructor(v.String())
+		if !ok {
+			return "", fmt.Errorf("%q is not a %s", v.String(), d.enum.Name)
+		}
+		return c, nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "None", nil
+		}
+		elem, err := m.literal(v.Elem(), indent, common)
+		if err != nil {
+			return "", err
+		}
+		return "Some " + parenthesize(elem), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return damlString("null"), nil
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return damlString(string(data)), nil
+	case reflect.String:
+		return damlString(v.String()), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "True", nil
+		}
+		return "False", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return negate(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return decimal(v.Float()), nil
+	case reflect.Slice, reflect.Array:
+		return m.listLiteral(v, indent, common)
+	case reflect.Struct:
+		return m.recordLiteral(v, indent, common)
+	}
+	return "", fmt.Errorf("no DAML literal for Go type %s", t)
+}
+
+func (m *module) lookup(t reflect.Type, common *module) *decl {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return nil
+	}
+	name, err := m.nameOf(t)
+	if err != nil {
+		return nil
+	}
+	if d, ok := m.decls[name]; ok {
+		return d
+	}
+	return common.decls[name]
+}
+
+func (m *module) recordLiteral(v reflect.Value, indent int, common *module) (string, error) {
+	t := v.Type()
+	name, err := m.nameOf(t)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	pad := strings.Repeat(" ", indent+2)
+	fields := 0
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := fieldName(sf)
+		if sf.PkgPath != "" || name == "-" {
+			continue
+		}
+		value, err := m.literal(v.Field(i), indent+2, common)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", name, err)
+		}
+		if m.optional(sf) {
+			if v.Field(i).IsZero() {
+				value = "None"
+			} else {
+				value = "Some " + parenthesize(value)
+			}
+		}
+		if fields == 0 {
+			b.WriteString(" with")
+		}
+		if strings.HasPrefix(value, "\n") {
+			fmt.Fprintf(&b, "\n%s%s =%s", pad, name, value)
+		} else {
+			fmt.Fprintf(&b, "\n%s%s = %s", pad, name, value)
+		}
+		fields++
+	}
+	return b.String(), nil
+}
+
+// listLiteral : render a list. Lists of scalars stay on one line; lists of
+// records start on a new line below the field, so that their leading commas
+// are indented past the enclosing record's fields.
+func (m *module) listLiteral(v reflect.Value, indent int, common *module) (string, error) {
+	if v.Len() == 0 {
+		return "[]", nil
+	}
+	items := make([]string, v.Len())
+	multiline := false
+	for i := range items {
+		item, err := m.literal(v.Index(i), indent+4, common)
+		if err != nil {
+			return "", fmt.Errorf("[%d]: %v", i, err)
+		}
+		items[i] = item
+		multiline = multiline || strings.Contains(item, "\n")
+	}
+	if !multiline {
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	pad := strings.Repeat(" ", indent+2)
+	var b strings.Builder
+	for i, item := range items {
+		sep := ","
+		if i == 0 {
+			sep = "["
+		}
+		fmt.Fprintf(&b, "\n%s%s %s", pad, sep, item)
+	}
+	fmt.Fprintf(&b, "\n%s]", pad)
+	return b.String(), nil
+}
+
+// damlString : quote s as a DAML Text literal.
+func damlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < ' ' || r == 0x7f:
+			// Decimal escape, terminated by the empty escape \& so that a
+			// following digit is not read as part of it.
+			fmt.Fprintf(&b, `\%d\&`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// decimal : format f as a DAML Decimal literal, which has at most 10
+// fractional digits.
+func decimal(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 10, 64)
+	s = strings.TrimRight(s, "0")
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+	return negate(s)
+}
+
+func negate(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return "(" + s + ")"
+	}
+	return s
+}
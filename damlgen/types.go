@@ -0,0 +1,347 @@
+package damlgen
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// decl : a top-level DAML declaration for a named Go type.
+type decl struct {
+	text string
+	// refs : names of the declared types the declaration refers to.
+	refs []string
+	// enum : set if the declaration is a variant generated from an enum type.
+	enum *Enum
+}
+
+type module struct {
+	name  string
+	root  string
+	decls map[string]*decl
+	// rootType : the Go type of the root record, named by its Go name.
+	rootType reflect.Type
+	// names : DAML names of the other named types, from the schema's type
+	// map. If nil, every type is named by its Go name.
+	names map[reflect.Type]string
+	// workflow : templates for the root record, if it has an owner.
+	workflow *workflow
+	// compact : whether optional fields are declared Optional.
+	compact bool
+	// missing : if set, the types typeExpr reaches that names has no entry
+	// for are collected here and named by their Go name, rather than failing
+	// generation, so that CheckTypeMap can report them all.
+	missing map[reflect.Type]bool
+}
+
+// typeExpr : return the DAML type expression for t, declaring t (and the
+// types it refers to) in m if it is a named type.
+func (m *module) typeExpr(t reflect.Type) (string, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := m.typeExpr(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "Optional " + parenthesize(elem), nil
+	case reflect.Slice, reflect.Array:
+		elem, err := m.typeExpr(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "[" + elem + "]", nil
+	}
+	if t.Name() == "" || t.PkgPath() == "" {
+		return builtin(t)
+	}
+	name, err := m.nameOf(t)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := m.decls[name]; ok {
+		return name, nil
+	}
+	// Reserve the name first so recursive types terminate.
+	d := &decl{}
+	m.decls[name] = d
+	if t.Kind() == reflect.Struct {
+		err = m.record(d, name, t)
+	} else {
+		err = m.alias(d, name, t)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", name, err)
+	}
+	return name, nil
+}
+
+// nameOf : return the DAML name of the named type t.
+func (m *module) nameOf(t reflect.Type) (string, error) {
+	if m.names == nil || t == m.rootType {
+		return t.Name(), nil
+	}
+	name, ok := m.names[t]
+	if !ok {
+		if m.missing != nil {
+			m.missing[t] = true
+			return t.Name(), nil
+		}
+		return "", fmt.Errorf("type %s has no DAML mapping in the type map", t)
+	}
+	return name, nil
+}
+
+// builtin : return the DAML type for an unnamed or predeclared Go type.
+func builtin(t reflect.Type) (string, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return "Text", nil
+	case reflect.Bool:
+		return "Bool", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "Int", nil
+	case reflect.Float32, reflect.Float64:
+		return "Decimal", nil
+	}
+	return "", fmt.Errorf("no DAML type for Go type %s", t)
+}
+
+// declarable : check that the named type t can be declared in DAML,
+// assuming the named types it refers to can be.
+func declarable(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" || fieldName(sf) == "-" {
+				continue
+			}
+			if err := expressible(sf.Type); err != nil {
+				return fmt.Errorf("field %s: %v", sf.Name, err)
+			}
+		}
+		return nil
+	case reflect.Interface:
+		return nil
+	}
+	_, err := builtin(t)
+	return err
+}
+
+// expressible : check that a field of type t has a DAML type, assuming the
+// named types it refers to are declared.
+func expressible(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return expressible(t.Elem())
+	}
+	if t.Name() != "" && t.PkgPath() != "" {
+		return nil
+	}
+	_, err := builtin(t)
+	return err
+}
+
+// alias : declare a named non-struct type as a synonym of its DAML type, or
+// as a variant if it is an enum with known values. Interface types hold
+// arbitrary values and are carried as JSON text.
+func (m *module) alias(d *decl, name string, t reflect.Type) error {
+	if options, ok := spec.EnumOptions(t); ok && len(options) > 0 {
+		// Rendered as is, so that extractCommon can compare it with the
+		// enums of other modules before constructor clashes are resolved.
+		d.enum = newEnum(name, options)
+		d.text = renderEnum(d.enum)
+		return nil
+	}
+	if t.Kind() == reflect.Interface {
+		d.text = fmt.Sprintf("-- | Free-form value, encoded as JSON.\ntype %s = Text\n", name)
+		return nil
+	}
+	underlying, err := builtin(t)
+	if err != nil {
+		return err
+	}
+	d.text = fmt.Sprintf("type %s = %s\n", name, underlying)
+	return nil
+}
+
+// record : declare a struct type as a DAML record.
+func (m *module) record(d *decl, name string, t reflect.Type) error {
+	var b bytes.Buffer
+	refs := map[string]bool{}
+	fmt.Fprintf(&b, "data %s = %s", name, name)
+	fields := 0
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+		expr, err := m.typeExpr(sf.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", sf.Name, err)
+		}
+		if m.optional(sf) {
+			expr = "Optional " + parenthesize(expr)
+		}
+		for _, word := range strings.FieldsFunc(expr, func(r rune) bool { return r == '[' || r == ']' || r == ' ' || r == '(' || r == ')' }) {
+			if _, ok := m.decls[word]; ok {
+				refs[word] = true
+			}
+		}
+		if fields == 0 {
+			fmt.Fprintf(&b, "\n  with\n")
+		}
+		fmt.Fprintf(&b, "    %s : %s\n", name, expr)
+		fields++
+	}
+	if fields == 0 {
+		fmt.Fprintf(&b, " {}\n")
+	}
+	fmt.Fprintf(&b, "  deriving (Eq, Show)\n")
+	d.text = b.String()
+	for ref := range refs {
+		d.refs = append(d.refs, ref)
+	}
+	sort.Strings(d.refs)
+	return nil
+}
+
+// keywords : DAML reserved words that cannot be used as field names.
+var keywords = map[string]bool{
+	"agreement": true, "case": true, "choice": true, "class": true, "controller": true,
+	"data": true, "deriving": true, "do": true, "else": true, "ensure": true,
+	"exists": true, "forall": true, "if": true, "import": true, "in": true,
+	"instance": true, "key": true, "let": true, "maintainer": true, "module": true,
+	"observer": true, "of": true, "signatory": true, "template": true, "then": true,
+	"type": true, "where": true, "with": true,
+}
+
+// fieldName : the DAML field name of sf, from its daml tag or its
+// lower-camel-cased Go name. Reserved words get a trailing underscore.
+func fieldName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("daml"), ",")[0]
+	if name == "" {
+		name = lowerFirst(sf.Name)
+	}
+	if keywords[name] {
+		name += "_"
+	}
+	return name
+}
+
+// optional : whether field sf is declared Optional in compact mode, i.e. it
+// is not required and its zero value would be omitted from YAML and JSON.
+// Lists are not wrapped: an empty list already stands for no values, and
+// pointers are Optional anyway.
+func (m *module) optional(sf reflect.StructField) bool {
+	if !m.compact || (spec.Field{StructField: sf}).Required() {
+		return false
+	}
+	switch sf.Type.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return false
+	}
+	return true
+}
+
+var unescaped = strings.NewReplacer(`\\`, "", `\"`, "")
+
+// parenthesize : wrap expr in parentheses if it is more than one token.
+// Text literals, whose only unescaped quotes are the enclosing ones, are a
+// single token.
+func parenthesize(expr string) string {
+	if len(expr) >= 2 && expr[0] == '"' && !strings.Contains(unescaped.Replace(expr[1:len(expr)-1]), `"`) {
+		return expr
+	}
+	if strings.ContainsRune(expr, ' ') {
+		return "(" + expr + ")"
+	}
+	return expr
+}
+
+// order : names of m's declarations, root record first and the rest sorted.
+func (m *module) order() []string {
+	names := make([]string, 0, len(m.decls))
+	for name := range m.decls {
+		if name != m.root {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := m.decls[m.root]; ok {
+		names = append([]string{m.root}, names...)
+	}
+	return names
+}
+
+// uses : whether any declaration in m refers to a type declared in other.
+func (m *module) uses(other *module) bool {
+	for _, d := range m.decls {
+		for _, ref := range d.refs {
+			if _, local := m.decls[ref]; !local {
+				if _, ok := other.decls[ref]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// extractCommon : move the declarations that two or more modules make
+// identically, and whose referenced types are themselves common, into a
+// Common module. Root records always stay in their own module.
+func extractCommon(modules []*module) *module {
+	common := &module{name: "Common", decls: map[string]*decl{}}
+	first := map[string]*decl{}
+	count := map[string]int{}
+	conflict := map[string]bool{}
+	for _, m := range modules {
+		for name, d := range m.decls {
+			if name == m.root {
+				continue
+			}
+			if prev, ok := first[name]; !ok {
+				first[name] = d
+			} else if prev.text != d.text {
+				conflict[name] = true
+			}
+			count[name]++
+		}
+	}
+	candidates := map[string]*decl{}
+	for name, d := range first {
+		if count[name] >= 2 && !conflict[name] {
+			candidates[name] = d
+		}
+	}
+	// Drop candidates that refer to non-candidates until nothing changes.
+	for changed := true; changed; {
+		changed = false
+		for name, d := range candidates {
+			for _, ref := range d.refs {
+				if _, ok := candidates[ref]; !ok {
+					delete(candidates, name)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for name, d := range candidates {
+		common.decls[name] = d
+		for _, m := range modules {
+			delete(m.decls, name)
+		}
+	}
+	return common
+}
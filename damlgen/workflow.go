@@ -0,0 +1,196 @@
+package damlgen
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// workflow : the parties and fields of a root record that drive its
+// generated templates. Party roles are taken from the record's Agent fields:
+// "owner" signs the registration, "contact" is a role the invited party
+// accepts, and every other Agent field is an observer.
+type workflow struct {
+	root    string
+	owner   string
+	contact string
+	// ownerField, contactField : Go names of the owner and contact fields.
+	ownerField   string
+	contactField string
+	observers    []party
+	// status : field holding the record's FacilityStatus, if any.
+	status string
+	// reviews : field holding the record's []CustomerReview, if any.
+	reviews string
+	// rated : whether CustomerReview has a rating to range-check.
+	rated bool
+	// optionalStatus, optionalRating : whether the status and rating fields
+	// are declared Optional.
+	optionalStatus, optionalRating bool
+}
+
+type party struct {
+	name string
+	list bool
+}
+
+// newWorkflow : inspect root for the fields workflows need. It returns nil
+// if root has no owner Agent, since every template is signed by the owner.
+// optional reports whether a field is declared Optional.
+func newWorkflow(root reflect.Type, optional func(reflect.StructField) bool) *workflow {
+	w := &workflow{root: root.Name()}
+	for i := 0; i < root.NumField(); i++ {
+		sf := root.Field(i)
+		name := fieldName(sf)
+		if sf.PkgPath != "" || name == "-" {
+			continue
+		}
+		t, list := sf.Type, false
+		if t.Kind() == reflect.Slice {
+			t, list = t.Elem(), true
+		}
+		switch {
+		case t.Name() == "Agent" && !list && name == "owner":
+			w.owner, w.ownerField = name, sf.Name
+		case t.Name() == "Agent" && !list && name == "contact":
+			w.contact, w.contactField = name, sf.Name
+		case t.Name() == "Agent":
+			w.observers = append(w.observers, party{name: name, list: list})
+		case t.Name() == "FacilityStatus" && !list:
+			w.status = name
+			w.optionalStatus = optional(sf)
+		case t.Name() == "CustomerReview" && list:
+			w.reviews = name
+			for j := 0; j < t.NumField(); j++ {
+				if fieldName(t.Field(j)) == "rating" {
+					w.rated = true
+					w.optionalRating = optional(t.Field(j))
+				}
+			}
+		}
+	}
+	if w.owner == "" {
+		return nil
+	}
+	return w
+}
+
+// render : declare the templates for the workflow.
+func (w *workflow) render() string {
+	var b bytes.Buffer
+	signatories := []string{w.owner}
+	if w.contact != "" {
+		signatories = append(signatories, w.contact)
+	}
+	var observers, roles []string
+	for _, p := range w.observers {
+		observers = append(observers, p.name)
+	}
+	roles = append(append(roles, signatories...), observers...)
+
+	fmt.Fprintf(&b, "-- | A registered %s record, signed by its owner. The parties stand for the\n", w.root)
+	fmt.Fprintf(&b, "-- Agents in the record's %s fields.\n", strings.Join(roles, ", "))
+	fmt.Fprintf(&b, "template Registration\n")
+	fmt.Fprintf(&b, "  with\n")
+	fmt.Fprintf(&b, "    %s : Party\n", w.owner)
+	if w.contact != "" {
+		fmt.Fprintf(&b, "    %s : Optional Party\n", w.contact)
+	}
+	for _, p := range w.observers {
+		if p.list {
+			fmt.Fprintf(&b, "    %s : [Party]\n", p.name)
+		} else {
+			fmt.Fprintf(&b, "    %s : Optional Party\n", p.name)
+		}
+	}
+	fmt.Fprintf(&b, "    record : %s\n", w.root)
+	fmt.Fprintf(&b, "  where\n")
+	fmt.Fprintf(&b, "    signatory %s\n", strings.Join(signatories, ", "))
+	if len(observers) > 0 {
+		fmt.Fprintf(&b, "    observer %s\n", strings.Join(observers, ", "))
+	}
+	if w.status != "" {
+		fmt.Fprintf(&b, "\n    choice SetFacilityStatus : ContractId Registration\n")
+		fmt.Fprintf(&b, "      with\n")
+		fmt.Fprintf(&b, "        status : FacilityStatus\n")
+		fmt.Fprintf(&b, "      controller %s\n", w.owner)
+		fmt.Fprintf(&b, "      do\n")
+		if w.optionalStatus {
+			fmt.Fprintf(&b, "        let updated = record with %s = Some status\n", w.status)
+		} else {
+			fmt.Fprintf(&b, "        let updated = record with %s = status\n", w.status)
+		}
+		fmt.Fprintf(&b, "        create this with record = updated\n")
+	}
+	if w.contact != "" {
+		fmt.Fprintf(&b, "\n    choice InviteContact : ContractId ContactInvitation\n")
+		fmt.Fprintf(&b, "      with\n")
+		fmt.Fprintf(&b, "        invitee : Party\n")
+		fmt.Fprintf(&b, "      controller %s\n", w.owner)
+		fmt.Fprintf(&b, "      do create ContactInvitation with registration = this; invitee\n")
+	}
+	if w.reviews != "" {
+		fmt.Fprintf(&b, "\n    choice AddReview : ContractId Registration\n")
+		fmt.Fprintf(&b, "      with\n")
+		fmt.Fprintf(&b, "        review : CustomerReview\n")
+		fmt.Fprintf(&b, "      controller %s\n", w.owner)
+		fmt.Fprintf(&b, "      do\n")
+		fmt.Fprintf(&b, "        let updated = record with %s = record.%s ++ [review]\n", w.reviews, w.reviews)
+		fmt.Fprintf(&b, "        create this with record = updated\n")
+	}
+
+	if w.contact != "" {
+		fmt.Fprintf(&b, "\n-- | An offer from the owner for the invitee to become the %s of a\n", w.contact)
+		fmt.Fprintf(&b, "-- registration. The registration is held by the invitation until the\n")
+		fmt.Fprintf(&b, "-- invitee answers or the owner withdraws it.\n")
+		fmt.Fprintf(&b, "template ContactInvitation\n")
+		fmt.Fprintf(&b, "  with\n")
+		fmt.Fprintf(&b, "    registration : Registration\n")
+		fmt.Fprintf(&b, "    invitee : Party\n")
+		fmt.Fprintf(&b, "  where\n")
+		fmt.Fprintf(&b, "    signatory signatory registration\n")
+		fmt.Fprintf(&b, "    observer invitee\n")
+		fmt.Fprintf(&b, "\n    choice AcceptContactRole : ContractId Registration\n")
+		fmt.Fprintf(&b, "      controller invitee\n")
+		fmt.Fprintf(&b, "      do create registration with %s = Some invitee\n", w.contact)
+		fmt.Fprintf(&b, "\n    choice DeclineContactRole : ContractId Registration\n")
+		fmt.Fprintf(&b, "      controller invitee\n")
+		fmt.Fprintf(&b, "      do create registration\n")
+		fmt.Fprintf(&b, "\n    choice WithdrawContactInvitation : ContractId Registration\n")
+		fmt.Fprintf(&b, "      controller registration.%s\n", w.owner)
+		fmt.Fprintf(&b, "      do create registration\n")
+	}
+
+	if w.reviews != "" {
+		fmt.Fprintf(&b, "\n-- | A customer review proposed by a reviewer, added to the registration's\n")
+		fmt.Fprintf(&b, "-- %s once the owner accepts it.\n", w.reviews)
+		fmt.Fprintf(&b, "template ReviewProposal\n")
+		fmt.Fprintf(&b, "  with\n")
+		fmt.Fprintf(&b, "    reviewer : Party\n")
+		fmt.Fprintf(&b, "    %s : Party\n", w.owner)
+		fmt.Fprintf(&b, "    review : CustomerReview\n")
+		fmt.Fprintf(&b, "  where\n")
+		fmt.Fprintf(&b, "    signatory reviewer\n")
+		fmt.Fprintf(&b, "    observer %s\n", w.owner)
+		if w.rated && w.optionalRating {
+			fmt.Fprintf(&b, "    ensure case review.rating of\n")
+			fmt.Fprintf(&b, "      None -> True\n")
+			fmt.Fprintf(&b, "      Some rating -> rating >= 1 && rating <= 5\n")
+		} else if w.rated {
+			fmt.Fprintf(&b, "    ensure review.rating >= 1 && review.rating <= 5\n")
+		}
+		fmt.Fprintf(&b, "\n    choice AcceptReview : ContractId Registration\n")
+		fmt.Fprintf(&b, "      with\n")
+		fmt.Fprintf(&b, "        registrationId : ContractId Registration\n")
+		fmt.Fprintf(&b, "      controller %s\n", w.owner)
+		fmt.Fprintf(&b, "      do exercise registrationId AddReview with review\n")
+		fmt.Fprintf(&b, "\n    choice RejectReview : ()\n")
+		fmt.Fprintf(&b, "      controller %s\n", w.owner)
+		fmt.Fprintf(&b, "      do pure ()\n")
+		fmt.Fprintf(&b, "\n    choice WithdrawReview : ()\n")
+		fmt.Fprintf(&b, "      controller reviewer\n")
+		fmt.Fprintf(&b, "      do pure ()\n")
+	}
+	return b.String()
+}
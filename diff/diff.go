@@ -0,0 +1,395 @@
+// Package diff compares two versions of a record field by field, rather
+// than line by line, so that reviewers see what changed in a record and not
+// how its file was laid out. Items of lists are matched by their id,
+// identifier or serial number where they have one, so that reordering
+// equipment, agents or reviews shows as moves rather than as every item
+// changing. Changes can be written
+// as text or as an RFC 6902 JSON Patch, which Apply and ApplyRecord apply.
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"gopkg.in/yaml.v2"
+)
+
+// Op : the kind of a change, named as in JSON Patch.
+type Op string
+
+const (
+	Add     Op = "add"
+	Remove  Op = "remove"
+	Replace Op = "replace"
+	Move    Op = "move"
+)
+
+// IdentityKeys : the keys that identify an item of a list, in order of
+// preference: agents by id, or by ref, customer reviews by identifier and
+// equipment by serial number. A list is matched by identity only if every
+// item on both sides has one and no two items on a side share one;
+// otherwise it is matched by position and content.
+var IdentityKeys = []string{"id", "ref", "identifier", "serial_number"}
+
+// Change : a difference between two trees. Changes are listed in the order
+// they are applied in, and Pointer and From hold for the tree as the
+// changes before have left it.
+type Change struct {
+	Op Op `json:"op"`
+	// Path : where the change is, as dotted keys, with list items given by
+	// their identity or else their index, e.g.
+	// "equipment[serial_number=SN-1].model" or "certifications[2]".
+	Path string `json:"path"`
+	// Pointer : the RFC 6901 JSON Pointer of the value changed.
+	Pointer string `json:"pointer"`
+	// From : for a move, the pointer of the value moved.
+	From string      `json:"from,omitempty"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+	switch c.Op {
+	case Add:
+		return fmt.Sprintf("added %s: %s", path, inline(c.New))
+	case Remove:
+		return fmt.Sprintf("removed %s: %s", path, inline(c.Old))
+	case Move:
+		return fmt.Sprintf("moved %s from %s to %s", path, position(c.From), position(c.Pointer))
+	}
+	return fmt.Sprintf("changed %s: %s -> %s", path, inline(c.Old), inline(c.New))
+}
+
+// Records : return the changes from old to new, two typed records of the
+// same kind, comparing them as trees with the keys of format f: YAML for
+// reading, JSON for a JSON Patch of the JSON encoding.
+func Records(old, new interface{}, f codec.Format) ([]Change, error) {
+	o, err := codec.ToTree(old, f)
+	if err != nil {
+		return nil, err
+	}
+	n, err := codec.ToTree(new, f)
+	if err != nil {
+		return nil, err
+	}
+	return Trees(o, n), nil
+}
+
+// Trees : return the changes from old to new, two trees as decoded by
+// codec.DecodeTree.
+func Trees(old, new interface{}) []Change {
+	d := &differ{}
+	d.compare("", "", old, new)
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) compare(path, ptr string, o, n interface{}) {
+	if equal(o, n) {
+		return
+	}
+	if om, ok := o.(codec.Map); ok {
+		if nm, ok := n.(codec.Map); ok {
+			d.compareMaps(path, ptr, om, nm)
+			return
+		}
+	}
+	if ol, ok := o.([]interface{}); ok {
+		if nl, ok := n.([]interface{}); ok {
+			if oid, nid, ok := identities(ol, nl); ok {
+				d.compareByIdentity(path, ptr, ol, nl, oid, nid)
+			} else {
+				d.compareByPosition(path, ptr, ol, nl)
+			}
+			return
+		}
+	}
+	d.add(Change{Op: Replace, Path: path, Pointer: ptr, Old: o, New: n})
+}
+
+func (d *differ) compareMaps(path, ptr string, o, n codec.Map) {
+	for _, item := range o {
+		p, q := join(path, item.Key), ptr+"/"+escape(item.Key)
+		if v, ok := n.Get(item.Key); ok {
+			d.compare(p, q, item.Value, v)
+		} else {
+			d.add(Change{Op: Remove, Path: p, Pointer: q, Old: item.Value})
+		}
+	}
+	for _, item := range n {
+		if _, ok := o.Get(item.Key); !ok {
+			d.add(Change{Op: Add, Path: join(path, item.Key), Pointer: ptr + "/" + escape(item.Key), New: item.Value})
+		}
+	}
+}
+
+// compareByIdentity : compare lists whose items have the identities oid
+// and nid: remove the items that are gone, then bring the others into
+// their new order, moving or adding each in turn and comparing those kept.
+func (d *differ) compareByIdentity(path, ptr string, o, n []interface{}, oid, nid []string) {
+	kept := map[string]bool{}
+	for _, id := range nid {
+		kept[id] = true
+	}
+	old := map[string]interface{}{}
+	var current []string
+	for i, id := range oid {
+		old[id] = o[i]
+		current = append(current, id)
+	}
+	for i := len(oid) - 1; i >= 0; i-- {
+		if !kept[oid[i]] {
+			d.add(Change{Op: Remove, Path: path + "[" + oid[i] + "]", Pointer: ptr + "/" + strconv.Itoa(i), Old: o[i]})
+			current = append(current[:i], current[i+1:]...)
+		}
+	}
+	for j, id := range nid {
+		p, q := path+"["+id+"]", ptr+"/"+strconv.Itoa(j)
+		i := indexOf(current, id, j)
+		if i < 0 {
+			d.add(Change{Op: Add, Path: p, Pointer: q, New: n[j]})
+			current = append(current[:j], append([]string{id}, current[j:]...)...)
+			continue
+		}
+		if i != j {
+			d.add(Change{Op: Move, Path: p, Pointer: q, From: ptr + "/" + strconv.Itoa(i)})
+			current = append(current[:i], current[i+1:]...)
+			current = append(current[:j], append([]string{id}, current[j:]...)...)
+		}
+		d.compare(p, q, old[id], n[j])
+	}
+}
+
+// compareByPosition : compare lists item by item, keeping the longest
+// common subsequence of equal items and comparing the items changed in
+// between pairwise, so that an edited item shows as changed fields rather
+// than as removed and added.
+func (d *differ) compareByPosition(path, ptr string, o, n []interface{}) {
+	// lcs[i][j] : the length of the longest common subsequence of o[i:] and n[j:].
+	lcs := make([][]int, len(o)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(n)+1)
+	}
+	for i := len(o) - 1; i >= 0; i-- {
+		for j := len(n) - 1; j >= 0; j-- {
+			switch {
+			case equal(o[i], n[j]):
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	pos, i, j := 0, 0, 0
+	for i < len(o) || j < len(n) {
+		if i < len(o) && j < len(n) && equal(o[i], n[j]) {
+			pos, i, j = pos+1, i+1, j+1
+			continue
+		}
+		// Collect the run of items removed and added before the next
+		// common item.
+		var removed, added []interface{}
+		for i < len(o) || j < len(n) {
+			if i < len(o) && j < len(n) && equal(o[i], n[j]) {
+				break
+			}
+			if j >= len(n) || (i < len(o) && lcs[i+1][j] >= lcs[i][j+1]) {
+				removed = append(removed, o[i])
+				i++
+			} else {
+				added = append(added, n[j])
+				j++
+			}
+		}
+		for len(removed) > 0 && len(added) > 0 {
+			d.compare(index(path, pos), ptr+"/"+strconv.Itoa(pos), removed[0], added[0])
+			removed, added, pos = removed[1:], added[1:], pos+1
+		}
+		for _, v := range removed {
+			d.add(Change{Op: Remove, Path: index(path, pos), Pointer: ptr + "/" + strconv.Itoa(pos), Old: v})
+		}
+		for _, v := range added {
+			d.add(Change{Op: Add, Path: index(path, pos), Pointer: ptr + "/" + strconv.Itoa(pos), New: v})
+			pos++
+		}
+	}
+}
+
+// identities : return the identities of the items of o and n, and whether
+// every item has one that no other item of its list shares.
+func identities(o, n []interface{}) ([]string, []string, bool) {
+	oid, ok := identify(o)
+	if !ok {
+		return nil, nil, false
+	}
+	nid, ok := identify(n)
+	return oid, nid, ok
+}
+
+func identify(items []interface{}) ([]string, bool) {
+	ids := make([]string, len(items))
+	seen := map[string]bool{}
+	for i, item := range items {
+		m, ok := item.(codec.Map)
+		if !ok {
+			return nil, false
+		}
+		for _, key := range IdentityKeys {
+			if v, _ := m.Get(key); v != nil && fmt.Sprint(v) != "" {
+				ids[i] = key + "=" + fmt.Sprint(v)
+				break
+			}
+		}
+		if ids[i] == "" || seen[ids[i]] {
+			return nil, false
+		}
+		seen[ids[i]] = true
+	}
+	return ids, true
+}
+
+func indexOf(ids []string, id string, from int) int {
+	for i := from; i < len(ids); i++ {
+		if ids[i] == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// equal : whether a and b are the same tree, comparing numbers by value
+// and mappings regardless of key order.
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case codec.Map:
+		b, ok := b.(codec.Map)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for _, item := range a {
+			v, ok := b.Get(item.Key)
+			if !ok || !equal(item.Value, v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case int64, float64:
+		x, aok := toFloat(a)
+		y, bok := toFloat(b)
+		return aok && bok && x == y
+	}
+	return a == b
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func index(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// escape : escape key as a JSON Pointer reference token.
+func escape(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// position : the index a JSON Pointer into a list ends with.
+func position(ptr string) string {
+	return ptr[strings.LastIndex(ptr, "/")+1:]
+}
+
+// inline : format v on one line, as JSON.
+func inline(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// WriteText : write changes to w, one per line. Values added or removed
+// that are mappings or lists are written below their line as indented
+// YAML.
+func WriteText(w io.Writer, changes []Change) error {
+	for _, c := range changes {
+		var block interface{}
+		switch c.Op {
+		case Add:
+			block = c.New
+		case Remove:
+			block = c.Old
+		}
+		switch block.(type) {
+		case codec.Map, []interface{}:
+			path := c.Path
+			if path == "" {
+				path = "(root)"
+			}
+			fmt.Fprintf(w, "%s %s:\n", map[Op]string{Add: "added", Remove: "removed"}[c.Op], path)
+			data, err := yaml.Marshal(block)
+			if err != nil {
+				return err
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+			continue
+		}
+		fmt.Fprintln(w, c)
+	}
+	switch len(changes) {
+	case 0:
+		_, err := fmt.Fprintln(w, "no changes")
+		return err
+	case 1:
+		_, err := fmt.Fprintln(w, "1 change")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d changes\n", len(changes))
+	return err
+}
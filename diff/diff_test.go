@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
+)
+
+func TestTrees(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"equal", `{"a": 1, "b": [1, 2]}`, `{"b": [1, 2], "a": 1.0}`, nil},
+		{"mapping", `{"a": 1, "b": 2}`, `{"a": 3, "c": 4}`,
+			[]string{"changed a: 1 -> 3", "removed b: 2", "added c: 4"}},
+		{"type", `{"a": [1]}`, `{"a": {"b": 1}}`, []string{`changed a: [1] -> {"b":1}`}},
+		{"list by position", `{"a": [1, 2, 3]}`, `{"a": [1, 3, 4]}`,
+			[]string{"removed a[1]: 2", "added a[2]: 4"}},
+		{"edited item", `{"a": [{"x": 1, "y": 1}]}`, `{"a": [{"x": 1, "y": 2}]}`,
+			[]string{"changed a[0].y: 1 -> 2"}},
+		{"agents by id", `{"a": [{"id": "p"}, {"id": "q"}]}`, `{"a": [{"id": "q"}, {"id": "p", "name": "P"}]}`,
+			[]string{"moved a[id=q] from 1 to 0", `added a[id=p].name: "P"`}},
+		{"reviews by identifier",
+			`{"customer_reviews": [{"identifier": "r1", "rating": 3}, {"identifier": "r2", "rating": 5}]}`,
+			`{"customer_reviews": [{"identifier": "r2", "rating": 5}, {"identifier": "r1", "rating": 3}]}`,
+			[]string{"moved customer_reviews[identifier=r2] from 1 to 0"}},
+		{"equipment by serial number",
+			`{"equipment": [{"serial_number": "SN-1"}, {"serial_number": "SN-2"}]}`,
+			`{"equipment": [{"serial_number": "SN-3"}, {"serial_number": "SN-1", "model": "M"}]}`,
+			[]string{"removed equipment[serial_number=SN-2]: {\"serial_number\":\"SN-2\"}",
+				"added equipment[serial_number=SN-3]: {\"serial_number\":\"SN-3\"}",
+				"added equipment[serial_number=SN-1].model: \"M\""}},
+		{"id is preferred", `{"a": [{"id": "p", "identifier": "x"}, {"id": "q", "identifier": "x"}]}`,
+			`{"a": [{"id": "q", "identifier": "x"}, {"id": "p", "identifier": "x"}]}`,
+			[]string{"moved a[id=q] from 1 to 0"}},
+		{"shared identities match by position", `{"a": [{"identifier": "r"}, {"identifier": "r", "b": 1}]}`,
+			`{"a": [{"identifier": "r", "b": 1}, {"identifier": "r"}]}`,
+			[]string{`removed a[0]: {"identifier":"r"}`, `added a[1]: {"identifier":"r"}`}},
+		{"missing identities match by position", `{"a": [{"identifier": "r1"}, {"rating": 1}]}`,
+			`{"a": [{"rating": 1}, {"identifier": "r1"}]}`,
+			[]string{`removed a[0]: {"identifier":"r1"}`, `added a[1]: {"identifier":"r1"}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range Trees(tree(t, tt.old), tree(t, tt.new)) {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Trees = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordsSwappedReviews(t *testing.T) {
+	old := okw.NewSample()
+	reviews := old.CustomerReviews
+	if len(reviews) < 2 {
+		t.Fatalf("the sample has %d customer reviews, want at least 2", len(reviews))
+	}
+	new := okw.NewSample()
+	new.CustomerReviews[0], new.CustomerReviews[1] = reviews[1], reviews[0]
+	changes, err := Records(old, new, codec.YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "moved customer_reviews[identifier=" + reviews[1].Identifier + "] from 1 to 0"
+	if len(changes) != 1 || changes[0].String() != want {
+		t.Errorf("Records = %v, want [%s]", changes, want)
+	}
+}
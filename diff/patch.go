@@ -0,0 +1,403 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/migration"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// Operation : an RFC 6902 JSON Patch operation: "add", "remove", "replace",
+// "move", "copy" or "test". Value is a tree, and From is set for move and
+// copy only.
+type Operation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// MarshalJSON : encode o as a JSON Patch operation, with a value for add,
+// replace and test only, even if the value is null.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	m := codec.Map{{Key: "op", Value: o.Op}, {Key: "path", Value: o.Path}}
+	switch o.Op {
+	case "move", "copy":
+		m = append(m, codec.MapItem{Key: "from", Value: o.From})
+	case "add", "replace", "test":
+		m = append(m, codec.MapItem{Key: "value", Value: o.Value})
+	}
+	return m.MarshalJSON()
+}
+
+// UnmarshalJSON : decode a JSON Patch operation, keeping the order of the
+// keys of its value.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Op    string          `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Path == nil {
+		return fmt.Errorf("%s operation has no path", raw.Op)
+	}
+	*o = Operation{Op: raw.Op, Path: *raw.Path}
+	switch raw.Op {
+	case "move", "copy":
+		if raw.From == nil {
+			return fmt.Errorf("%s operation has no from", raw.Op)
+		}
+		o.From = *raw.From
+	case "add", "replace", "test":
+		if raw.Value == nil {
+			return fmt.Errorf("%s operation has no value", raw.Op)
+		}
+		v, err := codec.DecodeTree(raw.Value, codec.JSON)
+		if err != nil {
+			return err
+		}
+		o.Value = v
+	case "remove":
+	default:
+		return fmt.Errorf("unknown operation %q", raw.Op)
+	}
+	return nil
+}
+
+// Patch : return changes as JSON Patch operations.
+func Patch(changes []Change) []Operation {
+	ops := make([]Operation, len(changes))
+	for i, c := range changes {
+		ops[i] = Operation{Op: string(c.Op), Path: c.Pointer, From: c.From, Value: c.New}
+	}
+	return ops
+}
+
+// ParsePatch : decode data, a JSON Patch document.
+func ParsePatch(data []byte) ([]Operation, error) {
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// Apply : apply ops to tree in turn and return the result. tree is left
+// unchanged. If an operation fails, so does the patch, and the error names
+// the operation by its position.
+func Apply(tree interface{}, ops []Operation) (interface{}, error) {
+	doc := clone(tree)
+	for i, op := range ops {
+		var err error
+		if doc, err = apply(doc, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %v", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	switch op.Op {
+	case "add":
+		return put(doc, op.Path, clone(op.Value), false)
+	case "remove":
+		doc, _, err := take(doc, op.Path)
+		return doc, err
+	case "replace":
+		if _, err := get(doc, op.Path); err != nil {
+			return nil, err
+		}
+		return put(doc, op.Path, clone(op.Value), true)
+	case "move":
+		if op.From == op.Path {
+			return doc, nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s into itself", op.From)
+		}
+		doc, v, err := take(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return put(doc, op.Path, v, false)
+	case "copy":
+		v, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return put(doc, op.Path, clone(v), false)
+	case "test":
+		v, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(v, op.Value) {
+			return nil, fmt.Errorf("value is %s, not %s", inline(v), inline(op.Value))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// tokens : split ptr, an RFC 6901 JSON Pointer, into its unescaped
+// reference tokens.
+func tokens(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("pointer %q does not start with /", ptr)
+	}
+	parts := strings.Split(ptr[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.Replace(strings.Replace(p, "~1", "/", -1), "~0", "~", -1)
+	}
+	return parts, nil
+}
+
+// element : parse token as an index into a list of n items; "-", the
+// position after the last item, is allowed if end is set.
+func element(token string, n int, end bool) (int, error) {
+	if token == "-" && end {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && token[0] == '0') {
+		return 0, fmt.Errorf("%q is not a list index", token)
+	}
+	max := n - 1
+	if end {
+		max = n
+	}
+	if i > max {
+		return 0, fmt.Errorf("index %d is out of range", i)
+	}
+	return i, nil
+}
+
+// get : return the value at ptr in doc.
+func get(doc interface{}, ptr string) (interface{}, error) {
+	parts, err := tokens(ptr)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parts {
+		switch t := doc.(type) {
+		case codec.Map:
+			v, ok := t.Get(p)
+			if !ok {
+				return nil, fmt.Errorf("no key %q", p)
+			}
+			doc = v
+		case []interface{}:
+			i, err := element(p, len(t), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = t[i]
+		default:
+			return nil, fmt.Errorf("cannot look up %q in a %s", p, kindOf(doc))
+		}
+	}
+	return doc, nil
+}
+
+// put : set the value at ptr in doc to v and return the new doc. In a list
+// v is inserted before the item at the index unless replace is set; in a
+// mapping it replaces any value of the key.
+func put(doc interface{}, ptr string, v interface{}, replace bool) (interface{}, error) {
+	parts, err := tokens(ptr)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return v, nil
+	}
+	parent, err := get(doc, pointer(parts[:len(parts)-1]))
+	if err != nil {
+		return nil, err
+	}
+	last := parts[len(parts)-1]
+	switch t := parent.(type) {
+	case codec.Map:
+		for i := range t {
+			if t[i].Key == last {
+				t[i].Value = v
+				return doc, nil
+			}
+		}
+		return set(doc, parts[:len(parts)-1], append(t, codec.MapItem{Key: last, Value: v}))
+	case []interface{}:
+		i, err := element(last, len(t), !replace)
+		if err != nil {
+			return nil, err
+		}
+		if replace {
+			t[i] = v
+			return doc, nil
+		}
+		list := append(t[:i:i], append([]interface{}{v}, t[i:]...)...)
+		return set(doc, parts[:len(parts)-1], list)
+	}
+	return nil, fmt.Errorf("cannot set %q in a %s", last, kindOf(parent))
+}
+
+// take : remove the value at ptr from doc, returning the new doc and the
+// value removed.
+func take(doc interface{}, ptr string) (interface{}, interface{}, error) {
+	parts, err := tokens(ptr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(parts) == 0 {
+		return nil, doc, nil
+	}
+	parent, err := get(doc, pointer(parts[:len(parts)-1]))
+	if err != nil {
+		return nil, nil, err
+	}
+	last := parts[len(parts)-1]
+	switch t := parent.(type) {
+	case codec.Map:
+		for i := range t {
+			if t[i].Key == last {
+				v := t[i].Value
+				doc, err = set(doc, parts[:len(parts)-1], append(t[:i:i], t[i+1:]...))
+				return doc, v, err
+			}
+		}
+		return nil, nil, fmt.Errorf("no key %q", last)
+	case []interface{}:
+		i, err := element(last, len(t), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := t[i]
+		doc, err = set(doc, parts[:len(parts)-1], append(t[:i:i], t[i+1:]...))
+		return doc, v, err
+	}
+	return nil, nil, fmt.Errorf("cannot remove %q from a %s", last, kindOf(parent))
+}
+
+// set : replace the mapping or list at the unescaped path parts of doc,
+// whose length has changed, with v, and return the new doc.
+func set(doc interface{}, parts []string, v interface{}) (interface{}, error) {
+	if len(parts) == 0 {
+		return v, nil
+	}
+	return put(doc, pointer(parts), v, true)
+}
+
+// pointer : return the JSON Pointer of the unescaped path parts. The root
+// is "", not "/", which is the empty key of the root.
+func pointer(parts []string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString("/" + escape(p))
+	}
+	return b.String()
+}
+
+func kindOf(v interface{}) string {
+	switch v.(type) {
+	case codec.Map:
+		return "mapping"
+	case []interface{}:
+		return "list"
+	case nil:
+		return "null"
+	}
+	return "scalar"
+}
+
+// clone : return a deep copy of tree.
+func clone(tree interface{}) interface{} {
+	switch t := tree.(type) {
+	case codec.Map:
+		m := make(codec.Map, len(t))
+		for i, item := range t {
+			m[i] = codec.MapItem{Key: item.Key, Value: clone(item.Value)}
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = clone(v)
+		}
+		return l
+	}
+	return tree
+}
+
+// ApplyRecord : apply ops to the JSON encoding of record, a typed record of
+// kind k, and decode and validate the result as ValidateData does. It
+// returns the new record and its validation result; the record should not
+// be used unless the result is valid. The error is set if the patch could
+// not be applied, or if it sets keys the kind does not have or values its
+// fields cannot hold, which decoding would otherwise silently drop.
+func ApplyRecord(v *validator.Validate, k document.Kind, record interface{}, ops []Operation) (interface{}, validation.Result, error) {
+	tree, err := codec.ToTree(record, codec.JSON)
+	if err != nil {
+		return nil, validation.Result{}, err
+	}
+	patched, err := Apply(tree, ops)
+	if err != nil {
+		return nil, validation.Result{}, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(patched); err != nil {
+		return nil, validation.Result{}, err
+	}
+	out := k.New()
+	result := validation.ValidateData(v, k, buf.Bytes(), codec.JSON, out)
+	if result.Error != "" {
+		return out, result, nil
+	}
+	lost, err := lost(k, patched, out)
+	if err != nil {
+		return nil, validation.Result{}, err
+	}
+	if len(lost) > 0 {
+		return nil, validation.Result{}, fmt.Errorf("the patch sets keys a %s record does not have, or values it cannot hold: %s", k.Name, strings.Join(lost, ", "))
+	}
+	return out, result, nil
+}
+
+// lost : return the pointers of the values in patched, the patched tree,
+// that decoding it into out, a record of kind k, dropped or changed. The
+// tree is first upgraded as decoding does, so that keys a migration step
+// or legacy tag renames are not counted; keys filled in with their zero
+// value, and null values decoded as one, are not counted either.
+func lost(k document.Kind, patched interface{}, out interface{}) ([]string, error) {
+	doc, _, err := document.Migrations().Migrate(migration.Document{Kind: k.Name, Format: codec.JSON, Tree: patched}, "")
+	if err != nil {
+		return nil, err
+	}
+	upgraded, _ := codec.Upgrade(doc.Tree, reflect.TypeOf(out), codec.JSON)
+	decoded, err := codec.ToTree(out, codec.JSON)
+	if err != nil {
+		return nil, err
+	}
+	var pointers []string
+	for _, c := range Trees(upgraded, decoded) {
+		if c.Op == Add || (c.Op == Replace && c.Old == nil) {
+			continue
+		}
+		pointers = append(pointers, c.Pointer)
+	}
+	return pointers, nil
+}
@@ -0,0 +1,347 @@
+package diff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+)
+
+func tree(t *testing.T, s string) interface{} {
+	v, err := codec.DecodeTree([]byte(s), codec.JSON)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+	return v
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		// want : the patched document, or if err is set, "".
+		want string
+		// err : a part of the error.
+		err string
+	}{
+		// The examples of RFC 6902, appendix A.
+		{"A.1 adding an object member", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`, ""},
+		{"A.2 adding an array element", `{"foo": ["bar", "baz"]}`,
+			`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`, ""},
+		{"A.3 removing an object member", `{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`, ""},
+		{"A.4 removing an array element", `{"foo": ["bar", "qux", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`, ""},
+		{"A.5 replacing a value", `{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`, ""},
+		{"A.6 moving a value", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`, ""},
+		{"A.7 moving an array element", `{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`, ""},
+		{"A.8 testing a value: success", `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`, ""},
+		{"A.9 testing a value: error", `{"baz": "qux"}`,
+			`[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			"", `operation 0 (test /baz): value is "qux", not "bar"`},
+		{"A.10 adding a nested member object", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`, ""},
+		{"A.11 ignoring unrecognized elements", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			`{"foo": "bar", "baz": "qux"}`, ""},
+		{"A.12 adding to a nonexistent target", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			"", `no key "baz"`},
+		{"A.14 ~ escape ordering", `{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": 10}]`,
+			`{"/": 9, "~1": 10}`, ""},
+		{"A.15 comparing strings and numbers", `{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": "10"}]`,
+			"", `value is 10, not "10"`},
+		{"A.16 adding an array value", `{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			`{"foo": ["bar", ["abc", "def"]]}`, ""},
+
+		// Edge cases of pointers and operations.
+		{"~1 is a slash", `{"a/b": 1}`,
+			`[{"op": "replace", "path": "/a~1b", "value": 2}]`,
+			`{"a/b": 2}`, ""},
+		{"~0 is a tilde", `{"m~n": 1}`,
+			`[{"op": "remove", "path": "/m~0n"}]`,
+			`{}`, ""},
+		{"empty key", `{"": 1}`,
+			`[{"op": "test", "path": "/", "value": 1}]`,
+			`{"": 1}`, ""},
+		{"whole document", `{"foo": 1}`,
+			`[{"op": "replace", "path": "", "value": [1]}]`,
+			`[1]`, ""},
+		{"- appends", `{"foo": [1, 2]}`,
+			`[{"op": "copy", "from": "/foo/0", "path": "/foo/-"}]`,
+			`{"foo": [1, 2, 1]}`, ""},
+		{"- is not an item", `{"foo": [1, 2]}`,
+			`[{"op": "remove", "path": "/foo/-"}]`,
+			"", `"-" is not a list index`},
+		{"- cannot be replaced", `{"foo": [1, 2]}`,
+			`[{"op": "replace", "path": "/foo/-", "value": 3}]`,
+			"", `"-" is not a list index`},
+		{"leading zero", `{"foo": [1, 2]}`,
+			`[{"op": "remove", "path": "/foo/01"}]`,
+			"", `"01" is not a list index`},
+		{"negative index", `{"foo": [1, 2]}`,
+			`[{"op": "remove", "path": "/foo/-1"}]`,
+			"", `"-1" is not a list index`},
+		{"index past the end", `{"foo": [1, 2]}`,
+			`[{"op": "add", "path": "/foo/3", "value": 3}]`,
+			"", "index 3 is out of range"},
+		{"add at the end", `{"foo": [1, 2]}`,
+			`[{"op": "add", "path": "/foo/2", "value": 3}]`,
+			`{"foo": [1, 2, 3]}`, ""},
+		{"replace needs the value", `{"foo": 1}`,
+			`[{"op": "replace", "path": "/bar", "value": 2}]`,
+			"", `no key "bar"`},
+		{"move into a child", `{"foo": {"bar": 1}}`,
+			`[{"op": "move", "from": "/foo", "path": "/foo/baz"}]`,
+			"", "cannot move /foo into itself"},
+		{"move onto a sibling prefix", `{"foo": 1, "foobar": 2}`,
+			`[{"op": "move", "from": "/foo", "path": "/foobar"}]`,
+			`{"foobar": 1}`, ""},
+		{"move to itself", `{"foo": {"bar": 1}}`,
+			`[{"op": "move", "from": "/foo", "path": "/foo"}]`,
+			`{"foo": {"bar": 1}}`, ""},
+		{"copy is deep", `{"foo": {"bar": 1}}`,
+			`[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "replace", "path": "/baz/bar", "value": 2}]`,
+			`{"foo": {"bar": 1}, "baz": {"bar": 2}}`, ""},
+		{"test compares numbers by value", `{"foo": 1}`,
+			`[{"op": "test", "path": "/foo", "value": 1.0}]`,
+			`{"foo": 1}`, ""},
+		{"test compares mappings regardless of order", `{"foo": {"a": 1, "b": 2}}`,
+			`[{"op": "test", "path": "/foo", "value": {"b": 2, "a": 1}}]`,
+			`{"foo": {"a": 1, "b": 2}}`, ""},
+		{"test of a missing value", `{"foo": 1}`,
+			`[{"op": "test", "path": "/bar", "value": null}]`,
+			"", `no key "bar"`},
+		{"a later failure fails the patch", `{"foo": 1}`,
+			`[{"op": "remove", "path": "/foo"}, {"op": "test", "path": "/foo", "value": 1}]`,
+			"", `operation 1 (test /foo): no key "foo"`},
+		{"pointer without a slash", `{"foo": 1}`,
+			`[{"op": "remove", "path": "foo"}]`,
+			"", `pointer "foo" does not start with /`},
+		{"scalar parent", `{"foo": 1}`,
+			`[{"op": "add", "path": "/foo/bar", "value": 1}]`,
+			"", `cannot set "bar" in a scalar`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := ParsePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ParsePatch: %v", err)
+			}
+			doc := tree(t, tt.doc)
+			got, err := Apply(doc, ops)
+			if !equal(doc, tree(t, tt.doc)) {
+				t.Errorf("Apply changed its document to %s", inline(doc))
+			}
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Apply: error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !equal(got, tree(t, tt.want)) {
+				t.Errorf("Apply = %s, want %s", inline(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	tests := []struct {
+		patch string
+		want  string
+	}{
+		{`[{"op": "add", "value": 1}]`, "add operation has no path"},
+		{`[{"op": "add", "path": "/a"}]`, "add operation has no value"},
+		{`[{"op": "move", "path": "/a"}]`, "move operation has no from"},
+		{`[{"op": "frobnicate", "path": "/a"}]`, `unknown operation "frobnicate"`},
+		{`{"op": "add", "path": "/a", "value": 1}`, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		_, err := ParsePatch([]byte(tt.patch))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParsePatch(%s): error %v, want one containing %q", tt.patch, err, tt.want)
+		}
+	}
+}
+
+func TestMarshalOperation(t *testing.T) {
+	ops := []Operation{
+		{Op: "add", Path: "/a", Value: nil},
+		{Op: "remove", Path: "/a", Value: "ignored"},
+		{Op: "move", Path: "/b", From: "/a"},
+		{Op: "test", Path: "/c", Value: codec.Map{{Key: "z", Value: int64(1)}, {Key: "a", Value: "x"}}},
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/a"},{"op":"move","path":"/b","from":"/a"},` +
+		`{"op":"test","path":"/c","value":{"z":1,"a":"x"}}]`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+}
+
+// mutate : return a copy of tree with its lists reversed and the last key
+// of each mapping left out, changing every level of a record.
+func mutate(tree interface{}) interface{} {
+	switch t := tree.(type) {
+	case codec.Map:
+		var m codec.Map
+		for i, item := range t {
+			if i < len(t)-1 {
+				m = append(m, codec.MapItem{Key: item.Key, Value: mutate(item.Value)})
+			}
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[len(t)-1-i] = mutate(v)
+		}
+		return l
+	}
+	return tree
+}
+
+func TestRoundTrip(t *testing.T) {
+	v := validation.New()
+	for _, k := range document.Kinds() {
+		sample := k.Sample()
+		st, err := codec.ToTree(sample, codec.JSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(mutate(st))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mutated := k.New()
+		if err := codec.Unmarshal(data, codec.JSON, mutated); err != nil {
+			t.Fatalf("%s: decoding the mutated sample: %v", k.Name, err)
+		}
+		for _, pair := range []struct {
+			name     string
+			old, new interface{}
+			// upgraded : whether decoding new upgrades it, as it has no
+			// spec_version, so that ApplyRecord gives another record.
+			upgraded bool
+		}{
+			{"sample to mutated", sample, mutated, false},
+			{"mutated to sample", mutated, sample, false},
+			{"empty to sample", k.New(), sample, false},
+			{"sample to empty", sample, k.New(), true},
+			{"sample to sample", sample, sample, false},
+		} {
+			changes, err := Records(pair.old, pair.new, codec.JSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Encode and decode the patch, as the command line and the
+			// server do.
+			data, err := json.Marshal(Patch(changes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops, err := ParsePatch(data)
+			if err != nil {
+				t.Fatalf("%s, %s: ParsePatch: %v", k.Name, pair.name, err)
+			}
+			old, _ := codec.ToTree(pair.old, codec.JSON)
+			want, _ := codec.ToTree(pair.new, codec.JSON)
+			got, err := Apply(old, ops)
+			if err != nil {
+				t.Fatalf("%s, %s: Apply: %v", k.Name, pair.name, err)
+			}
+			if !equal(got, want) {
+				t.Errorf("%s, %s: Apply gives %s, want %s", k.Name, pair.name, inline(got), inline(want))
+			}
+			if rest := Trees(got, want); len(rest) != 0 {
+				t.Errorf("%s, %s: %d changes left after Apply: %v", k.Name, pair.name, len(rest), rest)
+			}
+
+			record, _, err := ApplyRecord(v, k, pair.old, ops)
+			if err != nil {
+				t.Fatalf("%s, %s: ApplyRecord: %v", k.Name, pair.name, err)
+			}
+			if pair.upgraded {
+				continue
+			}
+			if got, _ := codec.ToTree(record, codec.JSON); !equal(got, want) {
+				t.Errorf("%s, %s: ApplyRecord gives %s, want %s", k.Name, pair.name, inline(got), inline(want))
+			}
+		}
+	}
+}
+
+func TestApplyRecordLost(t *testing.T) {
+	v := validation.New()
+	k, err := document.Lookup("okw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		patch string
+		// lost : the pointers the error names, or none if the patch applies.
+		lost []string
+	}{
+		{"unknown key", `[{"op": "add", "path": "/colour", "value": "red"}]`, []string{"/colour"}},
+		{"unknown nested key", `[{"op": "add", "path": "/location/planet", "value": "Mars"}]`, []string{"/location/planet"}},
+		{"two unknown keys", `[{"op": "add", "path": "/colour", "value": "red"}, {"op": "add", "path": "/location/planet", "value": "Mars"}]`,
+			[]string{"/location/planet", "/colour"}},
+		{"known key", `[{"op": "replace", "path": "/name", "value": "Renamed"}]`, nil},
+		{"removed key decodes as its zero value", `[{"op": "remove", "path": "/description"}]`, nil},
+		{"null decodes as the zero value", `[{"op": "replace", "path": "/description", "value": null}]`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := ParsePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			record, result, err := ApplyRecord(v, k, k.Sample(), ops)
+			if len(tt.lost) == 0 {
+				if err != nil {
+					t.Fatalf("ApplyRecord: %v", err)
+				}
+				if record == nil || result.Error != "" {
+					t.Fatalf("ApplyRecord: %+v, want a record", result)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ApplyRecord: no error, want %v lost", tt.lost)
+			}
+			want := "does not have, or values it cannot hold: " + strings.Join(tt.lost, ", ")
+			if !strings.HasSuffix(err.Error(), want) {
+				t.Errorf("ApplyRecord: error %q, want it to end %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package document describes the kinds of record (OKW, OKT, ...) understood
+// by the framework, so that tools can handle them without a type switch.
+package document
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/migration"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okh"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okt"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
+)
+
+// Kind : a kind of record.
+type Kind struct {
+	// Name : short lower-case name of the kind, also used as the file name stem, e.g. "okw".
+	Name string
+	// Title : name of the specification the kind follows.
+	Title string
+	// Collection : plural noun naming records of the kind, as used in URLs, e.g. "facilities".
+	Collection string
+	// New : return a pointer to a new zero-valued record.
+	New func() interface{}
+	// Sample : return the sample record.
+	Sample func() interface{}
+	// TypeMap : return the named types of the record, keyed by their DAML type name.
+	TypeMap func() map[string]interface{}
+	// Docs : the specification's documentation of each field, keyed by YAML path.
+	Docs spec.Docs
+	// Version : the version of the specification the record type implements.
+	Version string
+	// Migrations : the steps that upgrade older documents to Version, in order.
+	Migrations []migration.Step
+}
+
+var kinds = []Kind{
+	{
+		Name:       "okw",
+		Title:      "Open Know Where",
+		Collection: "facilities",
+		New:        func() interface{} { return &okw.OKW{} },
+		Sample:     func() interface{} { return okw.NewSample() },
+		TypeMap:    okw.TypeMap,
+		Docs:       okw.FieldDocs,
+		Version:    okw.SpecVersion,
+		Migrations: okw.Migrations,
+	},
+	{
+		Name:       "okt",
+		Title:      "Open Know Transport",
+		Collection: "carriers",
+		New:        func() interface{} { return &okt.OKT{} },
+		Sample:     func() interface{} { return okt.NewSample() },
+		TypeMap:    okt.TypeMap,
+		Docs:       okt.FieldDocs,
+		Version:    okt.SpecVersion,
+		Migrations: okt.Migrations,
+	},
+	{
+		Name:       "okh",
+		Title:      "Open Know How",
+		Collection: "designs",
+		New:        func() interface{} { return &okh.OKH{} },
+		Sample:     func() interface{} { return okh.NewSample() },
+		TypeMap:    okh.TypeMap,
+		Docs:       okh.FieldDocs,
+		Version:    okh.SpecVersion,
+		Migrations: okh.Migrations,
+	},
+}
+
+// Kinds : return every known kind.
+func Kinds() []Kind {
+	return append([]Kind(nil), kinds...)
+}
+
+// Migrations : return the migration steps of every kind.
+func Migrations() migration.Registry {
+	r := migration.Registry{}
+	for _, k := range kinds {
+		r[k.Name] = k.Migrations
+	}
+	return r
+}
+
+// Names : return the names of every known kind.
+func Names() []string {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = k.Name
+	}
+	return names
+}
+
+// Lookup : return the kind with the given name.
+func Lookup(name string) (Kind, error) {
+	for _, k := range kinds {
+		if strings.EqualFold(k.Name, name) {
+			return k, nil
+		}
+	}
+	return Kind{}, fmt.Errorf("unknown kind %q (want one of %s)", name, strings.Join(Names(), ", "))
+}
+
+// Detect : infer the kind of the record stored at path. The file name stem is
+// checked first ("okw.yaml", "makerspace.okw.yaml"), then the names of the
+// enclosing directories ("okw/makerspace.yaml").
+func Detect(path string) (Kind, error) {
+	base := filepath.Base(path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	for _, k := range kinds {
+		if strings.EqualFold(stem, k.Name) || strings.HasSuffix(strings.ToLower(stem), "."+k.Name) {
+			return k, nil
+		}
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		for _, k := range kinds {
+			if strings.EqualFold(filepath.Base(dir), k.Name) {
+				return k, nil
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return Kind{}, fmt.Errorf("%s: cannot infer the record kind from the path", path)
+}
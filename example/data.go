@@ -0,0 +1,210 @@
+package example
+
+// place : a real town with enough detail to fill an address, GPS
+// coordinates and phone numbers that agree with each other.
+type place struct {
+	city, region, country string
+	districts, streets    []string
+	// postcode : format of postcodes, with # standing for a random digit.
+	postcode  string
+	lat, lon  float64
+	dialCode  string
+	language  string
+	landmarks []string
+}
+
+var places = []place{
+	{
+		city: "Nairobi", region: "Nairobi County", country: "Kenya",
+		districts: []string{"Westlands", "Kilimani", "Industrial Area", "Kasarani"},
+		streets:   []string{"Mombasa Road", "Ngong Road", "Enterprise Road", "Kimathi Street"},
+		postcode:  "00###", lat: -1.2921, lon: 36.8219, dialCode: "+254", language: "en",
+		landmarks: []string{"bus terminal", "petrol station", "market"},
+	},
+	{
+		city: "Kigali", region: "Kigali City", country: "Rwanda",
+		districts: []string{"Gasabo", "Kicukiro", "Nyarugenge"},
+		streets:   []string{"KG 7 Avenue", "KN 3 Road", "KK 15 Road"},
+		postcode:  "", lat: -1.9441, lon: 30.0619, dialCode: "+250", language: "en",
+		landmarks: []string{"convention centre", "moto stage", "market"},
+	},
+	{
+		city: "Accra", region: "Greater Accra", country: "Ghana",
+		districts: []string{"Osu", "Kokomlemle", "Kaneshie"},
+		streets:   []string{"Oxford Street", "Ring Road Central", "Liberation Road"},
+		postcode:  "GA-###-####", lat: 5.6037, lon: -0.1870, dialCode: "+233", language: "en",
+		landmarks: []string{"trotro station", "church", "roundabout"},
+	},
+	{
+		city: "Bristol", region: "South West England", country: "United Kingdom",
+		districts: []string{"Bedminster", "St Philips", "Easton", "Clifton"},
+		streets:   []string{"Feeder Road", "Cheltenham Road", "Gloucester Road", "Temple Way"},
+		postcode:  "BS# #AB", lat: 51.4545, lon: -2.5879, dialCode: "+44", language: "en-GB",
+		landmarks: []string{"railway station", "canal", "retail park"},
+	},
+	{
+		city: "Lyon", region: "Auvergne-Rhône-Alpes", country: "France",
+		districts: []string{"Gerland", "La Part-Dieu", "Vaise"},
+		streets:   []string{"Rue de Gerland", "Avenue Jean Jaurès", "Quai Perrache"},
+		postcode:  "6900#", lat: 45.7640, lon: 4.8357, dialCode: "+33", language: "fr",
+		landmarks: []string{"tram stop", "river bank", "boulangerie"},
+	},
+	{
+		city: "Pune", region: "Maharashtra", country: "India",
+		districts: []string{"Bhosari", "Hadapsar", "Kothrud"},
+		streets:   []string{"Nashik Highway", "Solapur Road", "Karve Road"},
+		postcode:  "411###", lat: 18.5204, lon: 73.8567, dialCode: "+91", language: "en-IN",
+		landmarks: []string{"bus depot", "temple", "MIDC gate"},
+	},
+	{
+		city: "Medellín", region: "Antioquia", country: "Colombia",
+		districts: []string{"El Poblado", "Guayabal", "Itagüí"},
+		streets:   []string{"Carrera 43A", "Calle 10", "Avenida 80"},
+		postcode:  "0500##", lat: 6.2442, lon: -75.5812, dialCode: "+57", language: "es",
+		landmarks: []string{"metro station", "plaza", "bakery"},
+	},
+	{
+		city: "Detroit", region: "Michigan", country: "United States",
+		districts: []string{"Corktown", "Eastern Market", "Midtown"},
+		streets:   []string{"Michigan Avenue", "Woodward Avenue", "Gratiot Avenue"},
+		postcode:  "482##", lat: 42.3314, lon: -83.0458, dialCode: "+1", language: "en-US",
+		landmarks: []string{"parking garage", "rail yard", "diner"},
+	},
+	{
+		city: "Dhaka", region: "Dhaka Division", country: "Bangladesh",
+		districts: []string{"Tejgaon", "Mirpur", "Uttara"},
+		streets:   []string{"Bir Uttam Mir Shawkat Road", "Mirpur Road", "Airport Road"},
+		postcode:  "12##", lat: 23.8103, lon: 90.4125, dialCode: "+880", language: "bn",
+		landmarks: []string{"rickshaw stand", "mosque", "bus counter"},
+	},
+	{
+		city: "Manila", region: "Metro Manila", country: "Philippines",
+		districts: []string{"Tondo", "Sampaloc", "Pasig"},
+		streets:   []string{"Rizal Avenue", "España Boulevard", "Ortigas Avenue"},
+		postcode:  "10##", lat: 14.5995, lon: 120.9842, dialCode: "+63", language: "en",
+		landmarks: []string{"jeepney terminal", "church", "sari-sari store"},
+	},
+}
+
+// machine : a piece of equipment and the process it is used for.
+type machine struct {
+	equipmentType, process string
+	make, model            string
+	skills                 []string
+}
+
+var machines = []machine{
+	{"https://en.wikipedia.org/wiki/3D_printing", "https://en.wikipedia.org/wiki/Fused_filament_fabrication", "Prusa Research", "Original Prusa i3 MK3S+", []string{"3D printing", "CAD"}},
+	{"https://en.wikipedia.org/wiki/Laser_cutting", "https://en.wikipedia.org/wiki/Laser_cutting", "Trotec", "Speedy 300", []string{"Laser cutting", "Vector design"}},
+	{"https://en.wikipedia.org/wiki/CNC_router", "https://en.wikipedia.org/wiki/Milling_(machining)", "ShopBot", "PRSalpha 96-48", []string{"CNC machining", "CAM"}},
+	{"https://en.wikipedia.org/wiki/Lathe", "https://en.wikipedia.org/wiki/Turning", "Colchester", "Student 1800", []string{"Turning", "Metalwork"}},
+	{"https://en.wikipedia.org/wiki/Injection_moulding", "https://en.wikipedia.org/wiki/Injection_moulding", "Precious Plastic", "Injection Machine v4", []string{"Plastic recycling", "Mould making"}},
+	{"https://en.wikipedia.org/wiki/Welding", "https://en.wikipedia.org/wiki/Gas_metal_arc_welding", "Lincoln Electric", "POWER MIG 210 MP", []string{"MIG welding", "Metalwork"}},
+	{"https://en.wikipedia.org/wiki/Sewing_machine", "https://en.wikipedia.org/wiki/Sewing", "Juki", "DDL-8700", []string{"Sewing", "Pattern cutting"}},
+}
+
+// material : a material and who supplies it.
+type material struct {
+	url, name, manufacturer, brand string
+}
+
+var materials = []material{
+	{"https://en.wikipedia.org/wiki/Polylactic_acid", "PLA", "NatureWorks", "Ingeo"},
+	{"https://en.wikipedia.org/wiki/Polyethylene_terephthalate", "PETG", "Eastman", "Spectar"},
+	{"https://en.wikipedia.org/wiki/Plywood", "Plywood", "Metsä Wood", "Birch Ply"},
+	{"https://en.wikipedia.org/wiki/Poly(methyl_methacrylate)", "Acrylic", "Mitsubishi Chemical", "Perspex"},
+	{"https://en.wikipedia.org/wiki/Aluminium_alloy", "Aluminium 6061", "Novelis", "Novelis 6061"},
+	{"https://en.wikipedia.org/wiki/High-density_polyethylene", "HDPE", "Dow", "Dowlex"},
+	{"https://en.wikipedia.org/wiki/Cotton", "Cotton", "Arvind", "Arvind Denim"},
+}
+
+// quantity : the unit and plausible range of a quantitative value, keyed by
+// the field holding it.
+type quantity struct {
+	unitCode, unitText, reference string
+	min, max                      int
+}
+
+var quantities = map[string]quantity{
+	"acceleration_time": {"SEC", "seconds", "0-100 km/h", 6, 18},
+	"cargo_volume":      {"LTR", "litres", "", 400, 12000},
+}
+
+var (
+	firstNames = []string{"Amina", "Tomás", "Priya", "Kwame", "Sofia", "Jun", "Grace", "Olusegun", "Marie", "Ahmed", "Lucía", "Chen", "Fatima", "David", "Nadia", "Samuel"}
+	lastNames  = []string{"Okafor", "García", "Sharma", "Mensah", "Rossi", "Tanaka", "Mwangi", "Dubois", "Haddad", "Nguyen", "Kowalski", "Silva", "Rahman", "Smith", "Uwase", "Ochieng"}
+
+	organisationSuffixes = []string{"Engineering Co-operative", "Fabrication Ltd", "Design Collective", "Tool Library", "Foundation", "Makers Network"}
+	// rootSuffixes : how the name of a root record is formed from its town,
+	// keyed by the root type.
+	rootSuffixes = map[string][]string{
+		"OKW": {"Makerspace", "Fab Lab", "Maker Hub", "Community Workshop", "Hackerspace"},
+		"OKT": {"Freight", "Logistics", "Cargo Co-operative", "Courier Services", "Haulage"},
+	}
+	rootDescriptions = map[string][]string{
+		"OKW": {
+			"A community workshop in %s offering digital fabrication, metalwork and training for local makers.",
+			"An open-access fab lab in %s supporting small-batch production for local enterprises.",
+			"A shared manufacturing space in %s with equipment for prototyping and repair.",
+		},
+		"OKT": {
+			"A regional carrier in %s moving medical and humanitarian supplies.",
+			"A cooperative of independent drivers in %s offering last-mile delivery.",
+			"A freight operator in %s with refrigerated and flatbed vehicles.",
+		},
+		"OKH": {
+			"Open source hardware designed to be made in a typical makerspace with locally available materials.",
+			"A low-cost, repairable device documented so that it can be built and maintained anywhere.",
+		},
+	}
+
+	projects     = []string{"Open Ventilator", "Low-cost Microscope", "Solar Water Pump", "Face Shield", "Oxygen Concentrator", "Prosthetic Hand", "Water Filter", "Incubator"}
+	intendedUses = []string{
+		"Emergency use in field hospitals.",
+		"Teaching and laboratory work in schools.",
+		"Irrigation for smallholder farms.",
+		"Personal protection for health workers.",
+	}
+	keywords          = []string{"medical", "open hardware", "low cost", "3D printed", "repairable", "solar", "humanitarian", "education"}
+	developmentStages = []string{"concept", "prototype", "testing", "production"}
+	hardwareLicenses  = []string{"CERN-OHL-S-2.0", "CERN-OHL-W-2.0", "CERN-OHL-P-2.0", "TAPR-OHL-1.0"}
+	documentLicenses  = []string{"CC-BY-4.0", "CC-BY-SA-4.0"}
+	softwareLicenses  = []string{"GPL-3.0-or-later", "MIT", "Apache-2.0"}
+	dataLicenses      = []string{"CC0-1.0", "CC-BY-4.0", "CC-BY-SA-4.0", "ODbL-1.0"}
+	changes           = []string{
+		"Imported from a community directory.",
+		"Corrected the contact details.",
+		"Added a description.",
+		"Updated the links.",
+		"Confirmed the details with the publisher.",
+	}
+	standards           = []struct{ title, publisher, reference string }{{"Medical devices — Quality management systems", "ISO", "ISO 13485:2016"}, {"Medical electrical equipment — General requirements", "IEC", "IEC 60601-1:2005"}, {"Quality management systems — Requirements", "ISO", "ISO 9001:2015"}}
+	healthSafetyNotices = []string{
+		"Wear eye protection when cutting and drilling parts.",
+		"Mains voltage is present inside the enclosure; disconnect before opening.",
+		"Not certified for clinical use; follow local regulations.",
+	}
+
+	openingHours   = []string{"Mon-Fri 09:00-17:00", "Mon-Sat 08:00-18:00", "Tue-Sun 10:00-20:00", "24/7 for members, public hours Sat 10:00-16:00"}
+	conditions     = []string{"New", "Good", "Serviceable", "Needs repair"}
+	schedules      = []string{"Weekly cleaning, quarterly servicing by the manufacturer.", "Monthly inspection; annual calibration.", "Checked before every shift; serviced every 500 hours."}
+	products       = []string{"Face shields", "Furniture", "Replacement parts", "Enclosures", "Signage", "Prosthetic components", "School kits", "Solar lamp housings"}
+	certifications = []string{"ISO 9001", "ISO 13485", "ISO 14001", "CE marking", "Good Manufacturing Practice"}
+	batchSizes     = []string{"1", "2-10", "11-100", "101-1000", "1001+"}
+	storage        = []string{"20 pallets", "50 m3 dry storage", "A shipping container", "10 m2 of shelving"}
+	reviews        = []string{
+		"Friendly staff and well maintained machines.",
+		"Turned our prototype round in two days.",
+		"Good equipment, but booking slots fill up quickly.",
+		"Reliable delivery and clear communication throughout.",
+	}
+	bodyTypes = []string{"Panel van", "Flatbed truck", "Pickup", "Box truck", "Refrigerated truck", "Cargo bike"}
+	services  = []string{"Last-mile delivery", "Refrigerated transport", "Same-day courier", "Warehousing", "Cross-border freight"}
+	permits   = []string{"Goods vehicle operator licence", "Cross-border transit permit", "Hazardous goods endorsement", "Cold chain certification"}
+	circular  = []string{
+		"Offcuts are sorted and sold to local craft businesses.",
+		"Plastic waste is shredded and re-extruded into filament.",
+		"Broken tools are repaired in repair cafés held monthly.",
+	}
+	words = []string{"index", "home", "raft", "table", "lamp", "river", "stone", "quiet", "amber", "cloud", "maple", "gentle", "orbit", "lantern", "meadow", "copper"}
+)
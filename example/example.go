@@ -0,0 +1,578 @@
+// Package example fills records with plausible, deterministic values, for
+// documentation, test fixtures and load testing.
+package example
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// maxDepth : how deeply nested structs are filled, as a guard against
+// recursive types.
+const maxDepth = 8
+
+// Generator : fills records with values drawn from a seeded source, so that
+// the same seed always produces the same records.
+type Generator struct {
+	rand  *rand.Rand
+	root  string
+	home  *place
+	depth int
+	scope scope
+	// founded, created : the day the record's subject was founded or
+	// created, and the time its metadata was created, once filled; later
+	// dates of the record fall after them.
+	founded, created time.Time
+}
+
+// scope : what the fields of the struct being filled should agree on.
+type scope struct {
+	place    *place
+	machine  *machine
+	material *material
+	standard int
+	// quantity, low, high : unit and range of a quantitative value.
+	quantity  *quantity
+	low, high int
+	// subject : name of the record, agent or person being filled, used to
+	// derive e-mail addresses and URLs.
+	subject string
+	person  bool
+}
+
+// New : return a Generator seeded with seed.
+func New(seed int64) *Generator {
+	return &Generator{rand: rand.New(rand.NewSource(seed))}
+}
+
+// Fill : set every field of the struct v points to, replacing any value it
+// already holds. Successive calls produce different records. Fill panics if v
+// is not a non-nil pointer to a struct.
+func (g *Generator) Fill(v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("example: Fill of %T, want a pointer to a struct", v))
+	}
+	g.root = rv.Elem().Type().Name()
+	g.home = &places[g.rand.Intn(len(places))]
+	g.scope = scope{place: g.home}
+	g.depth = 0
+	g.founded, g.created = time.Time{}, time.Time{}
+	g.fill(rv.Elem(), "", "")
+}
+
+// fill : set v, found under key, to a plausible value satisfying the
+// validate rules.
+func (g *Generator) fill(v reflect.Value, key, rules string) {
+	if options, ok := spec.EnumOptions(v.Type()); ok && v.Kind() == reflect.String {
+		if len(options) > 0 {
+			v.SetString(g.pick(options))
+		} else {
+			v.SetString(g.text(key))
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if g.depth >= maxDepth {
+			return
+		}
+		// Optional scalars are sometimes unknown, as in real records.
+		if k := v.Type().Elem().Kind(); k != reflect.Struct && k != reflect.Slice && g.rand.Intn(5) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		g.fill(v.Elem(), key, rules)
+	case reflect.Struct:
+		if g.depth >= maxDepth {
+			return
+		}
+		g.fillStruct(v, key)
+	case reflect.Slice:
+		if g.depth >= maxDepth {
+			return
+		}
+		n := 1 + g.rand.Intn(3)
+		s := reflect.MakeSlice(v.Type(), 0, n)
+		seen := map[string]bool{}
+		for i := 0; i < n; i++ {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			// Retry a few times so that lists of words do not repeat.
+			for try := 0; try < 5; try++ {
+				g.fill(elem, key, "")
+				if elem.Kind() != reflect.String || !seen[elem.String()] {
+					break
+				}
+			}
+			if elem.Kind() == reflect.String {
+				if seen[elem.String()] {
+					continue
+				}
+				seen[elem.String()] = true
+			}
+			s = reflect.Append(s, elem)
+		}
+		v.Set(s)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(g.text(key)))
+		}
+	case reflect.String:
+		v.SetString(g.text(key))
+	case reflect.Bool:
+		v.SetBool(g.rand.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(g.integer(key, rules))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(g.integer(key, rules)))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(g.float(key))
+	}
+}
+
+// fillStruct : fill the fields of struct v in order, choosing the place,
+// machine, material or quantity they describe first.
+func (g *Generator) fillStruct(v reflect.Value, key string) {
+	saved := g.scope
+	g.depth++
+	defer func() {
+		g.scope = saved
+		g.depth--
+	}()
+	fields := spec.Fields(v.Type(), "yaml")
+	has := map[string]bool{}
+	for _, f := range fields {
+		has[normalize(f.Key)] = true
+	}
+	// The record and its equipment are located at its home; agents and
+	// suppliers usually, but not always, are in the same town.
+	if g.depth > 1 && (has["location"] || has["supplierlocation"]) && !(has["make"] && has["model"]) && g.rand.Intn(3) == 0 {
+		g.scope.place = &places[g.rand.Intn(len(places))]
+	}
+	switch {
+	case has["make"] && has["model"]:
+		g.scope.machine = &machines[g.rand.Intn(len(machines))]
+	case has["material_type"]:
+		g.scope.material = &materials[g.rand.Intn(len(materials))]
+	case has["standard_title"]:
+		g.scope.standard = g.rand.Intn(len(standards))
+	case has["unit_code"]:
+		q, ok := quantities[normalize(key)]
+		if !ok {
+			q = quantity{"C62", "units", "", 1, 100}
+		}
+		g.scope.quantity = &q
+		mid := (q.min + q.max) / 2
+		g.scope.low = q.min + g.rand.Intn(mid-q.min+1)
+		g.scope.high = mid + g.rand.Intn(q.max-mid+1)
+	}
+	if has["name"] || has["title"] {
+		g.scope.subject, g.scope.person = "", false
+	}
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.Index)
+		g.fill(fv, f.Key, f.Tag.Get("validate"))
+		if k := normalize(f.Key); (k == "name" || k == "title") && fv.Kind() == reflect.String {
+			g.scope.subject = fv.String()
+		}
+	}
+}
+
+// text : return a plausible string for the field key.
+func (g *Generator) text(key string) string {
+	s := &g.scope
+	p := s.place
+	switch k := normalize(key); k {
+	case "name":
+		switch {
+		case g.depth == 1:
+			if suffixes, ok := rootSuffixes[g.root]; ok {
+				return p.city + " " + g.pick(suffixes)
+			}
+			return p.city + " " + g.pick(organisationSuffixes)
+		case g.rand.Intn(2) == 0:
+			s.person = true
+			return g.personName()
+		}
+		return g.organisation()
+	case "contact_person":
+		if s.person {
+			return s.subject
+		}
+		return g.personName()
+	case "maker":
+		return g.personName()
+	case "affiliation":
+		return g.organisation()
+	case "title":
+		return g.pick(projects)
+	case "email":
+		if s.person {
+			return slug(s.subject, ".") + "@example.org"
+		}
+		return "info@" + g.domain()
+	case "website":
+		return "https://" + g.domain()
+	case "other_urls":
+		return "https://" + g.domain() + "/" + g.pick([]string{"blog", "projects", "events", "shop"})
+	case "spec_version":
+		// Only the caller knows which version the record type implements.
+		return ""
+	case "twitter":
+		return "@" + truncate(slug(s.subject, "_"), 15)
+	case "facebook":
+		return "https://facebook.com/" + slug(s.subject, ".")
+	case "instagram":
+		return "https://instagram.com/" + slug(s.subject, "_")
+	case "landline", "mobile", "fax", "whatsapp":
+		return fmt.Sprintf("%s %d %03d %04d", p.dialCode, 2+g.rand.Intn(8), g.rand.Intn(1000), g.rand.Intn(10000))
+	case "number":
+		return strconv.Itoa(1 + g.rand.Intn(250))
+	case "street":
+		return g.pick(p.streets)
+	case "district":
+		return g.pick(p.districts)
+	case "city":
+		return p.city
+	case "region":
+		return p.region
+	case "country":
+		return p.country
+	case "postcode":
+		return g.digits(p.postcode)
+	case "directions":
+		return fmt.Sprintf("Entrance on %s, next to the %s.", g.pick(p.streets), g.pick(p.landmarks))
+	case "what_3_words", "coordinates":
+		return g.pick(words) + "." + g.pick(words) + "." + g.pick(words)
+	case "language", "manifest_language", "documentation_language":
+		return p.language
+	case "description":
+		if g.depth == 1 {
+			if d, ok := rootDescriptions[g.root]; ok {
+				return strings.Replace(g.pick(d), "%s", p.city, 1)
+			}
+		}
+		return g.pick(circular)
+	case "opening_hours":
+		return g.pick(openingHours)
+	case "equipment_type":
+		return g.machine().equipmentType
+	case "manufacturing_process":
+		return g.machine().process
+	case "manufacturing_processes":
+		return machines[g.rand.Intn(len(machines))].process
+	case "make":
+		return g.machine().make
+	case "model":
+		return g.machine().model
+	case "serial_number":
+		return fmt.Sprintf("%s-%06d", strings.ToUpper(truncate(slug(g.machine().make, ""), 3)), g.rand.Intn(1000000))
+	case "skills_required":
+		return g.pick(g.machine().skills)
+	case "condition":
+		return g.pick(conditions)
+	case "material_type":
+		return g.material().url
+	case "manufacturer":
+		return g.material().manufacturer
+	case "brand":
+		return g.material().brand
+	case "definedmaterialtype":
+		return g.material().name
+	case "typical_batch_size":
+		return g.pick(batchSizes)
+	case "storage_capacity":
+		return g.pick(storage)
+	case "certifications":
+		return g.pick(certifications)
+	case "maintenance_schedule":
+		return g.pick(schedules)
+	case "typical_products":
+		return g.pick(products)
+	case "identifier":
+		return g.uuid()
+	case "id":
+		return "urn:uuid:" + g.uuid()
+	case "ref":
+		// Records generated alone define their Agents rather than refer to them.
+		return ""
+	case "created":
+		t := g.timestamp(g.founded, false)
+		g.created = t
+		return t.Format(time.RFC3339)
+	case "modified", "verified":
+		after := g.created
+		if after.IsZero() {
+			after = g.founded
+		}
+		return g.timestamp(after, true).Format(time.RFC3339)
+	case "date_founded", "date_created":
+		d := g.day(time.Time{}, false)
+		g.founded = d
+		return d.Format("2006-01-02")
+	case "source":
+		return "https://directory.example.org/records/" + slug(g.scope.subject, "-")
+	case "data_license":
+		return g.pick(dataLicenses)
+	case "changelog":
+		return g.pick(changes)
+	case "body":
+		return g.pick(reviews)
+	case "body_type":
+		return g.pick(bodyTypes)
+	case "unit_code":
+		return g.quantity().unitCode
+	case "unit_text":
+		return g.quantity().unitText
+	case "value":
+		return strconv.Itoa((s.low + s.high) / 2)
+	case "value_reference":
+		return g.quantity().reference
+	case "services":
+		return g.pick(services)
+	case "areas_of_service":
+		return fmt.Sprintf("%s and %d km around", p.city, 25*(1+g.rand.Intn(8)))
+	case "permits":
+		return fmt.Sprintf("%s (%s)", g.pick(permits), p.country)
+	case "intended_use":
+		return g.pick(intendedUses)
+	case "keywords":
+		return g.pick(keywords)
+	case "project_link":
+		return "https://github.com/" + slug(p.city, "-") + "-hardware/" + slug(s.subject, "-")
+	case "image":
+		return "docs/images/" + slug(s.subject, "-") + ".jpg"
+	case "bom":
+		return "docs/bom.csv"
+	case "manufacturing_instructions":
+		return "docs/assembly.md"
+	case "user_manual":
+		return "docs/user-manual.md"
+	case "version":
+		return fmt.Sprintf("%d.%d.%d", g.rand.Intn(3), g.rand.Intn(10), g.rand.Intn(10))
+	case "development_stage":
+		return g.pick(developmentStages)
+	case "health_safety_notice":
+		return g.pick(healthSafetyNotices)
+	case "hardware":
+		return g.pick(hardwareLicenses)
+	case "documentation":
+		return g.pick(documentLicenses)
+	case "software":
+		return g.pick(softwareLicenses)
+	case "standard_title":
+		return standards[s.standard].title
+	case "publisher":
+		return standards[s.standard].publisher
+	case "reference":
+		return standards[s.standard].reference
+	case "certificate_link":
+		return fmt.Sprintf("https://certificates.example.org/%08x", g.rand.Uint32())
+	default:
+		if strings.Contains(k, "date") {
+			return g.day(g.founded, strings.Contains(k, "updated")).Format("2006-01-02")
+		}
+		if strings.HasSuffix(k, "url") || strings.HasSuffix(k, "link") {
+			return "https://" + g.domain()
+		}
+	}
+	return g.pick(words) + " " + g.pick(words)
+}
+
+// integer : return a plausible integer for the field key, within the bounds
+// of its validate rules if it has any.
+func (g *Generator) integer(key, rules string) int64 {
+	low, high := int64(1), int64(100)
+	switch normalize(key) {
+	case "size_floor_size":
+		low, high = 40, 2500
+	case "headcount":
+		low, high = 2, 60
+	case "staff":
+		low, high = 1, 25
+	case "footfall":
+		low, high = 20, 600
+	case "axes":
+		low, high = 3, 5
+	case "bed_size":
+		low, high = 200, 1500
+	case "min_value":
+		return int64(g.scope.low)
+	case "max_value":
+		return int64(g.scope.high)
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch parts[0] {
+		case "gte", "min":
+			low = n
+		case "lte", "max":
+			high = n
+		}
+	}
+	if high < low {
+		high = low
+	}
+	return low + g.rand.Int63n(high-low+1)
+}
+
+// float : return a plausible number for the field key.
+func (g *Generator) float(key string) float64 {
+	switch normalize(key) {
+	case "latitude":
+		return round(g.scope.place.lat+(g.rand.Float64()-0.5)/10, 5)
+	case "longitude":
+		return round(g.scope.place.lon+(g.rand.Float64()-0.5)/10, 5)
+	case "width", "depth", "height":
+		return round(50+g.rand.Float64()*1150, 1)
+	}
+	return round(g.rand.Float64()*100, 2)
+}
+
+// timestamp : return a time in UTC after after, recent if updated is set.
+func (g *Generator) timestamp(after time.Time, updated bool) time.Time {
+	minute := time.Duration(g.rand.Intn(24*60)) * time.Minute
+	return g.day(after, updated).Add(minute)
+}
+
+// day : return the start of a day after after, recent if updated is set:
+// one of 1995 to 2022, or of 2023 and 2024, or if after is later than those,
+// of the year that follows it.
+func (g *Generator) day(after time.Time, updated bool) time.Time {
+	from, to := time.Date(1995, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if updated {
+		from, to = to, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if next := after.Truncate(24*time.Hour).AddDate(0, 0, 1); next.After(from) {
+		from = next
+	}
+	if !to.After(from) {
+		to = from.AddDate(1, 0, 0)
+	}
+	days := int(to.Sub(from).Hours() / 24)
+	return from.AddDate(0, 0, g.rand.Intn(days))
+}
+
+func (g *Generator) personName() string {
+	return g.pick(firstNames) + " " + g.pick(lastNames)
+}
+
+func (g *Generator) organisation() string {
+	if g.rand.Intn(2) == 0 {
+		return g.pick(lastNames) + " " + g.pick(organisationSuffixes)
+	}
+	return g.scope.place.city + " " + g.pick(organisationSuffixes)
+}
+
+// domain : return a domain name for the subject being filled, under
+// example.org so that it cannot belong to anyone.
+func (g *Generator) domain() string {
+	name := slug(g.scope.subject, "-")
+	if name == "" {
+		name = slug(g.scope.place.city, "-")
+	}
+	return name + ".example.org"
+}
+
+func (g *Generator) machine() *machine {
+	if g.scope.machine == nil {
+		g.scope.machine = &machines[g.rand.Intn(len(machines))]
+	}
+	return g.scope.machine
+}
+
+func (g *Generator) material() *material {
+	if g.scope.material == nil {
+		g.scope.material = &materials[g.rand.Intn(len(materials))]
+	}
+	return g.scope.material
+}
+
+func (g *Generator) quantity() *quantity {
+	if g.scope.quantity == nil {
+		g.scope.quantity = &quantity{"C62", "units", "", 1, 100}
+	}
+	return g.scope.quantity
+}
+
+func (g *Generator) pick(options []string) string {
+	if len(options) == 0 {
+		return ""
+	}
+	return options[g.rand.Intn(len(options))]
+}
+
+// digits : replace each # in format with a random digit.
+func (g *Generator) digits(format string) string {
+	b := []byte(format)
+	for i := range b {
+		if b[i] == '#' {
+			b[i] = byte('0' + g.rand.Intn(10))
+		}
+	}
+	return string(b)
+}
+
+// normalize : return key in snake case, so that "cargoVolume",
+// "cargo-volume" and "cargo_volume" are treated alike.
+func normalize(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case r == '-':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if i > 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var folds = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n", "ü", "u", "ç", "c", "è", "e", "ô", "o")
+
+// uuid : return a random (version 4) UUID.
+func (g *Generator) uuid() string {
+	return fmt.Sprintf("%08x-%04x-4%03x-%04x-%012x", g.rand.Uint32(), g.rand.Intn(1<<16), g.rand.Intn(1<<12), 0x8000|g.rand.Intn(1<<14), g.rand.Int63n(1<<48))
+}
+
+// slug : return s in lower case with every run of other characters than
+// ASCII letters and digits replaced by sep.
+func slug(s, sep string) string {
+	parts := strings.FieldsFunc(folds.Replace(strings.ToLower(s)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(parts, sep)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func round(f float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(f*scale) / scale
+}
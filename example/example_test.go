@@ -0,0 +1,58 @@
+package example_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/example"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okh"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
+)
+
+func parse(t *testing.T, layout, s string) time.Time {
+	d, err := time.Parse(layout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestFillLocations(t *testing.T) {
+	for seed := int64(1); seed <= 100; seed++ {
+		var r okw.OKW
+		example.New(seed).Fill(&r)
+		e := r.Equipment
+		if city, home := e.Location.Address.City, r.Location.Address.City; city != home {
+			t.Errorf("seed %d: equipment %s %s in %s, record in %s", seed, e.Make, e.Model, city, home)
+		}
+	}
+}
+
+// TestFillDates : a record's metadata is created after what it describes
+// was founded, and modified and verified after that.
+func TestFillDates(t *testing.T) {
+	for seed := int64(1); seed <= 100; seed++ {
+		var w okw.OKW
+		example.New(seed).Fill(&w)
+		var h okh.OKH
+		example.New(seed).Fill(&h)
+		for _, r := range []struct {
+			kind, founded               string
+			created, modified, verified string
+		}{
+			{"okw", w.DateFounded, w.Metadata.Created, w.Metadata.Modified, w.Metadata.Verified},
+			{"okh", h.DateCreated, h.Metadata.Created, h.Metadata.Modified, h.Metadata.Verified},
+		} {
+			founded := parse(t, "2006-01-02", r.founded)
+			created := parse(t, time.RFC3339, r.created)
+			if !created.After(founded) {
+				t.Errorf("seed %d: %s created %s, before it was founded %s", seed, r.kind, r.created, r.founded)
+			}
+			for _, later := range []string{r.modified, r.verified} {
+				if !parse(t, time.RFC3339, later).After(created) {
+					t.Errorf("seed %d: %s modified or verified %s, before it was created %s", seed, r.kind, later, r.created)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package fulltext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Analyzer : turns text into the terms that are indexed and searched for.
+// Name identifies it in a persisted index, which is rebuilt when it was
+// written with another analyzer.
+type Analyzer interface {
+	Name() string
+	Analyze(text string) []string
+}
+
+// analyzer : an Analyzer that splits text into words, folds them to lower
+// case, drops stop words and stems the rest.
+type analyzer struct {
+	name string
+	stop map[string]bool
+	stem func(string) string
+}
+
+func (a analyzer) Name() string { return a.name }
+
+func (a analyzer) Analyze(text string) []string {
+	var terms []string
+	for _, word := range Tokenize(text) {
+		word = strings.ToLower(word)
+		if a.stop[word] {
+			continue
+		}
+		if a.stem != nil {
+			word = a.stem(word)
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// NewAnalyzer : return an Analyzer named name that splits text into words,
+// folds them to lower case, drops those in stopWords and passes the rest
+// through stem, if it is not nil. Other languages can be supported by
+// registering an analyzer made with their stop words and stemmer.
+func NewAnalyzer(name string, stopWords []string, stem func(string) string) Analyzer {
+	stop := map[string]bool{}
+	for _, w := range stopWords {
+		stop[w] = true
+	}
+	return analyzer{name: name, stop: stop, stem: stem}
+}
+
+// English : the analyzer for English text, which drops common words and
+// stems the rest with the Porter algorithm.
+var English = NewAnalyzer("english", strings.Fields(`a an and are as at be but by for from has have
+	in into is it its of on or our such that the their then there these they this to was we were
+	will with`), Stem)
+
+// Simple : an analyzer for text in any language, which only splits it into
+// words and folds them to lower case.
+var Simple = NewAnalyzer("simple", nil, nil)
+
+var analyzers = map[string]Analyzer{}
+
+// Register : make a available to LookupAnalyzer under its name.
+func Register(a Analyzer) {
+	analyzers[a.Name()] = a
+}
+
+func init() {
+	Register(English)
+	Register(Simple)
+}
+
+// LookupAnalyzer : return the registered analyzer with the given name.
+func LookupAnalyzer(name string) (Analyzer, error) {
+	if a, ok := analyzers[name]; ok {
+		return a, nil
+	}
+	return nil, fmt.Errorf("unknown analyzer %q (want one of %s)", name, strings.Join(AnalyzerNames(), ", "))
+}
+
+// AnalyzerNames : return the names of the registered analyzers, sorted.
+func AnalyzerNames() []string {
+	var names []string
+	for name := range analyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tokenize : split text into words, runs of letters and digits such as "3D"
+// and "i3". Apostrophes within a word are dropped, and so is a possessive
+// 's, so that "maker's" is "maker" and "don't" is "dont".
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		w = strings.Trim(w, "'")
+		w = strings.TrimSuffix(w, "'s")
+		w = strings.Replace(w, "'", "", -1)
+		if w != "" {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,222 @@
+// Package fulltext searches the free text of records, such as descriptions,
+// typical products and the make and model of equipment, ranking matches by
+// BM25. Text is turned into terms by an Analyzer, English by default, and an
+// Index can be kept alongside a registry's own index and brought up to date
+// with it incrementally.
+package fulltext
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+)
+
+// Fields : the YAML paths of the free text that is indexed, in any kind of
+// record. Paths through lists, such as equipment.make, index every item.
+var Fields = []string{
+	"name",
+	"title",
+	"description",
+	"typical_products",
+	"keywords",
+	"equipment.make",
+	"equipment.model",
+	"typical_materials.brand",
+}
+
+// BM25 parameters: K1 controls how quickly repeating a term stops adding to
+// a record's score, and B how much a long text is penalised.
+const (
+	K1 = 1.2
+	B  = 0.75
+)
+
+// Index : an inverted index of the text of records. It is safe for
+// concurrent use.
+type Index struct {
+	analyzer Analyzer
+	mu       sync.RWMutex
+	docs     map[string]*doc
+	// postings : the records each term occurs in, and how often.
+	postings map[string]map[string]int
+	// length : the number of terms in all records together.
+	length int
+}
+
+// doc : an indexed record: its kind, how often each term occurs in it and
+// its number of terms. ModTime and Size identify the version of a
+// registry's record file that was indexed.
+type doc struct {
+	Kind    string         `json:"kind"`
+	Terms   map[string]int `json:"terms"`
+	Length  int            `json:"length"`
+	ModTime time.Time      `json:"mod_time,omitempty"`
+	Size    int64          `json:"size,omitempty"`
+}
+
+// Hit : a record that matches a search, and its BM25 score.
+type Hit struct {
+	ID    string  `json:"id"`
+	Kind  string  `json:"kind"`
+	Score float64 `json:"score"`
+}
+
+// NewIndex : return an empty Index whose text is analysed by a, or by
+// English if a is nil.
+func NewIndex(a Analyzer) *Index {
+	if a == nil {
+		a = English
+	}
+	return &Index{analyzer: a, docs: map[string]*doc{}, postings: map[string]map[string]int{}}
+}
+
+// Analyzer : return the analyzer of ix.
+func (ix *Index) Analyzer() Analyzer {
+	return ix.analyzer
+}
+
+// Add : index the text of record, a typed record of the named kind, under
+// id, replacing any record already indexed under it.
+func (ix *Index) Add(id, kind string, record interface{}) error {
+	d, err := ix.analyze(kind, record)
+	if err != nil {
+		return err
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.put(id, d)
+	return nil
+}
+
+// analyze : return the terms of the text of record.
+func (ix *Index) analyze(kind string, record interface{}) (*doc, error) {
+	tree, err := codec.ToTree(record, codec.YAML)
+	if err != nil {
+		return nil, err
+	}
+	d := &doc{Kind: kind, Terms: map[string]int{}}
+	for _, field := range Fields {
+		for _, text := range Text(tree, field) {
+			for _, term := range ix.analyzer.Analyze(text) {
+				d.Terms[term]++
+				d.Length++
+			}
+		}
+	}
+	return d, nil
+}
+
+// put : index d under id. The caller holds the write lock.
+func (ix *Index) put(id string, d *doc) {
+	ix.remove(id)
+	ix.docs[id] = d
+	ix.length += d.Length
+	for term, n := range d.Terms {
+		if ix.postings[term] == nil {
+			ix.postings[term] = map[string]int{}
+		}
+		ix.postings[term][id] = n
+	}
+}
+
+// Remove : remove the record indexed under id, if there is one.
+func (ix *Index) Remove(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+func (ix *Index) remove(id string) {
+	d, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for term := range d.Terms {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.length -= d.Length
+	delete(ix.docs, id)
+}
+
+// Len : return the number of records indexed.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Search : return the records that contain any of the terms of text, best
+// first by their BM25 score, and by identifier among equals.
+func (ix *Index) Search(text string) []Hit {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	if len(ix.docs) == 0 {
+		return nil
+	}
+	n := float64(len(ix.docs))
+	avg := float64(ix.length) / n
+	scores := map[string]float64{}
+	seen := map[string]bool{}
+	for _, term := range ix.analyzer.Analyze(text) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		postings := ix.postings[term]
+		idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for id, tf := range postings {
+			length := float64(ix.docs[id].Length)
+			norm := 1 - B
+			if avg > 0 {
+				norm += B * length / avg
+			}
+			f := float64(tf)
+			scores[id] += idf * f * (K1 + 1) / (f + K1*norm)
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Kind: ix.docs[id].Kind, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits
+}
+
+// Text : return the strings at the dotted YAML path in tree, looking
+// through lists at every step.
+func Text(tree interface{}, path string) []string {
+	var texts []string
+	var walk func(v interface{}, keys []string)
+	walk = func(v interface{}, keys []string) {
+		switch t := v.(type) {
+		case []interface{}:
+			for _, item := range t {
+				walk(item, keys)
+			}
+		case codec.Map:
+			if len(keys) > 0 {
+				if child, ok := t.Get(keys[0]); ok {
+					walk(child, keys[1:])
+				}
+			}
+		case string:
+			if len(keys) == 0 && t != "" {
+				texts = append(texts, t)
+			}
+		}
+	}
+	walk(tree, strings.Split(path, "."))
+	return texts
+}
@@ -0,0 +1,270 @@
+package fulltext
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
+)
+
+func facility(name, description string, products ...string) *okw.OKW {
+	return &okw.OKW{Name: name, Description: description, TypicalProducts: products}
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := []string{}
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func testIndex(t *testing.T) *Index {
+	ix := NewIndex(nil)
+	for id, record := range map[string]*okw.OKW{
+		"laser":   facility("Laser Lab", "Laser cutting and laser engraving of acrylic and plywood."),
+		"mixed":   facility("Kigali Makers", "Laser cutting, CNC milling, 3D printing, sewing, welding and electronics assembly for the whole city."),
+		"milling": facility("Mill Works", "CNC milling of aluminium parts.", "brackets"),
+		"sewing":  facility("Stitch", "Sewing and embroidery.", "face masks", "gowns"),
+	} {
+		if err := ix.Add(id, "okw", record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ix
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix := testIndex(t)
+	tests := []struct {
+		text string
+		want []string
+	}{
+		// A term repeated scores higher, and so does a shorter text.
+		{"laser", []string{"laser", "mixed"}},
+		{"milling", []string{"milling", "mixed"}},
+		// Words are stemmed and folded, and stop words dropped.
+		{"Lasers", []string{"laser", "mixed"}},
+		{"the milled", []string{"milling", "mixed"}},
+		// The rarer term outweighs the commoner: embroidery is only in sewing.
+		{"laser embroidery", []string{"sewing", "laser", "mixed"}},
+		// Typical products are indexed, and records matching none are left out.
+		{"masks", []string{"sewing"}},
+		{"titanium", []string{}},
+		{"the and of", []string{}},
+	}
+	for _, tt := range tests {
+		if got := hitIDs(ix.Search(tt.text)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+	hits := ix.Search("laser")
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Errorf("Search(laser): %v not best first", hits)
+		}
+	}
+	if hits[0].Kind != "okw" {
+		t.Errorf("Search(laser): kind %q, want okw", hits[0].Kind)
+	}
+}
+
+func TestSearchTies(t *testing.T) {
+	ix := NewIndex(nil)
+	for _, id := range []string{"c", "a", "b"} {
+		if err := ix.Add(id, "okw", facility("Welding", "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := hitIDs(ix.Search("welding")); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("equal scores ordered %v, want by identifier", got)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	ix := testIndex(t)
+	ix.Remove("laser")
+	ix.Remove("missing")
+	if ix.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", ix.Len())
+	}
+	if got := hitIDs(ix.Search("laser")); !reflect.DeepEqual(got, []string{"mixed"}) {
+		t.Errorf("after Remove, Search(laser) = %v, want [mixed]", got)
+	}
+
+	// The scores are those of an index that never had the record.
+	fresh := NewIndex(nil)
+	for id, r := range map[string]*okw.OKW{
+		"mixed":   facility("Kigali Makers", "Laser cutting, CNC milling, 3D printing, sewing, welding and electronics assembly for the whole city."),
+		"milling": facility("Mill Works", "CNC milling of aluminium parts.", "brackets"),
+		"sewing":  facility("Stitch", "Sewing and embroidery.", "face masks", "gowns"),
+	} {
+		fresh.Add(id, "okw", r)
+	}
+	if got, want := ix.Search("cnc milling sewing"), fresh.Search("cnc milling sewing"); !reflect.DeepEqual(got, want) {
+		t.Errorf("after Remove, hits %v, want %v", got, want)
+	}
+
+	// Adding under an identifier already indexed replaces the record.
+	ix.Add("sewing", "okw", facility("Stitch", "Upholstery."))
+	if got := hitIDs(ix.Search("sewing")); !reflect.DeepEqual(got, []string{"mixed"}) {
+		t.Errorf("after replacing, Search(sewing) = %v, want [mixed]", got)
+	}
+	if got := hitIDs(ix.Search("upholstery")); !reflect.DeepEqual(got, []string{"sewing"}) {
+		t.Errorf("after replacing, Search(upholstery) = %v, want [sewing]", got)
+	}
+}
+
+// testRegistry : return a registry in a new directory holding two records.
+func testRegistry(t *testing.T) (*registry.Registry, func()) {
+	dir, err := ioutil.TempDir("", "fulltext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err := registry.Open(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	for id, r := range map[string]*okw.OKW{
+		"okw/laser":   facility("Laser Lab", "Laser cutting."),
+		"okw/milling": facility("Mill Works", "CNC milling."),
+	} {
+		if err := reg.Put(id, r); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+	}
+	return reg, func() { os.RemoveAll(dir) }
+}
+
+func TestSync(t *testing.T) {
+	reg, cleanup := testRegistry(t)
+	defer cleanup()
+	ix, err := Open(reg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := ix.Sync(); err != nil || !changed {
+		t.Fatalf("first Sync() = %v, %v, want true", changed, err)
+	}
+	if changed, err := ix.Sync(); err != nil || changed {
+		t.Fatalf("second Sync() = %v, %v, want false", changed, err)
+	}
+	if got := hitIDs(ix.Search("laser")); !reflect.DeepEqual(got, []string{"okw/laser"}) {
+		t.Errorf("Search(laser) = %v", got)
+	}
+
+	// Files changed by other means are read again, and those removed
+	// dropped.
+	path := filepath.Join(reg.Root(), "okw", "milling.yaml")
+	edited := facility("Mill Works", "CNC milling and laser engraving.")
+	if err := reg.Put("okw/milling", edited); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the change is seen even on file systems with coarse
+	// modification times.
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	if changed, err := ix.Sync(); err != nil || !changed {
+		t.Fatalf("Sync() after an edit = %v, %v, want true", changed, err)
+	}
+	if got := hitIDs(ix.Search("laser")); !reflect.DeepEqual(got, []string{"okw/laser", "okw/milling"}) {
+		t.Errorf("after an edit, Search(laser) = %v", got)
+	}
+	if err := os.Remove(filepath.Join(reg.Root(), "okw", "laser.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := ix.Sync(); err != nil || !changed {
+		t.Fatalf("Sync() after a removal = %v, %v, want true", changed, err)
+	}
+	if got := hitIDs(ix.Search("laser")); !reflect.DeepEqual(got, []string{"okw/milling"}) {
+		t.Errorf("after a removal, Search(laser) = %v", got)
+	}
+
+	// The index is reloaded from its file without reading the records.
+	again, err := Open(reg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Len() != 1 {
+		t.Errorf("reopened Len() = %d, want 1", again.Len())
+	}
+	if got, want := again.Search("cnc laser"), ix.Search("cnc laser"); !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened Search = %v, want %v", got, want)
+	}
+	if changed, err := again.Sync(); err != nil || changed {
+		t.Errorf("reopened Sync() = %v, %v, want false", changed, err)
+	}
+}
+
+func TestOpenRebuilds(t *testing.T) {
+	reg, cleanup := testRegistry(t)
+	defer cleanup()
+	ix, err := Open(reg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(reg.Root(), filepath.FromSlash(IndexPath))
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func() (Analyzer, func())
+	}{
+		{"same", func() (Analyzer, func()) { return nil, func() {} }},
+		{"another analyzer", func() (Analyzer, func()) { return Simple, func() {} }},
+		{"other fields", func() (Analyzer, func()) {
+			fields := Fields
+			Fields = append([]string{"opening_hours"}, fields...)
+			return nil, func() { Fields = fields }
+		}},
+		{"another version", func() (Analyzer, func()) {
+			var f map[string]interface{}
+			if err := json.Unmarshal(saved, &f); err != nil {
+				t.Fatal(err)
+			}
+			f["version"] = indexVersion + 1
+			data, _ := json.Marshal(f)
+			if err := ioutil.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			return nil, func() { ioutil.WriteFile(path, saved, 0644) }
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, restore := tt.change()
+			defer restore()
+			ix, err := Open(reg, a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := 0
+			if tt.name == "same" {
+				want = 2
+			}
+			if ix.Len() != want {
+				t.Fatalf("Open: Len() = %d, want %d", ix.Len(), want)
+			}
+			if changed, err := ix.Sync(); err != nil || changed != (want == 0) {
+				t.Fatalf("Sync() = %v, %v, want %v", changed, err, want == 0)
+			}
+			if ix.Len() != 2 {
+				t.Errorf("after Sync, Len() = %d, want 2", ix.Len())
+			}
+		})
+	}
+}
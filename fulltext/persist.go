@@ -0,0 +1,122 @@
+package fulltext
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+)
+
+// IndexPath : the file a registry's full-text index is kept in, relative to
+// the registry root, next to registry.IndexPath.
+const IndexPath = ".okf/fulltext.json"
+
+// indexVersion : the layout version of the index file. An index of another
+// version, or written with another analyzer or other Fields, is discarded
+// and rebuilt.
+const indexVersion = 1
+
+type indexFile struct {
+	Version  int             `json:"version"`
+	Analyzer string          `json:"analyzer"`
+	Fields   []string        `json:"fields"`
+	Docs     map[string]*doc `json:"docs"`
+}
+
+// Registry : an Index of the records of a registry, kept in IndexPath under
+// its root.
+type Registry struct {
+	*Index
+	reg  *registry.Registry
+	path string
+}
+
+// Open : return the full-text index of the records of r, analysed by a, or
+// by English if a is nil, read from IndexPath if it is there. Call Sync to
+// bring it up to date.
+func Open(r *registry.Registry, a Analyzer) (*Registry, error) {
+	ix := &Registry{Index: NewIndex(a), reg: r, path: filepath.Join(r.Root(), filepath.FromSlash(IndexPath))}
+	data, err := ioutil.ReadFile(ix.path)
+	if os.IsNotExist(err) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f indexFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", ix.path, err)
+	}
+	if f.Version != indexVersion || f.Analyzer != ix.analyzer.Name() || strings.Join(f.Fields, ",") != strings.Join(Fields, ",") {
+		return ix, nil
+	}
+	for id, d := range f.Docs {
+		ix.put(id, d)
+	}
+	return ix, nil
+}
+
+// Sync : bring the index up to date with the registry, after refreshing the
+// registry's own index, and save it if anything changed. Only the records
+// whose files changed since they were indexed are read. It returns whether
+// anything changed.
+func (ix *Registry) Sync() (bool, error) {
+	if _, err := ix.reg.Refresh(); err != nil {
+		return false, err
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	changed := false
+	current := map[string]bool{}
+	for _, e := range ix.reg.List() {
+		if e.Error != "" {
+			continue
+		}
+		current[e.ID] = true
+		if d, ok := ix.docs[e.ID]; ok && d.ModTime.Equal(e.ModTime) && d.Size == e.Size {
+			continue
+		}
+		record, err := ix.reg.Get(e.ID)
+		if err != nil {
+			return changed, err
+		}
+		d, err := ix.analyze(e.Kind, record)
+		if err != nil {
+			return changed, err
+		}
+		d.ModTime, d.Size = e.ModTime, e.Size
+		ix.put(e.ID, d)
+		changed = true
+	}
+	for id := range ix.docs {
+		if !current[id] {
+			ix.remove(id)
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, ix.save()
+}
+
+// save : write the index to its file, replacing the previous one in one
+// step. The caller holds the lock.
+func (ix *Registry) save() error {
+	data, err := json.Marshal(indexFile{Version: indexVersion, Analyzer: ix.analyzer.Name(), Fields: Fields, Docs: ix.docs})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return err
+	}
+	tmp := ix.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ix.path)
+}
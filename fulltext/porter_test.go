@@ -0,0 +1,134 @@
+package fulltext
+
+import "testing"
+
+// porterVectors : words and their stems from the examples of M.F. Porter,
+// "An algorithm for suffix stripping" (1980), run through the whole
+// algorithm, and from the reference vocabulary and output published with
+// it.
+var porterVectors = [][2]string{
+	// Step 1a.
+	{"caresses", "caress"},
+	{"ponies", "poni"},
+	{"ties", "ti"},
+	{"caress", "caress"},
+	{"cats", "cat"},
+	// Step 1b.
+	{"feed", "feed"},
+	{"agreed", "agre"},
+	{"plastered", "plaster"},
+	{"bled", "bled"},
+	{"motoring", "motor"},
+	{"sing", "sing"},
+	{"conflated", "conflat"},
+	{"troubled", "troubl"},
+	{"sized", "size"},
+	{"hopping", "hop"},
+	{"tanned", "tan"},
+	{"falling", "fall"},
+	{"hissing", "hiss"},
+	{"fizzed", "fizz"},
+	{"failing", "fail"},
+	{"filing", "file"},
+	// Step 1c.
+	{"happy", "happi"},
+	{"sky", "sky"},
+	// Step 2.
+	{"relational", "relat"},
+	{"conditional", "condit"},
+	{"rational", "ration"},
+	{"valenci", "valenc"},
+	{"hesitanci", "hesit"},
+	{"digitizer", "digit"},
+	{"conformabli", "conform"},
+	{"radicalli", "radic"},
+	{"differentli", "differ"},
+	{"vileli", "vile"},
+	{"analogousli", "analog"},
+	{"vietnamization", "vietnam"},
+	{"predication", "predic"},
+	{"operator", "oper"},
+	{"feudalism", "feudal"},
+	{"decisiveness", "decis"},
+	{"hopefulness", "hope"},
+	{"callousness", "callous"},
+	{"formaliti", "formal"},
+	{"sensitiviti", "sensit"},
+	{"sensibiliti", "sensibl"},
+	// Step 3.
+	{"triplicate", "triplic"},
+	{"formative", "form"},
+	{"formalize", "formal"},
+	{"electriciti", "electr"},
+	{"electrical", "electr"},
+	{"hopeful", "hope"},
+	{"goodness", "good"},
+	// Step 4.
+	{"revival", "reviv"},
+	{"allowance", "allow"},
+	{"inference", "infer"},
+	{"airliner", "airlin"},
+	{"gyroscopic", "gyroscop"},
+	{"adjustable", "adjust"},
+	{"defensible", "defens"},
+	{"irritant", "irrit"},
+	{"replacement", "replac"},
+	{"adjustment", "adjust"},
+	{"dependent", "depend"},
+	{"adoption", "adopt"},
+	{"homologou", "homolog"},
+	{"communism", "commun"},
+	{"activate", "activ"},
+	{"angulariti", "angular"},
+	{"homologous", "homolog"},
+	{"effective", "effect"},
+	{"bowdlerize", "bowdler"},
+	// Step 5.
+	{"probate", "probat"},
+	{"rate", "rate"},
+	{"cease", "ceas"},
+	{"controll", "control"},
+	{"roll", "roll"},
+	// Several steps.
+	{"generalizations", "gener"},
+	{"oscillators", "oscil"},
+	{"connection", "connect"},
+	{"connections", "connect"},
+	{"connective", "connect"},
+	{"connected", "connect"},
+	{"connecting", "connect"},
+	// From the reference vocabulary.
+	{"abandoned", "abandon"},
+	{"abbreviation", "abbrevi"},
+	{"abilities", "abil"},
+	{"ability", "abil"},
+	{"absolutely", "absolut"},
+	{"accompanied", "accompani"},
+	{"according", "accord"},
+	{"agreement", "agreement"},
+	{"beautiful", "beauti"},
+	{"consistency", "consist"},
+	{"dying", "dy"},
+	{"generous", "gener"},
+	{"knightly", "knightli"},
+	{"lying", "ly"},
+	{"marketing", "market"},
+	{"printing", "print"},
+	{"university", "univers"},
+}
+
+func TestStem(t *testing.T) {
+	for _, v := range porterVectors {
+		if got := Stem(v[0]); got != v[1] {
+			t.Errorf("Stem(%q) = %q, want %q", v[0], got, v[1])
+		}
+	}
+}
+
+func TestStemUnchanged(t *testing.T) {
+	for _, word := range []string{"", "a", "is", "as", "3d", "café", "CNC"} {
+		if got := Stem(word); got != word {
+			t.Errorf("Stem(%q) = %q, want it unchanged", word, got)
+		}
+	}
+}
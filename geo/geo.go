@@ -0,0 +1,211 @@
+// Package geo finds records by where they are: within a distance of a point,
+// or inside a bounding box, using the GPS coordinates of their location. An
+// Index holds the records in memory, bucketed by geohash cell, and results
+// are ordered by great-circle distance.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+)
+
+// EarthRadius : the mean radius of the Earth, in kilometres.
+const EarthRadius = 6371.0088
+
+// Point : a position in decimal degrees.
+type Point struct {
+	Lat float64 `json:"latitude" yaml:"latitude"`
+	Lon float64 `json:"longitude" yaml:"longitude"`
+}
+
+func (p Point) String() string {
+	return strconv.FormatFloat(p.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(p.Lon, 'f', -1, 64)
+}
+
+// Valid : whether p is on the globe.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+// ParsePoint : parse "lat,lon" in decimal degrees, e.g. "-1.9441,30.0619".
+func ParsePoint(s string) (Point, error) {
+	v, err := floats(s, 2)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid point %q: want latitude,longitude", s)
+	}
+	p := Point{Lat: v[0], Lon: v[1]}
+	if !p.Valid() {
+		return Point{}, fmt.Errorf("invalid point %q: out of range", s)
+	}
+	return p, nil
+}
+
+// Box : the area between two parallels and two meridians. A box whose
+// western edge is east of its eastern one crosses the antimeridian.
+type Box struct {
+	South float64 `json:"south" yaml:"south"`
+	West  float64 `json:"west" yaml:"west"`
+	North float64 `json:"north" yaml:"north"`
+	East  float64 `json:"east" yaml:"east"`
+}
+
+// ParseBox : parse "south,west,north,east" in decimal degrees, i.e. the
+// south-west corner followed by the north-east one.
+func ParseBox(s string) (Box, error) {
+	v, err := floats(s, 4)
+	if err != nil {
+		return Box{}, fmt.Errorf("invalid box %q: want south,west,north,east", s)
+	}
+	b := Box{South: v[0], West: v[1], North: v[2], East: v[3]}
+	if !(Point{b.South, b.West}).Valid() || !(Point{b.North, b.East}).Valid() || b.South > b.North {
+		return Box{}, fmt.Errorf("invalid box %q: out of range", s)
+	}
+	return b, nil
+}
+
+// Contains : whether p is inside b, edges included.
+func (b Box) Contains(p Point) bool {
+	if p.Lat < b.South || p.Lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return p.Lon >= b.West && p.Lon <= b.East
+	}
+	return p.Lon >= b.West || p.Lon <= b.East
+}
+
+// Center : the point midway between b's edges.
+func (b Box) Center() Point {
+	east := b.East
+	if b.West > east {
+		east += 360
+	}
+	lon := (b.West + east) / 2
+	if lon > 180 {
+		lon -= 360
+	}
+	return Point{Lat: (b.South + b.North) / 2, Lon: lon}
+}
+
+// split : return b as boxes that do not cross the antimeridian.
+func (b Box) split() []Box {
+	if b.West <= b.East {
+		return []Box{b}
+	}
+	return []Box{{b.South, b.West, b.North, 180}, {b.South, -180, b.North, b.East}}
+}
+
+// BoundingBox : return the smallest box that contains every point within
+// radius kilometres of p.
+func BoundingBox(p Point, radius float64) Box {
+	d := radius / EarthRadius * 180 / math.Pi
+	b := Box{South: math.Max(p.Lat-d, -90), North: math.Min(p.Lat+d, 90), West: -180, East: 180}
+	if b.South == -90 || b.North == 90 {
+		// The circle contains a pole, so every meridian crosses it.
+		return b
+	}
+	// The widest point of the circle is at the latitude where a great
+	// circle through p is tangent to it, not at p's own latitude.
+	dLon := math.Asin(math.Sin(radius/EarthRadius)/math.Cos(p.Lat*math.Pi/180)) * 180 / math.Pi
+	if math.IsNaN(dLon) || dLon >= 180 {
+		return b
+	}
+	b.West, b.East = wrap(p.Lon-dLon), wrap(p.Lon+dLon)
+	return b
+}
+
+// wrap : return lon in the range [-180, 180].
+func wrap(lon float64) float64 {
+	for lon < -180 {
+		lon += 360
+	}
+	for lon > 180 {
+		lon -= 360
+	}
+	return lon
+}
+
+// Distance : return the great-circle distance between a and b, in
+// kilometres, by the haversine formula.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ParseDistance : parse a distance with a unit, "km", "m" or "mi", e.g.
+// "50km", and return it in kilometres. A number without a unit is in
+// kilometres.
+func ParseDistance(s string) (float64, error) {
+	units := []struct {
+		suffix string
+		km     float64
+	}{{"km", 1}, {"mi", 1.609344}, {"m", 0.001}, {"", 1}}
+	for _, u := range units {
+		if !strings.HasSuffix(s, u.suffix) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), 64)
+		if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+			break
+		}
+		return n * u.km, nil
+	}
+	return 0, fmt.Errorf("invalid distance %q: want a number with km, m or mi, e.g. 50km", s)
+}
+
+// PointOf : return the GPS coordinates of record's top-level location, read
+// under their YAML keys. Coordinates of 0,0 are taken to be missing, as in
+// records whose location was never filled in.
+func PointOf(record interface{}) (Point, bool) {
+	tree, err := codec.ToTree(record, codec.YAML)
+	if err != nil {
+		return Point{}, false
+	}
+	root, _ := tree.(codec.Map)
+	location, _ := get(root, "location").(codec.Map)
+	gps, _ := get(location, "gps").(codec.Map)
+	p := Point{Lat: number(gps, "latitude"), Lon: number(gps, "longitude")}
+	if p == (Point{}) || !p.Valid() {
+		return Point{}, false
+	}
+	return p, true
+}
+
+func get(m codec.Map, key string) interface{} {
+	v, _ := m.Get(key)
+	return v
+}
+
+func number(m codec.Map, key string) float64 {
+	switch v := get(m, key).(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
+
+// floats : parse n comma-separated numbers.
+func floats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("want %d numbers", n)
+	}
+	v := make([]float64, n)
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = f
+	}
+	return v, nil
+}
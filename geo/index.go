@@ -0,0 +1,219 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// Precision : the length of the geohashes an Index buckets entries by. Cells
+// of 4 characters are about 39 km wide and 20 km high at the equator, a
+// fair size for searches of tens of kilometres.
+const Precision = 4
+
+// Entry : something indexed at a point, e.g. a record and its identifier.
+type Entry struct {
+	ID    string
+	Point Point
+	Value interface{}
+}
+
+// Hit : an entry found by a search, and its distance from the point the
+// search is ordered by, in kilometres.
+type Hit struct {
+	Entry
+	Distance float64
+}
+
+// Index : entries bucketed by the geohash cell they are in. The zero value
+// is empty and ready to use. An Index is not safe for concurrent writes.
+type Index struct {
+	entries []Entry
+	cells   map[string][]int
+	// ids : the entries indexed under each identifier, for Remove.
+	ids map[string][]int
+	// n : the number of entries indexed and not removed.
+	n int
+}
+
+// Add : index e. Entries whose point is not on the globe are ignored.
+func (ix *Index) Add(e Entry) {
+	if !e.Point.Valid() {
+		return
+	}
+	if ix.cells == nil {
+		ix.cells, ix.ids = map[string][]int{}, map[string][]int{}
+	}
+	cell := Geohash(e.Point, Precision)
+	ix.cells[cell] = append(ix.cells[cell], len(ix.entries))
+	ix.ids[e.ID] = append(ix.ids[e.ID], len(ix.entries))
+	ix.entries = append(ix.entries, e)
+	ix.n++
+}
+
+// Remove : remove the entries indexed under id. Their slots are not
+// reused, so an index that changes often should be rebuilt now and then.
+func (ix *Index) Remove(id string) {
+	for _, i := range ix.ids[id] {
+		cell := Geohash(ix.entries[i].Point, Precision)
+		list := ix.cells[cell]
+		for j, k := range list {
+			if k == i {
+				list = append(list[:j], list[j+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(ix.cells, cell)
+		} else {
+			ix.cells[cell] = list
+		}
+		ix.entries[i] = Entry{}
+		ix.n--
+	}
+	delete(ix.ids, id)
+}
+
+// AddRecord : index record, a typed record, under id at the GPS coordinates
+// of its location, and return whether it has any.
+func (ix *Index) AddRecord(id string, record interface{}) bool {
+	p, ok := PointOf(record)
+	if ok {
+		ix.Add(Entry{ID: id, Point: p, Value: record})
+	}
+	return ok
+}
+
+// Len : return the number of entries indexed.
+func (ix *Index) Len() int {
+	return ix.n
+}
+
+// Near : return the entries within radius kilometres of p, nearest first.
+func (ix *Index) Near(p Point, radius float64) []Hit {
+	var hits []Hit
+	ix.scan(BoundingBox(p, radius), func(e Entry) {
+		if d := Distance(p, e.Point); d <= radius {
+			hits = append(hits, Hit{Entry: e, Distance: d})
+		}
+	})
+	sortHits(hits)
+	return hits
+}
+
+// Within : return the entries inside b, ordered by their distance from
+// from, e.g. the centre of b.
+func (ix *Index) Within(b Box, from Point) []Hit {
+	var hits []Hit
+	ix.scan(b, func(e Entry) {
+		if b.Contains(e.Point) {
+			hits = append(hits, Hit{Entry: e, Distance: Distance(from, e.Point)})
+		}
+	})
+	sortHits(hits)
+	return hits
+}
+
+// scan : call fn with every entry in the cells that overlap b, and perhaps
+// others. Where b covers more cells than there are entries, every entry is
+// visited instead.
+func (ix *Index) scan(b Box, fn func(Entry)) {
+	var cells []string
+	for _, part := range b.split() {
+		cells = appendCells(cells, part, ix.n)
+		if cells == nil {
+			break
+		}
+	}
+	if cells == nil {
+		for _, list := range ix.cells {
+			for _, i := range list {
+				fn(ix.entries[i])
+			}
+		}
+		return
+	}
+	for _, cell := range cells {
+		for _, i := range ix.cells[cell] {
+			fn(ix.entries[i])
+		}
+	}
+}
+
+// appendCells : append to cells the geohashes of the cells that overlap b,
+// which does not cross the antimeridian, or return nil if there would be
+// more than limit of them in all.
+func appendCells(cells []string, b Box, limit int) []string {
+	dLat, dLon := cellSize(Precision)
+	rows := index(b.North+90, dLat, 180) - index(b.South+90, dLat, 180) + 1
+	cols := index(b.East+180, dLon, 360) - index(b.West+180, dLon, 360) + 1
+	if len(cells)+rows*cols > limit {
+		return nil
+	}
+	for i := index(b.South+90, dLat, 180); i <= index(b.North+90, dLat, 180); i++ {
+		for j := index(b.West+180, dLon, 360); j <= index(b.East+180, dLon, 360); j++ {
+			center := Point{Lat: -90 + (float64(i)+0.5)*dLat, Lon: -180 + (float64(j)+0.5)*dLon}
+			cells = append(cells, Geohash(center, Precision))
+		}
+	}
+	if cells == nil {
+		cells = []string{}
+	}
+	return cells
+}
+
+// index : return the index of the cell of size size containing offset, an
+// offset from the start of a range of length span.
+func index(offset, size, span float64) int {
+	i := int(math.Floor(offset / size))
+	if last := int(math.Round(span/size)) - 1; i > last {
+		i = last
+	}
+	return i
+}
+
+func sortHits(hits []Hit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Distance != hits[j].Distance {
+			return hits[i].Distance < hits[j].Distance
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash : return the geohash of p with the given number of characters.
+func Geohash(p Point, precision int) string {
+	lat, lon := [2]float64{-90, 90}, [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+	bits, ch, even := 0, 0, true
+	for len(hash) < precision {
+		r, v := &lat, p.Lat
+		if even {
+			r, v = &lon, p.Lon
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+		if bits++; bits == 5 {
+			hash = append(hash, base32[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// cellSize : return the height and width, in degrees, of the cells of
+// geohashes with the given number of characters.
+func cellSize(precision int) (dLat, dLon float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lonBits))
+}
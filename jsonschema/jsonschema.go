@@ -0,0 +1,131 @@
+// Package jsonschema generates JSON Schema (draft-07) documents from the Go
+// types that define the specifications.
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// Draft : the JSON Schema dialect of generated documents.
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema : a JSON Schema document or sub-schema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	// Deprecated : the property is a legacy key, still accepted but no longer
+	// written. The keyword is an annotation from later drafts.
+	Deprecated  bool               `json:"deprecated,omitempty"`
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+	// SpecVersion : the version of the specification the root schema
+	// describes, i.e. the spec_version of the records it matches.
+	SpecVersion string `json:"x-spec-version,omitempty"`
+}
+
+// Reflect : return the schema of v's type. Property names are taken from the
+// given struct tag ("yaml" or "json"); named struct types other than the root
+// are emitted once under "definitions" and referenced with "$ref". Properties
+// documented in docs get its wording as their description; a definition is
+// described by the path at which it is first reached. Legacy keys are listed
+// as deprecated properties.
+func Reflect(v interface{}, tag string, docs spec.Docs) *Schema {
+	r := &reflector{tag: tag, docs: docs, defs: map[string]*Schema{}}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := r.structSchema(t, "")
+	s.Schema = Draft
+	s.Title = t.Name()
+	if len(r.defs) > 0 {
+		s.Definitions = r.defs
+	}
+	return s
+}
+
+type reflector struct {
+	tag  string
+	docs spec.Docs
+	defs map[string]*Schema
+}
+
+// schema : return the schema of t, found at the YAML path path.
+func (r *reflector) schema(t reflect.Type, path string) *Schema {
+	if t.Kind() == reflect.Ptr {
+		// A pointer field may be null, or missing, when its value is unknown.
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		return &Schema{AnyOf: []*Schema{r.schema(t, path), {Type: "null"}}}
+	}
+	if options, ok := spec.EnumOptions(t); ok && len(options) > 0 {
+		return &Schema{Type: "string", Enum: options}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: r.schema(t.Elem(), path)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem(), path)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t, path)
+		}
+		if _, ok := r.defs[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate.
+			r.defs[t.Name()] = &Schema{}
+			*r.defs[t.Name()] = *r.structSchema(t, path)
+		}
+		return &Schema{Ref: "#/definitions/" + t.Name()}
+	}
+	// Interfaces and anything else the encoders accept without constraint.
+	return &Schema{}
+}
+
+func (r *reflector) structSchema(t reflect.Type, path string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, f := range spec.Fields(t, r.tag) {
+		fieldPath := spec.Key(f.StructField, "yaml")
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+		p := r.schema(f.Type, fieldPath)
+		if doc, ok := r.docs[fieldPath]; ok {
+			p.Description = doc.Description()
+		}
+		s.Properties[f.Key] = p
+		for _, key := range f.Legacy() {
+			if _, ok := s.Properties[key]; !ok {
+				legacy := *p
+				legacy.Description = fmt.Sprintf("Deprecated: use %q.", f.Key)
+				legacy.Deprecated = true
+				s.Properties[key] = &legacy
+			}
+		}
+		if f.Required() {
+			s.Required = append(s.Required, f.Key)
+		}
+	}
+	return s
+}
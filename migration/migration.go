@@ -0,0 +1,281 @@
+// Package migration upgrades Open Knowledge Framework documents from one
+// version of their specification to a later one. Upgrades run on decoded
+// generic trees (see codec.DecodeTree), before the document is decoded into
+// its typed record, so that they can handle keys and shapes the current Go
+// types no longer know.
+package migration
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// Key : the key, in every format, under which a document records the
+// version of the specification it follows.
+const Key = "spec_version"
+
+// Initial : the version of documents that do not record one, i.e. those
+// written before versions were introduced.
+const Initial = "1"
+
+// Step : an upgrade of a kind's documents from one version to the next.
+type Step struct {
+	From string
+	To   string
+	// Summary : one line describing the upgrade, e.g. "Correct misspelt keys".
+	Summary string
+	// Apply : upgrade tree, a document in format f, and return the result and
+	// the changes made. Apply may modify tree in place. It need not set the
+	// version, which Migrate records after each step.
+	Apply func(tree interface{}, f codec.Format) (interface{}, []Change, error)
+	// Keys : the keys the step renames or removes, as dotted paths with "[]"
+	// for the items of lists, e.g. "customer_reviews[].indentifier". A
+	// breaking change to the schema at or under one of them is accounted
+	// for by the step.
+	Keys []string
+}
+
+// Change : a change a Step made to a document.
+type Change struct {
+	// Path : dotted path of the changed key, e.g. "location.gps.logitude".
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (c Change) String() string {
+	return c.Path + ": " + c.Message
+}
+
+// Document : a decoded document of a kind, as a generic tree.
+type Document struct {
+	Kind   string
+	Format codec.Format
+	Tree   interface{}
+}
+
+// Report : what migrating a document did, or would do.
+type Report struct {
+	Kind  string       `json:"kind"`
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Steps []StepReport `json:"steps,omitempty"`
+}
+
+// StepReport : the changes made by one step.
+type StepReport struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Summary string   `json:"summary"`
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Changed : whether the migration changed the document.
+func (r Report) Changed() bool {
+	return r.From != r.To
+}
+
+// Registry : the upgrade steps of each kind, keyed by kind name. The steps
+// of a kind are in order, each starting at the version the previous one
+// ends at, the first at Initial.
+type Registry map[string][]Step
+
+// Latest : return the latest version of kind, i.e. the version its last
+// step upgrades to, or Initial if it has none.
+func (r Registry) Latest(kind string) string {
+	steps := r[kind]
+	if len(steps) == 0 {
+		return Initial
+	}
+	return steps[len(steps)-1].To
+}
+
+// Versions : return the versions of kind, oldest first.
+func (r Registry) Versions(kind string) []string {
+	versions := []string{Initial}
+	for _, s := range r[kind] {
+		versions = append(versions, s.To)
+	}
+	return versions
+}
+
+// Migrate : upgrade doc to the target version of its kind, or to the latest
+// version if target is empty, and return the upgraded document together
+// with a report of the changes. doc itself is not modified, so a dry run is
+// a call whose result is only reported. Downgrades are not supported.
+func (r Registry) Migrate(doc Document, target string) (Document, Report, error) {
+	steps := r[doc.Kind]
+	if err := r.check(doc.Kind); err != nil {
+		return doc, Report{}, err
+	}
+	from, err := Version(doc.Tree)
+	if err != nil {
+		return doc, Report{}, err
+	}
+	if target == "" {
+		target = r.Latest(doc.Kind)
+	}
+	report := Report{Kind: doc.Kind, From: from, To: from}
+	versions := r.Versions(doc.Kind)
+	start, end := index(versions, from), index(versions, target)
+	switch {
+	case start < 0:
+		return doc, report, fmt.Errorf("unknown %s version %q (want one of %s)", doc.Kind, from, strings.Join(versions, ", "))
+	case end < 0:
+		return doc, report, fmt.Errorf("unknown %s version %q (want one of %s)", doc.Kind, target, strings.Join(versions, ", "))
+	case end < start:
+		return doc, report, fmt.Errorf("cannot migrate %s from version %s down to %s", doc.Kind, from, target)
+	}
+	tree := clone(doc.Tree)
+	for _, s := range steps[start:end] {
+		var changes []Change
+		tree, changes, err = s.Apply(tree, doc.Format)
+		if err != nil {
+			return doc, report, fmt.Errorf("migrating %s from version %s to %s: %v", doc.Kind, s.From, s.To, err)
+		}
+		tree = SetVersion(tree, s.To)
+		report.To = s.To
+		report.Steps = append(report.Steps, StepReport{From: s.From, To: s.To, Summary: s.Summary, Changes: changes})
+	}
+	doc.Tree = tree
+	return doc, report, nil
+}
+
+// Between : return the steps of kind that upgrade its documents from
+// version from to version to, in order; none if the versions are the same.
+func (r Registry) Between(kind, from, to string) ([]Step, error) {
+	if err := r.check(kind); err != nil {
+		return nil, err
+	}
+	versions := r.Versions(kind)
+	start, end := index(versions, from), index(versions, to)
+	switch {
+	case start < 0:
+		return nil, fmt.Errorf("unknown %s version %q (want one of %s)", kind, from, strings.Join(versions, ", "))
+	case end < 0:
+		return nil, fmt.Errorf("unknown %s version %q (want one of %s)", kind, to, strings.Join(versions, ", "))
+	case end < start:
+		return nil, fmt.Errorf("%s version %s is older than %s", kind, to, from)
+	}
+	return r[kind][start:end], nil
+}
+
+// Decode : migrate data, a document of kind in format f, to the latest
+// version and decode the result into record. Legacy keys left in the
+// upgraded document are reported as codec.Decode does.
+func (r Registry) Decode(kind string, data []byte, f codec.Format, record interface{}) (Report, []codec.Deprecation, error) {
+	tree, err := codec.DecodeTree(data, f)
+	if err != nil {
+		// Let the typed decoder report the error in its own words.
+		deprecations, err := codec.Decode(data, f, record)
+		return Report{}, deprecations, err
+	}
+	doc, report, err := r.Migrate(Document{Kind: kind, Format: f, Tree: tree}, "")
+	if err != nil {
+		return report, nil, err
+	}
+	if report.Changed() {
+		if data, err = codec.Marshal(doc.Tree, f); err != nil {
+			return report, nil, err
+		}
+	}
+	deprecations, err := codec.Decode(data, f, record)
+	return report, deprecations, err
+}
+
+// check : return an error if the steps of kind do not form a chain from
+// Initial.
+func (r Registry) check(kind string) error {
+	version := Initial
+	for i, s := range r[kind] {
+		if s.From != version {
+			return fmt.Errorf("%s migration step %d starts at version %q, want %q", kind, i+1, s.From, version)
+		}
+		version = s.To
+	}
+	return nil
+}
+
+// Version : return the version recorded by the document tree, or Initial if
+// it records none.
+func Version(tree interface{}) (string, error) {
+	m, ok := tree.(codec.Map)
+	if !ok {
+		return Initial, nil
+	}
+	v, _ := m.Get(Key)
+	switch v := v.(type) {
+	case nil:
+		return Initial, nil
+	case string:
+		if v == "" {
+			return Initial, nil
+		}
+		return v, nil
+	case int64, float64:
+		// An unquoted YAML or JSON number such as spec_version: 2.
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("%s must be a string, not %v", Key, v)
+}
+
+// SetVersion : record version in the document tree, as its first key if it
+// records none yet, and return the result.
+func SetVersion(tree interface{}, version string) interface{} {
+	m, _ := tree.(codec.Map)
+	for i := range m {
+		if m[i].Key == Key {
+			m[i].Value = version
+			return m
+		}
+	}
+	return append(codec.Map{{Key: Key, Value: version}}, m...)
+}
+
+// Stamp : set the spec_version field of record, a pointer to a typed
+// record, to version. Records without such a field are left alone.
+func Stamp(record interface{}, version string) {
+	v := reflect.ValueOf(record)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for _, f := range spec.Fields(v.Type(), "yaml") {
+		if f.Key == Key && f.Type.Kind() == reflect.String {
+			v.FieldByIndex(f.Index).SetString(version)
+		}
+	}
+}
+
+func index(versions []string, version string) int {
+	for i, v := range versions {
+		if v == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// clone : return a deep copy of tree.
+func clone(tree interface{}) interface{} {
+	switch t := tree.(type) {
+	case codec.Map:
+		m := make(codec.Map, len(t))
+		for i, item := range t {
+			m[i] = codec.MapItem{Key: item.Key, Value: clone(item.Value)}
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, v := range t {
+			s[i] = clone(v)
+		}
+		return s
+	}
+	return tree
+}
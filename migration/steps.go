@@ -0,0 +1,74 @@
+package migration
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// CorrectLegacyKeys : return a Step.Apply function that renames the legacy
+// keys declared by the legacy tags of record's type, as codec.Upgrade does.
+func CorrectLegacyKeys(record interface{}) func(interface{}, codec.Format) (interface{}, []Change, error) {
+	t := reflect.TypeOf(record)
+	return func(tree interface{}, f codec.Format) (interface{}, []Change, error) {
+		tree, deprecations := codec.Upgrade(tree, t, f)
+		changes := make([]Change, len(deprecations))
+		for i, d := range deprecations {
+			changes[i] = Change{Path: d.Path, Message: fmt.Sprintf("renamed %q to %q", d.Key, d.Replacement)}
+		}
+		return tree, changes, nil
+	}
+}
+
+// LegacyKeys : return the paths of the legacy keys declared by the legacy
+// tags of record's type, under both its YAML and JSON keys, for the Keys of
+// a step that applies CorrectLegacyKeys. Paths are in the form of
+// Step.Keys; a type reached at several paths is listed at each.
+func LegacyKeys(record interface{}) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, tag := range []string{"yaml", "json"} {
+		legacyKeys(reflect.TypeOf(record), tag, "", map[reflect.Type]bool{}, func(path string) {
+			if !seen[path] {
+				seen[path] = true
+				keys = append(keys, path)
+			}
+		})
+	}
+	return keys
+}
+
+// legacyKeys : call fn with the path of each legacy key of t, at path,
+// not following the types in walking, those the path already goes through.
+func legacyKeys(t reflect.Type, tag, path string, walking map[reflect.Type]bool, fn func(string)) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		legacyKeys(t.Elem(), tag, path+"[]", walking, fn)
+	case reflect.Map:
+		legacyKeys(t.Elem(), tag, join(path, "*"), walking, fn)
+	case reflect.Struct:
+		if walking[t] {
+			return
+		}
+		walking[t] = true
+		defer delete(walking, t)
+		for _, f := range spec.Fields(t, tag) {
+			for _, key := range f.Legacy() {
+				fn(join(path, key))
+			}
+			legacyKeys(f.Type, tag, join(path, f.Key), walking, fn)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,291 @@
+package query
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/geo"
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+)
+
+// Index : records in memory, with an inverted index from each field and
+// value to the records that have it, and a spatial index of their
+// locations. The zero value is not usable; call NewIndex. An Index is not
+// safe for concurrent writes.
+type Index struct {
+	docs []doc
+	// byID : the position in docs of each record indexed.
+	byID map[string]int
+	// removed : the number of docs left empty by Remove.
+	removed int
+	// terms : the records with each normalized value of each field, in the
+	// order they were added.
+	terms map[string]map[string][]int
+	geo   geo.Index
+}
+
+// doc : an indexed record, and its scalar values by field. A doc whose
+// record was removed has no values and is skipped.
+type doc struct {
+	id      string
+	kind    string
+	record  interface{}
+	values  map[string][]interface{}
+	removed bool
+}
+
+// Result : a record a query matched. Distance is the record's distance in
+// kilometres from the point of the query's within, if it has one, and Score
+// its full-text score once the results are ranked by Rank.
+type Result struct {
+	ID       string
+	Kind     string
+	Record   interface{}
+	Distance *float64
+	Score    *float64
+}
+
+// NewIndex : return an empty Index.
+func NewIndex() *Index {
+	return &Index{byID: map[string]int{}, terms: map[string]map[string][]int{}}
+}
+
+// FromRegistry : return an Index of every record in r that can be decoded,
+// under its registry identifier.
+func FromRegistry(r *registry.Registry) (*Index, error) {
+	ix := NewIndex()
+	for _, e := range r.List() {
+		if e.Error != "" {
+			continue
+		}
+		record, err := r.Get(e.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := ix.Add(e.ID, e.Kind, record); err != nil {
+			return nil, err
+		}
+	}
+	return ix, nil
+}
+
+// Add : index record, a typed record of the named kind, under id,
+// replacing any record already indexed under it. Its values are read under
+// their YAML keys.
+func (ix *Index) Add(id, kind string, record interface{}) error {
+	tree, err := codec.ToTree(record, codec.YAML)
+	if err != nil {
+		return err
+	}
+	d := doc{id: id, kind: kind, record: record, values: map[string][]interface{}{}}
+	add := func(field string, v interface{}) {
+		d.values[field] = append(d.values[field], v)
+	}
+	add("kind", kind)
+	leaves(tree, "", "", func(path, key string, v interface{}) {
+		add(path, v)
+		for _, alias := range aliasesOf(path, key) {
+			add(alias, v)
+		}
+	})
+	ix.Remove(id)
+	ix.insert(d)
+	return nil
+}
+
+// insert : add d to the inverted and spatial indexes.
+func (ix *Index) insert(d doc) {
+	n := len(ix.docs)
+	for field, vs := range d.values {
+		for _, v := range vs {
+			term := normalize(v)
+			if term == "" {
+				continue
+			}
+			values := ix.terms[field]
+			if values == nil {
+				values = map[string][]int{}
+				ix.terms[field] = values
+			}
+			if docs := values[term]; len(docs) == 0 || docs[len(docs)-1] != n {
+				values[term] = append(docs, n)
+			}
+		}
+	}
+	ix.docs = append(ix.docs, d)
+	ix.byID[d.id] = n
+	if p, ok := geo.PointOf(d.record); ok {
+		ix.geo.Add(geo.Entry{ID: d.id, Point: p, Value: n})
+	}
+}
+
+// Remove : remove the record indexed under id, if there is one. Once more
+// than half the index is removed records, it is rebuilt from the others.
+func (ix *Index) Remove(id string) {
+	n, ok := ix.byID[id]
+	if !ok {
+		return
+	}
+	for field, vs := range ix.docs[n].values {
+		for _, v := range vs {
+			term := normalize(v)
+			docs := ix.terms[field][term]
+			if i := sort.SearchInts(docs, n); i < len(docs) && docs[i] == n {
+				docs = append(docs[:i], docs[i+1:]...)
+			}
+			if len(docs) == 0 {
+				delete(ix.terms[field], term)
+			} else {
+				ix.terms[field][term] = docs
+			}
+		}
+	}
+	ix.geo.Remove(id)
+	ix.docs[n] = doc{removed: true}
+	delete(ix.byID, id)
+	ix.removed++
+	if ix.removed > len(ix.docs)/2 {
+		docs := ix.docs
+		*ix = *NewIndex()
+		for _, d := range docs {
+			if !d.removed {
+				ix.insert(d)
+			}
+		}
+	}
+}
+
+// Len : return the number of records indexed.
+func (ix *Index) Len() int {
+	return len(ix.docs) - ix.removed
+}
+
+// Search : return the records that n matches. See Plan.Run for their order.
+func (ix *Index) Search(n Node) []Result {
+	return ix.Plan(n).Run()
+}
+
+// leaves : call fn with the dotted path, last key and value of every scalar
+// in tree. Sequences do not add to the path, so that every item of
+// certifications is at "certifications".
+func leaves(tree interface{}, path, key string, fn func(path, key string, v interface{})) {
+	switch t := tree.(type) {
+	case codec.Map:
+		for _, item := range t {
+			p := item.Key
+			if path != "" {
+				p = path + "." + item.Key
+			}
+			leaves(item.Value, p, item.Key, fn)
+		}
+	case []interface{}:
+		for _, v := range t {
+			leaves(v, path, key, fn)
+		}
+	case nil:
+	default:
+		fn(path, key, t)
+	}
+}
+
+// aliasesOf : return the aliases of the field at path, whose last key is
+// key.
+func aliasesOf(path, key string) []string {
+	var aliases []string
+	for alias, paths := range Aliases {
+		for _, p := range paths {
+			if p == path || p == ".."+key {
+				aliases = append(aliases, alias)
+				break
+			}
+		}
+	}
+	return aliases
+}
+
+// normalize : return the form of v that values are compared in: numbers
+// formatted alike, URLs cut to their last path segment, and text in lower
+// case without spaces or punctuation.
+func normalize(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if strings.Contains(v, "://") {
+			if u, err := url.Parse(v); err == nil {
+				p := strings.TrimSuffix(u.Path, "/")
+				v = p[strings.LastIndex(p, "/")+1:]
+			}
+		}
+		return strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, v)
+	}
+	return ""
+}
+
+// number : return v as a number, if it is one or is text that is one.
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// compare : whether v compares to value by op, one of ">", ">=", "<" and
+// "<=". Numbers compare as numbers, and other values as text ignoring case,
+// which orders RFC 3339 and ISO 8601 dates correctly.
+func compare(v interface{}, op, value string) bool {
+	var c int
+	a, aok := number(v)
+	b, bok := number(value)
+	switch {
+	case aok && bok && a < b:
+		c = -1
+	case aok && bok && a > b:
+		c = 1
+	case aok && bok:
+	default:
+		var text string
+		switch v := v.(type) {
+		case string:
+			text = v
+		case bool:
+			text = strconv.FormatBool(v)
+		default:
+			return false
+		}
+		c = strings.Compare(strings.ToLower(text), strings.ToLower(value))
+	}
+	switch op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
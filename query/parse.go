@@ -0,0 +1,443 @@
+// Package query finds records with a small query language over their fields,
+// for example:
+//
+//	process:milling* AND certification:"ISO 13485" AND status:Active
+//	    AND backup_generator:true AND within(12.3,45.6,50km)
+//
+// A term is a field, a colon and a value. Fields are YAML paths such as
+// location.address.city, or the short names in Aliases. Values are compared
+// ignoring case, spaces and punctuation, so that ISO13485 matches "ISO 13485",
+// and a URL matches by its last path segment, so that process:laser_cutting
+// matches https://en.wikipedia.org/wiki/Laser_cutting. A value ending in *
+// matches by prefix, and one starting with >, >=, < or <= compares numbers,
+// or dates and other text, in order. within(lat,lon,distance) and
+// bbox(south,west,north,east) match records by the GPS coordinates of their
+// location. Terms are combined with AND, OR, NOT and parentheses; terms side
+// by side are ANDed.
+//
+// An Index holds records in memory with an inverted index of their field
+// values and a spatial index of their locations, and Plan chooses how to use
+// them to evaluate a query.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/geo"
+)
+
+// Node : a parsed query, or part of one. String returns it in canonical
+// form, which parses to the same query.
+type Node interface {
+	String() string
+}
+
+// And : matches records that all of Nodes match.
+type And struct {
+	Nodes []Node
+}
+
+// Or : matches records that any of Nodes matches.
+type Or struct {
+	Nodes []Node
+}
+
+// Not : matches records that Node does not match.
+type Not struct {
+	Node Node
+}
+
+// Term : matches records with a value of Field that compares to Value by Op.
+type Term struct {
+	Field string
+	// Op : one of ":" (equal), "*" (prefix), ">", ">=", "<" and "<=".
+	Op    string
+	Value string
+}
+
+// Within : matches records within Radius kilometres of Point.
+type Within struct {
+	Point  geo.Point
+	Radius float64
+}
+
+// InBox : matches records inside Box.
+type InBox struct {
+	Box geo.Box
+}
+
+func (n And) String() string { return join(n.Nodes, " AND ") }
+
+func (n Or) String() string { return join(n.Nodes, " OR ") }
+
+func (n Not) String() string {
+	switch n.Node.(type) {
+	case And, Or:
+		return "NOT (" + n.Node.String() + ")"
+	}
+	return "NOT " + n.Node.String()
+}
+
+func (n Term) String() string {
+	value := n.Value
+	if (value == "" && n.Op != "*") || strings.ContainsAny(value, " \t\"()*<>=") || isKeyword(value) {
+		value = strconv.Quote(value)
+	}
+	switch n.Op {
+	case ":":
+		return n.Field + ":" + value
+	case "*":
+		return n.Field + ":" + value + "*"
+	}
+	return n.Field + ":" + n.Op + value
+}
+
+func (n Within) String() string {
+	return fmt.Sprintf("within(%s,%skm)", n.Point, strconv.FormatFloat(n.Radius, 'f', -1, 64))
+}
+
+func (n InBox) String() string {
+	b := n.Box
+	return fmt.Sprintf("bbox(%s)", strings.Join([]string{
+		strconv.FormatFloat(b.South, 'f', -1, 64), strconv.FormatFloat(b.West, 'f', -1, 64),
+		strconv.FormatFloat(b.North, 'f', -1, 64), strconv.FormatFloat(b.East, 'f', -1, 64),
+	}, ","))
+}
+
+// join : join the canonical forms of nodes with sep, parenthesising those
+// that bind less tightly.
+func join(nodes []Node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.String()
+		if _, ok := n.(Or); ok && sep == " AND " {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func isKeyword(s string) bool {
+	return s == "AND" || s == "OR" || s == "NOT"
+}
+
+// Aliases : short names for fields, and the YAML paths they stand for. A
+// path ending in ".." stands for a key at any depth.
+var Aliases = map[string][]string{
+	"process":       {"..manufacturing_process", "..manufacturing_processes", "..manufacturing-processes"},
+	"certification": {"certifications"},
+	"status":        {"facility_status"},
+	"name":          {"name", "title"},
+	"city":          {"location.address.city"},
+	"region":        {"location.address.region"},
+	"country":       {"location.address.country"},
+	"kind":          {},
+}
+
+// Parse : parse a query.
+func Parse(s string) (Node, error) {
+	p := &parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	return n, nil
+}
+
+// Conjoin : return the query that matches what every one of nodes does,
+// leaving out nil nodes, or nil if there are none.
+func Conjoin(nodes ...Node) Node {
+	var and And
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case nil:
+		case And:
+			and.Nodes = append(and.Nodes, n.Nodes...)
+		default:
+			and.Nodes = append(and.Nodes, n)
+		}
+	}
+	switch len(and.Nodes) {
+	case 0:
+		return nil
+	case 1:
+		return and.Nodes[0]
+	}
+	return and
+}
+
+// DefaultRadius : the radius Build searches near a point within when none
+// is given.
+const DefaultRadius = "50km"
+
+// Build : return the query that matches the records that match q, if it is
+// not blank, are within radius of near, a point given as lat,lon, and are
+// inside box, given as south,west,north,east, or nil if none is given. radius
+// defaults to DefaultRadius, and may only be given with near. This is how
+// both okf search and the server combine their query, point and box.
+func Build(q, near, radius, box string) (Node, error) {
+	if radius != "" && near == "" {
+		return nil, fmt.Errorf("radius requires near")
+	}
+	var nodes []Node
+	if strings.TrimSpace(q) != "" {
+		n, err := Parse(q)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if near != "" {
+		p, err := geo.ParsePoint(near)
+		if err != nil {
+			return nil, err
+		}
+		if radius == "" {
+			radius = DefaultRadius
+		}
+		r, err := geo.ParseDistance(radius)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, Within{Point: p, Radius: r})
+	}
+	if box != "" {
+		b, err := geo.ParseBox(box)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, InBox{Box: b})
+	}
+	return Conjoin(nodes...), nil
+}
+
+// token : a word or a parenthesis, and its byte offset in the query.
+// Quoted is whether the word starts with a quoted string, and so cannot be a
+// keyword, and literal whether it contains one. inQuotes tells, for each byte
+// of text, whether it was quoted, so that a quoted * or > is not an operator.
+type token struct {
+	text     string
+	pos      int
+	quoted   bool
+	literal  bool
+	inQuotes []bool
+}
+
+// tokenize : split s into tokens. A word runs up to a space or parenthesis,
+// and may contain quoted strings, as in city:"New York".
+func tokenize(s string) []token {
+	var tokens []token
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: s[i : i+1], pos: i})
+			i++
+		default:
+			start, literal := i, false
+			var text strings.Builder
+			var inQuotes []bool
+			for i < len(s) && !strings.ContainsRune(" \t\n\r()", rune(s[i])) {
+				if s[i] != '"' {
+					text.WriteByte(s[i])
+					inQuotes = append(inQuotes, false)
+					i++
+					continue
+				}
+				literal = true
+				for i++; i < len(s) && s[i] != '"'; i++ {
+					if s[i] == '\\' && i+1 < len(s) {
+						i++
+					}
+					text.WriteByte(s[i])
+					inQuotes = append(inQuotes, true)
+				}
+				i++
+			}
+			tokens = append(tokens, token{text: text.String(), pos: start, quoted: s[start] == '"', literal: literal, inQuotes: inQuotes})
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next], true
+	}
+	return token{}, false
+}
+
+// keyword : consume the next token if it is the unquoted keyword word.
+func (p *parser) keyword(word string) bool {
+	if t, ok := p.peek(); ok && !t.quoted && t.text == word {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (Node, error) {
+	n, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	or := Or{Nodes: []Node{n}}
+	for p.keyword("OR") {
+		if n, err = p.and(); err != nil {
+			return nil, err
+		}
+		or.Nodes = append(or.Nodes, n)
+	}
+	if len(or.Nodes) == 1 {
+		return or.Nodes[0], nil
+	}
+	return or, nil
+}
+
+func (p *parser) and() (Node, error) {
+	var nodes []Node
+	for {
+		if len(nodes) > 0 && !p.keyword("AND") {
+			if t, ok := p.peek(); !ok || (!t.quoted && (t.text == ")" || t.text == "OR")) {
+				break
+			}
+		}
+		n, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return Conjoin(nodes...), nil
+}
+
+func (p *parser) not() (Node, error) {
+	if p.keyword("NOT") {
+		n, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: n}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Node, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	p.next++
+	if t.text == "(" && !t.quoted {
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("missing ) for ( at offset %d", t.pos)
+		}
+		return n, nil
+	}
+	if t.quoted || t.text == ")" || isKeyword(t.text) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	if open, ok := p.peek(); ok && open.text == "(" && !open.quoted && (t.text == "within" || t.text == "bbox") {
+		return p.function(t)
+	}
+	return parseTerm(t)
+}
+
+// function : parse the arguments of within or bbox, named by t, up to the
+// closing parenthesis.
+func (p *parser) function(t token) (Node, error) {
+	p.next++
+	var args []string
+	for {
+		a, ok := p.peek()
+		if !ok {
+			return nil, fmt.Errorf("missing ) for %s at offset %d", t.text, t.pos)
+		}
+		p.next++
+		if a.text == ")" && !a.quoted {
+			break
+		}
+		args = append(args, a.text)
+	}
+	s := strings.Join(args, "")
+	if t.text == "bbox" {
+		b, err := geo.ParseBox(s)
+		if err != nil {
+			return nil, fmt.Errorf("bbox at offset %d: %v", t.pos, err)
+		}
+		return InBox{Box: b}, nil
+	}
+	i := strings.LastIndex(s, ",")
+	if i < 0 {
+		return nil, fmt.Errorf("within at offset %d: want within(latitude,longitude,distance)", t.pos)
+	}
+	point, err := geo.ParsePoint(s[:i])
+	if err != nil {
+		return nil, fmt.Errorf("within at offset %d: %v", t.pos, err)
+	}
+	radius, err := geo.ParseDistance(s[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("within at offset %d: %v", t.pos, err)
+	}
+	return Within{Point: point, Radius: radius}, nil
+}
+
+// parseTerm : parse field:value, where value may start with a comparison
+// operator or end with *, unless they are quoted.
+func parseTerm(t token) (Node, error) {
+	i := strings.Index(t.text, ":")
+	if i <= 0 || t.inQuotes[i] {
+		return nil, fmt.Errorf("invalid term %q at offset %d: want field:value", t.text, t.pos)
+	}
+	term := Term{Field: t.text[:i], Op: ":", Value: t.text[i+1:]}
+	if err := checkField(term.Field); err != nil {
+		return nil, fmt.Errorf("%v at offset %d", err, t.pos)
+	}
+	quoted := t.inQuotes[i+1:]
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(term.Value, op) && !quoted[0] {
+			term.Op, term.Value = op, term.Value[len(op):]
+			break
+		}
+	}
+	if term.Op == ":" && strings.HasSuffix(term.Value, "*") && !quoted[len(quoted)-1] {
+		term.Op, term.Value = "*", strings.TrimSuffix(term.Value, "*")
+	}
+	if term.Value == "" && term.Op != "*" && !t.literal {
+		return nil, fmt.Errorf("invalid term %q at offset %d: missing value", t.text, t.pos)
+	}
+	return term, nil
+}
+
+// checkField : check that field is an alias or a YAML path of some kind of
+// record.
+func checkField(field string) error {
+	if _, ok := Aliases[field]; ok {
+		return nil
+	}
+	for _, k := range document.Kinds() {
+		if _, ok := k.Docs[field]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown field %q", field)
+}
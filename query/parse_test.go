@@ -0,0 +1,202 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/helpfulengineering/open-knowledge-framework/geo"
+)
+
+func term(field, op, value string) Term {
+	return Term{Field: field, Op: op, Value: value}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Node
+		// canonical : the String of the query, if it differs from query.
+		canonical string
+	}{
+		{"term", "city:Kigali", term("city", ":", "Kigali"), ""},
+		{"path", "location.address.city:Kigali", term("location.address.city", ":", "Kigali"), ""},
+		{"implicit and", "city:Kigali status:Active",
+			And{Nodes: []Node{term("city", ":", "Kigali"), term("status", ":", "Active")}},
+			"city:Kigali AND status:Active"},
+		{"explicit and", "city:Kigali AND status:Active",
+			And{Nodes: []Node{term("city", ":", "Kigali"), term("status", ":", "Active")}}, ""},
+		{"and binds tighter than or", "city:Kigali OR city:Lyon AND status:Active",
+			Or{Nodes: []Node{
+				term("city", ":", "Kigali"),
+				And{Nodes: []Node{term("city", ":", "Lyon"), term("status", ":", "Active")}},
+			}}, ""},
+		{"implicit and binds tighter than or", "city:Kigali status:Active OR city:Lyon",
+			Or{Nodes: []Node{
+				And{Nodes: []Node{term("city", ":", "Kigali"), term("status", ":", "Active")}},
+				term("city", ":", "Lyon"),
+			}}, "city:Kigali AND status:Active OR city:Lyon"},
+		{"parentheses", "(city:Kigali OR city:Lyon) AND status:Active",
+			And{Nodes: []Node{
+				Or{Nodes: []Node{term("city", ":", "Kigali"), term("city", ":", "Lyon")}},
+				term("status", ":", "Active"),
+			}}, ""},
+		{"nested ands flatten", "city:Kigali AND (status:Active AND country:Rwanda)",
+			And{Nodes: []Node{term("city", ":", "Kigali"), term("status", ":", "Active"), term("country", ":", "Rwanda")}},
+			"city:Kigali AND status:Active AND country:Rwanda"},
+		{"not", "NOT status:Closed", Not{Node: term("status", ":", "Closed")}, ""},
+		{"not binds tighter than and", "NOT status:Closed city:Kigali",
+			And{Nodes: []Node{Not{Node: term("status", ":", "Closed")}, term("city", ":", "Kigali")}},
+			"NOT status:Closed AND city:Kigali"},
+		{"not of a group", "NOT (city:Kigali OR city:Lyon)",
+			Not{Node: Or{Nodes: []Node{term("city", ":", "Kigali"), term("city", ":", "Lyon")}}}, ""},
+		{"double not", "NOT NOT city:Kigali", Not{Node: Not{Node: term("city", ":", "Kigali")}}, ""},
+		{"quoted value", `certification:"ISO 13485"`, term("certification", ":", "ISO 13485"), ""},
+		{"quoted keyword", `name:"OR"`, term("name", ":", "OR"), ""},
+		{"quoted empty value", `city:""`, term("city", ":", ""), ""},
+		{"escaped quote", `name:"The \"Lab\""`, term("name", ":", `The "Lab"`), ""},
+		{"quoted parenthesis", `name:"Lab (Kigali)"`, term("name", ":", "Lab (Kigali)"), ""},
+		{"quoted star is literal", `name:"Lab*"`, term("name", ":", "Lab*"), ""},
+		{"prefix", "process:mill*", term("process", "*", "mill"), ""},
+		{"empty prefix", "city:*", term("city", "*", ""), ""},
+		{"greater or equal", "size_floor_size:>=900", term("size_floor_size", ">=", "900"), ""},
+		{"less", "metadata.verified:<2024-01-01", term("metadata.verified", "<", "2024-01-01"), ""},
+		{"greater", "size_floor_size:>10", term("size_floor_size", ">", "10"), ""},
+		{"less or equal", "size_floor_size:<=10", term("size_floor_size", "<=", "10"), ""},
+		{"within", "within(12.3,45.6,50km)", Within{Point: geo.Point{Lat: 12.3, Lon: 45.6}, Radius: 50}, ""},
+		{"within with spaces", "within( 12.3, 45.6 , 50km )", Within{Point: geo.Point{Lat: 12.3, Lon: 45.6}, Radius: 50},
+			"within(12.3,45.6,50km)"},
+		{"within in miles", "within(12.3,45.6,1mi)", Within{Point: geo.Point{Lat: 12.3, Lon: 45.6}, Radius: 1.609344},
+			"within(12.3,45.6,1.609344km)"},
+		{"bbox", "bbox(-2, 29, -1, 31)", InBox{Box: geo.Box{South: -2, West: 29, North: -1, East: 31}}, "bbox(-2,29,-1,31)"},
+		{"within and terms", "process:milling* AND within(12.3,45.6,50km)",
+			And{Nodes: []Node{term("process", "*", "milling"), Within{Point: geo.Point{Lat: 12.3, Lon: 45.6}, Radius: 50}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse(%q) = %#v, want %#v", tt.query, got, tt.want)
+			}
+			canonical := tt.canonical
+			if canonical == "" {
+				canonical = tt.query
+			}
+			if s := got.String(); s != canonical {
+				t.Errorf("String() = %q, want %q", s, canonical)
+			}
+			again, err := Parse(got.String())
+			if err != nil {
+				t.Fatalf("Parse(%q), of String(): %v", got.String(), err)
+			}
+			if !reflect.DeepEqual(again, got) {
+				t.Errorf("Parse(%q), of String(), = %#v, want %#v", got.String(), again, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		// want : a part of the error.
+		want string
+	}{
+		{"", "empty query"},
+		{"   ", "empty query"},
+		{"city:", "missing value"},
+		{"Kigali", "want field:value"},
+		{":Kigali", "want field:value"},
+		{"colour:red", `unknown field "colour"`},
+		{"(city:Kigali", "missing ) for ( at offset 0"},
+		{"city:Kigali)", `unexpected ")" at offset 11`},
+		{"AND city:Kigali", `unexpected "AND" at offset 0`},
+		{"city:Kigali AND", "unexpected end of query"},
+		{"city:Kigali OR", "unexpected end of query"},
+		{"NOT", "unexpected end of query"},
+		{`"city:Kigali"`, `unexpected "city:Kigali"`},
+		{"within(12.3,45.6", "missing ) for within"},
+		{"within(12.3)", "want within(latitude,longitude,distance)"},
+		{"within(95,45.6,5km)", "within at offset 0"},
+		{"within(12.3,45.6,far)", "within at offset 0"},
+		{"bbox(1,2,3)", "bbox at offset 0"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		if err == nil {
+			t.Errorf("Parse(%q): no error, want one containing %q", tt.query, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): error %q, want one containing %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestConjoin(t *testing.T) {
+	a, b, c := term("city", ":", "a"), term("city", ":", "b"), term("city", ":", "c")
+	tests := []struct {
+		nodes []Node
+		want  Node
+	}{
+		{nil, nil},
+		{[]Node{nil, nil}, nil},
+		{[]Node{a}, a},
+		{[]Node{nil, a}, a},
+		{[]Node{a, b}, And{Nodes: []Node{a, b}}},
+		{[]Node{And{Nodes: []Node{a, b}}, c}, And{Nodes: []Node{a, b, c}}},
+	}
+	for _, tt := range tests {
+		if got := Conjoin(tt.nodes...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Conjoin(%v) = %#v, want %#v", tt.nodes, got, tt.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	kigali := geo.Point{Lat: -1.9441, Lon: 30.0619}
+	tests := []struct {
+		q, near, radius, box string
+		want                 Node
+		// err : a part of the error.
+		err string
+	}{
+		{"", "", "", "", nil, ""},
+		{"  ", "", "", "", nil, ""},
+		{"city:Kigali", "", "", "", term("city", ":", "Kigali"), ""},
+		{"", "-1.9441,30.0619", "", "", Within{Point: kigali, Radius: 50}, ""},
+		{"", "-1.9441,30.0619", "2mi", "", Within{Point: kigali, Radius: 3.218688}, ""},
+		{"", "", "", "-2,29,-1,31", InBox{Box: geo.Box{South: -2, West: 29, North: -1, East: 31}}, ""},
+		{"city:Kigali status:Active", "-1.9441,30.0619", "5km", "-2,29,-1,31", And{Nodes: []Node{
+			term("city", ":", "Kigali"), term("status", ":", "Active"),
+			Within{Point: kigali, Radius: 5},
+			InBox{Box: geo.Box{South: -2, West: 29, North: -1, East: 31}},
+		}}, ""},
+		{"", "", "5km", "", nil, "radius requires near"},
+		{"city:Kigali", "", "5km", "-2,29,-1,31", nil, "radius requires near"},
+		{"", "-1.9441", "", "", nil, "-1.9441"},
+		{"", "-1.9441,30.0619", "far", "", nil, "invalid distance"},
+		{"", "", "", "-2,29", nil, "-2,29"},
+		{"city:", "", "", "", nil, "missing value"},
+	}
+	for _, tt := range tests {
+		got, err := Build(tt.q, tt.near, tt.radius, tt.box)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Build(%q, %q, %q, %q): error %v, want one containing %q", tt.q, tt.near, tt.radius, tt.box, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Build(%q, %q, %q, %q): %v", tt.q, tt.near, tt.radius, tt.box, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Build(%q, %q, %q, %q) = %#v, want %#v", tt.q, tt.near, tt.radius, tt.box, got, tt.want)
+		}
+	}
+}
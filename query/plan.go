@@ -0,0 +1,338 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/fulltext"
+	"github.com/helpfulengineering/open-knowledge-framework/geo"
+)
+
+// Plan : how a query is evaluated against an Index. Each AND is driven by
+// the part expected to match the fewest records, looked up in the inverted
+// or spatial index, and its other parts are checked against those records
+// only. Comparisons cannot use an index and are checked record by record.
+type Plan struct {
+	ix    *Index
+	root  *step
+	query Node
+}
+
+// step : the plan for one node of a query.
+type step struct {
+	node Node
+	// how : "all", "index", "prefix", "spatial", "scan", "and", "or" or
+	// "not".
+	how string
+	// size : at most how many records the step matches.
+	size int
+	// docs : for index, prefix and spatial steps, the records matched.
+	docs []int
+	// distances : for spatial steps, the distance of each record matched
+	// from the query's point.
+	distances map[int]float64
+	// steps : for and, the driving step followed by those checked against
+	// its records; for or, the alternatives; for not, the step negated.
+	steps []*step
+}
+
+// Plan : return the plan for evaluating n against ix. A nil query matches
+// every record.
+func (ix *Index) Plan(n Node) *Plan {
+	return &Plan{ix: ix, root: ix.plan(n), query: n}
+}
+
+func (ix *Index) plan(n Node) *step {
+	s := &step{node: n, size: ix.Len()}
+	switch n := n.(type) {
+	case nil:
+		s.how = "all"
+	case Term:
+		switch n.Op {
+		case ":":
+			s.how = "index"
+			s.docs = ix.terms[n.Field][normalize(n.Value)]
+			s.size = len(s.docs)
+		case "*":
+			s.how = "prefix"
+			prefix := normalize(n.Value)
+			var sets [][]int
+			for value, docs := range ix.terms[n.Field] {
+				if strings.HasPrefix(value, prefix) {
+					sets = append(sets, docs)
+				}
+			}
+			s.docs = union(sets...)
+			s.size = len(s.docs)
+		default:
+			s.how = "scan"
+		}
+	case Within, InBox:
+		s.how = "spatial"
+		var hits []geo.Hit
+		if w, ok := n.(Within); ok {
+			hits = ix.geo.Near(w.Point, w.Radius)
+		} else {
+			b := n.(InBox).Box
+			hits = ix.geo.Within(b, b.Center())
+		}
+		s.distances = map[int]float64{}
+		for _, h := range hits {
+			d := h.Value.(int)
+			s.docs = append(s.docs, d)
+			s.distances[d] = h.Distance
+		}
+		sort.Ints(s.docs)
+		s.size = len(s.docs)
+	case And:
+		s.how = "and"
+		for _, child := range n.Nodes {
+			s.steps = append(s.steps, ix.plan(child))
+		}
+		// Drive from the smallest step that can list its records; negations
+		// and scans are cheaper to check than to list.
+		sort.SliceStable(s.steps, func(i, j int) bool {
+			return s.steps[i].rank() < s.steps[j].rank()
+		})
+		if first := s.steps[0]; first.how != "not" && first.how != "scan" {
+			s.size = first.size
+		}
+	case Or:
+		s.how = "or"
+		total := 0
+		for _, child := range n.Nodes {
+			c := ix.plan(child)
+			s.steps = append(s.steps, c)
+			total += c.size
+		}
+		if total < s.size {
+			s.size = total
+		}
+	case Not:
+		s.how = "not"
+		s.steps = []*step{ix.plan(n.Node)}
+	}
+	return s
+}
+
+// rank : the order of s among the steps of an AND, by how many records it
+// may match, with those that must be checked record by record last.
+func (s *step) rank() int {
+	switch s.how {
+	case "scan":
+		return 1 << 30
+	case "not":
+		return 1<<30 + 1
+	}
+	return s.size
+}
+
+// eval : return the records s matches, in ascending order.
+func (p *Plan) eval(s *step) []int {
+	switch s.how {
+	case "index", "prefix", "spatial":
+		return s.docs
+	case "and":
+		var docs []int
+		for _, d := range p.eval(s.steps[0]) {
+			if p.checkAll(s.steps[1:], d) {
+				docs = append(docs, d)
+			}
+		}
+		return docs
+	case "or":
+		sets := make([][]int, len(s.steps))
+		for i, c := range s.steps {
+			sets[i] = p.eval(c)
+		}
+		return union(sets...)
+	}
+	var docs []int
+	for d := range p.ix.docs {
+		if !p.ix.docs[d].removed && p.check(s, d) {
+			docs = append(docs, d)
+		}
+	}
+	return docs
+}
+
+func (p *Plan) checkAll(steps []*step, d int) bool {
+	for _, s := range steps {
+		if !p.check(s, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// check : whether s matches record d.
+func (p *Plan) check(s *step, d int) bool {
+	switch s.how {
+	case "index", "prefix", "spatial":
+		i := sort.SearchInts(s.docs, d)
+		return i < len(s.docs) && s.docs[i] == d
+	case "scan":
+		t := s.node.(Term)
+		for _, v := range p.ix.docs[d].values[t.Field] {
+			if compare(v, t.Op, t.Value) {
+				return true
+			}
+		}
+		return false
+	case "all":
+		return true
+	case "and":
+		return p.checkAll(s.steps, d)
+	case "or":
+		for _, c := range s.steps {
+			if p.check(c, d) {
+				return true
+			}
+		}
+		return false
+	case "not":
+		return !p.check(s.steps[0], d)
+	}
+	return false
+}
+
+// Run : return the records the query matches. If the query is, or is the
+// AND of, a within or bbox, they are ordered by their distance from its
+// point or the centre of its box, and for within the distance is given;
+// otherwise they are ordered by identifier.
+func (p *Plan) Run() []Result {
+	docs := p.eval(p.root)
+	results := make([]Result, len(docs))
+	origin := p.origin()
+	for i, d := range docs {
+		doc := p.ix.docs[d]
+		results[i] = Result{ID: doc.id, Kind: doc.kind, Record: doc.record}
+		if origin != nil {
+			dist := origin.distances[d]
+			results[i].Distance = &dist
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if a, b := results[i].Distance, results[j].Distance; a != nil && *a != *b {
+			return *a < *b
+		}
+		return results[i].ID < results[j].ID
+	})
+	if origin != nil && origin.how == "spatial" {
+		if _, ok := origin.node.(InBox); ok {
+			for i := range results {
+				results[i].Distance = nil
+			}
+		}
+	}
+	return results
+}
+
+// origin : the spatial step results are ordered by, preferring a within to
+// a bbox, or nil if there is none.
+func (p *Plan) origin() *step {
+	steps := []*step{p.root}
+	if p.root.how == "and" {
+		steps = p.root.steps
+	}
+	var box *step
+	for _, s := range steps {
+		if s.how != "spatial" {
+			continue
+		}
+		if _, ok := s.node.(Within); ok {
+			return s
+		}
+		if box == nil {
+			box = s
+		}
+	}
+	return box
+}
+
+// String : describe the plan, a step per line, indented under the AND, OR
+// or NOT it is part of, with the most records each step may match.
+func (p *Plan) String() string {
+	var b strings.Builder
+	if p.query != nil {
+		fmt.Fprintf(&b, "query: %s\n", p.query)
+	}
+	describe(&b, p.root, 0, "")
+	return b.String()
+}
+
+func describe(b *strings.Builder, s *step, depth int, role string) {
+	indent := strings.Repeat("  ", depth)
+	switch s.how {
+	case "all":
+		fmt.Fprintf(b, "%s%severy record: %s\n", indent, role, records(s.size))
+	case "index":
+		fmt.Fprintf(b, "%s%slook up %s in the inverted index: %s\n", indent, role, s.node, records(s.size))
+	case "prefix":
+		fmt.Fprintf(b, "%s%slook up %s by prefix in the inverted index: %s\n", indent, role, s.node, records(s.size))
+	case "spatial":
+		fmt.Fprintf(b, "%s%slook up %s in the spatial index: %s\n", indent, role, s.node, records(s.size))
+	case "scan":
+		fmt.Fprintf(b, "%s%scompare %s record by record\n", indent, role, s.node)
+	case "and":
+		fmt.Fprintf(b, "%s%sall of: at most %s\n", indent, role, records(s.size))
+		for i, c := range s.steps {
+			r := "check "
+			if i == 0 {
+				r = "from "
+			}
+			describe(b, c, depth+1, r)
+		}
+	case "or":
+		fmt.Fprintf(b, "%s%sany of: at most %s\n", indent, role, records(s.size))
+		for _, c := range s.steps {
+			describe(b, c, depth+1, "")
+		}
+	case "not":
+		fmt.Fprintf(b, "%s%snone of:\n", indent, role)
+		describe(b, s.steps[0], depth+1, "")
+	}
+}
+
+func records(n int) string {
+	if n == 1 {
+		return "1 record"
+	}
+	return fmt.Sprintf("%d records", n)
+}
+
+// Rank : return the results that are among hits, a full-text search's, with
+// their scores, best first, and then in the order they were in.
+func Rank(results []Result, hits []fulltext.Hit) []Result {
+	scores := map[string]float64{}
+	for _, h := range hits {
+		scores[h.ID] = h.Score
+	}
+	var ranked []Result
+	for _, r := range results {
+		if score, ok := scores[r.ID]; ok {
+			r.Score = &score
+			ranked = append(ranked, r)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return *ranked[i].Score > *ranked[j].Score })
+	return ranked
+}
+
+// union : return the records in any of sets, each in ascending order, in
+// ascending order.
+func union(sets ...[]int) []int {
+	seen := map[int]bool{}
+	var docs []int
+	for _, set := range sets {
+		for _, d := range set {
+			if !seen[d] {
+				seen[d] = true
+				docs = append(docs, d)
+			}
+		}
+	}
+	sort.Ints(docs)
+	return docs
+}
@@ -0,0 +1,257 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/helpfulengineering/open-knowledge-framework/fulltext"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
+)
+
+// facility : return an OKW record with the given fields; a record at 0,0
+// has no location.
+func facility(name, city string, status okw.FacilityStatus, lat, lon float64, size int, process string, certs ...okw.Certification) *okw.OKW {
+	r := &okw.OKW{Name: name, FacilityStatus: status, ManufacturingProcesses: process, Certifications: certs}
+	r.Location.Address.City = city
+	r.Location.GPS = okw.GPS{Latitude: lat, Longitude: lon}
+	if size > 0 {
+		r.SizeFloorSize = &size
+	}
+	return r
+}
+
+func testIndex(t *testing.T) *Index {
+	yes := true
+	kigali := facility("Kigali Makers", "Kigali", "Active", -1.9441, 30.0619, 1200,
+		"https://en.wikipedia.org/wiki/Laser_cutting", "ISO 13485")
+	kigali.BackupGenerator = &yes
+	ix := NewIndex()
+	for _, r := range []struct {
+		id     string
+		record *okw.OKW
+	}{
+		{"kigali", kigali},
+		{"lyon", facility("Atelier Lyon", "Lyon", "Active", 45.764, 4.8357, 600, "https://en.wikipedia.org/wiki/Milling_(machining)")},
+		{"musanze", facility("Musanze Lab", "Musanze", "Planned", -1.4998, 29.6344, 0, "3D printing", "ISO 9001")},
+		{"nowhere", facility("Nowhere", "", "Closed", 0, 0, 0, "")},
+	} {
+		if err := ix.Add(r.id, "okw", r.record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ix
+}
+
+func ids(results []Result) []string {
+	out := []string{}
+	for _, r := range results {
+		out = append(out, r.ID)
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	ix := testIndex(t)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"status:active", []string{"kigali", "lyon"}},
+		{"status:ACTIVE", []string{"kigali", "lyon"}},
+		{"kind:okw", []string{"kigali", "lyon", "musanze", "nowhere"}},
+		{"certification:ISO13485", []string{"kigali"}},
+		{`certification:"iso 9001"`, []string{"musanze"}},
+		{"process:laser_cutting", []string{"kigali"}},
+		{"process:mill*", []string{"lyon"}},
+		{"process:3d*", []string{"musanze"}},
+		{`name:"kigali makers"`, []string{"kigali"}},
+		{"name:kigali", []string{}},
+		{"backup_generator:true", []string{"kigali"}},
+		{"size_floor_size:>=900", []string{"kigali"}},
+		{"size_floor_size:<900", []string{"lyon"}},
+		{"size_floor_size:>600", []string{"kigali"}},
+		{"size_floor_size:<=600", []string{"lyon"}},
+		{"NOT status:active", []string{"musanze", "nowhere"}},
+		{"city:kigali OR city:lyon", []string{"kigali", "lyon"}},
+		{"status:active NOT city:lyon", []string{"kigali"}},
+		{"(city:kigali OR city:musanze) AND NOT status:planned", []string{"kigali"}},
+		{"within(-1.95,30.06,200km)", []string{"kigali", "musanze"}},
+		{"within(-1.5,29.64,10km)", []string{"musanze"}},
+		{"bbox(-2,29,-1,31)", []string{"musanze", "kigali"}},
+		{"bbox(40,170,50,-170)", []string{}},
+		{"status:active within(-1.95,30.06,200km)", []string{"kigali"}},
+		{"within(-1.95,30.06,200km) OR city:lyon", []string{"kigali", "lyon", "musanze"}},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.query, err)
+		}
+		if got := ids(ix.Search(n)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+	if got := ids(ix.Search(nil)); len(got) != 4 {
+		t.Errorf("Search(nil) = %v, want every record", got)
+	}
+}
+
+func TestSearchDistances(t *testing.T) {
+	ix := testIndex(t)
+	n, _ := Parse("within(-1.9441,30.0619,200km)")
+	results := ix.Search(n)
+	if len(results) != 2 || results[0].Distance == nil || *results[0].Distance != 0 {
+		t.Fatalf("within: got %+v, want kigali at 0 km first", results)
+	}
+	if d := results[1].Distance; d == nil || *d < 65 || *d > 72 {
+		t.Errorf("within: musanze at %+v, want about 68 km", results[1])
+	}
+	n, _ = Parse("bbox(-2,29,-1,31)")
+	for _, r := range ix.Search(n) {
+		if r.Distance != nil {
+			t.Errorf("bbox: %s has a distance, want none", r.ID)
+		}
+	}
+}
+
+func TestPlanString(t *testing.T) {
+	ix := testIndex(t)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"status:active AND size_floor_size:>=900 AND city:kigali", `query: status:active AND size_floor_size:>=900 AND city:kigali
+all of: at most 1 record
+  from look up city:kigali in the inverted index: 1 record
+  check look up status:active in the inverted index: 2 records
+  check compare size_floor_size:>=900 record by record
+`},
+		{"NOT status:active AND process:mill*", `query: NOT status:active AND process:mill*
+all of: at most 1 record
+  from look up process:mill* by prefix in the inverted index: 1 record
+  check none of:
+    look up status:active in the inverted index: 2 records
+`},
+		{"city:kigali OR within(45.76,4.83,5km)", `query: city:kigali OR within(45.76,4.83,5km)
+any of: at most 2 records
+  look up city:kigali in the inverted index: 1 record
+  look up within(45.76,4.83,5km) in the spatial index: 1 record
+`},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.query, err)
+		}
+		if got := ix.Plan(n).String(); got != tt.want {
+			t.Errorf("Plan(%q):\n%s\nwant:\n%s", tt.query, got, tt.want)
+		}
+	}
+	if got, want := ix.Plan(nil).String(), "every record: 4 records\n"; got != want {
+		t.Errorf("Plan(nil) = %q, want %q", got, want)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	ix := testIndex(t)
+	active, _ := Parse("status:active")
+	nearLyon, _ := Parse("within(45.76,4.83,5km)")
+	ix.Remove("lyon")
+	ix.Remove("missing")
+	if got := ids(ix.Search(active)); !reflect.DeepEqual(got, []string{"kigali"}) {
+		t.Errorf("after Remove, status:active = %v, want [kigali]", got)
+	}
+	if got := ids(ix.Search(nearLyon)); len(got) != 0 {
+		t.Errorf("after Remove, near Lyon = %v, want none", got)
+	}
+	if got := ids(ix.Search(nil)); !reflect.DeepEqual(got, []string{"kigali", "musanze", "nowhere"}) {
+		t.Errorf("after Remove, every record = %v", got)
+	}
+	if ix.Len() != 3 {
+		t.Errorf("after Remove, Len() = %d, want 3", ix.Len())
+	}
+
+	// Adding under an identifier already indexed replaces the record.
+	moved := facility("Atelier Lyon", "Lyon", "Active", 45.764, 4.8357, 600, "")
+	if err := ix.Add("kigali", "okw", moved); err != nil {
+		t.Fatal(err)
+	}
+	kigali, _ := Parse("city:kigali")
+	if got := ids(ix.Search(kigali)); len(got) != 0 {
+		t.Errorf("after replacing, city:kigali = %v, want none", got)
+	}
+	if got := ids(ix.Search(nearLyon)); !reflect.DeepEqual(got, []string{"kigali"}) {
+		t.Errorf("after replacing, near Lyon = %v, want [kigali]", got)
+	}
+	if ix.Len() != 3 {
+		t.Errorf("after replacing, Len() = %d, want 3", ix.Len())
+	}
+
+	// Removing most records rebuilds the index from the others.
+	ix.Remove("nowhere")
+	ix.Remove("kigali")
+	if got := ids(ix.Search(nil)); !reflect.DeepEqual(got, []string{"musanze"}) {
+		t.Errorf("after compacting, every record = %v, want [musanze]", got)
+	}
+	near, _ := Parse("within(-1.5,29.64,10km) AND status:planned")
+	if got := ids(ix.Search(near)); !reflect.DeepEqual(got, []string{"musanze"}) {
+		t.Errorf("after compacting, %s = %v, want [musanze]", near, got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{"ISO 13485", "iso13485"},
+		{"ISO-13485", "iso13485"},
+		{"https://en.wikipedia.org/wiki/Laser_cutting", "lasercutting"},
+		{"https://en.wikipedia.org/wiki/Laser_cutting/", "lasercutting"},
+		{"Zürich", "zürich"},
+		{"1.50", "1.5"},
+		{int64(3), "3"},
+		{3.0, "3"},
+		{true, "true"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.v); got != tt.want {
+			t.Errorf("normalize(%#v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		v     interface{}
+		op    string
+		value string
+		want  bool
+	}{
+		{int64(900), ">=", "900", true},
+		{int64(900), ">", "900", false},
+		{int64(10), "<", "9", false},
+		{"10", ">", "9", true},
+		{"2023-12-31", "<", "2024-01-01", true},
+		{"2024-03-01T09:30:00Z", ">=", "2024-03-01", true},
+		{"Beta", ">", "alpha", true},
+	}
+	for _, tt := range tests {
+		if got := compare(tt.v, tt.op, tt.value); got != tt.want {
+			t.Errorf("compare(%#v, %q, %q) = %v, want %v", tt.v, tt.op, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	results := []Result{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	hits := []fulltext.Hit{{ID: "c", Score: 2}, {ID: "a", Score: 1}, {ID: "d", Score: 2}, {ID: "x", Score: 9}}
+	ranked := Rank(results, hits)
+	if got := ids(ranked); !reflect.DeepEqual(got, []string{"c", "d", "a"}) {
+		t.Fatalf("Rank = %v, want [c d a]", got)
+	}
+	if *ranked[0].Score != 2 || *ranked[2].Score != 1 {
+		t.Errorf("Rank scores = %v, %v, want 2 and 1", *ranked[0].Score, *ranked[2].Score)
+	}
+}
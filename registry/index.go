@@ -0,0 +1,194 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+)
+
+// indexVersion : the layout version of the index file. An index of another
+// version is discarded and rebuilt.
+const indexVersion = 2
+
+// Entry : what the index records about one record file.
+type Entry struct {
+	// ID : the record's identifier, its path relative to the registry root
+	// without the extension, e.g. "okw/kigali-makerspace".
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// Path : the file's path relative to the registry root, e.g. "okw/kigali-makerspace.yaml".
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	// Name : the record's name, or title for kinds that have one instead.
+	Name     string    `json:"name,omitempty"`
+	Location *Location `json:"location,omitempty"`
+	// Processes : the manufacturing processes named anywhere in the record, sorted.
+	Processes []string `json:"processes,omitempty"`
+	// Status : the facility status, for kinds that have one.
+	Status string `json:"status,omitempty"`
+	// URI : the record's own stable identifier, its id key, if it has one.
+	URI string `json:"uri,omitempty"`
+	// Defines : the ids of the Agents, or other referable values, that the
+	// record defines for other records to refer to.
+	Defines []string `json:"defines,omitempty"`
+	// Error : why the file could not be decoded. Such files stay in the index
+	// so that they are not decoded again until they change.
+	Error string `json:"error,omitempty"`
+}
+
+// Location : where a record's facility is.
+type Location struct {
+	City      string  `json:"city,omitempty"`
+	Region    string  `json:"region,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+}
+
+type indexFile struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// readIndex : read the index at path. A missing or outdated index reads as
+// empty.
+func readIndex(path string) (map[string]Entry, error) {
+	entries := map[string]Entry{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f indexFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if f.Version != indexVersion {
+		return entries, nil
+	}
+	for _, e := range f.Entries {
+		entries[e.ID] = e
+	}
+	return entries, nil
+}
+
+// writeIndex : write entries to path, replacing the previous index in one
+// step so that readers never see a partial file.
+func writeIndex(path string, entries map[string]Entry) error {
+	f := indexFile{Version: indexVersion, Entries: sortedEntries(entries)}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func sortedEntries(entries map[string]Entry) []Entry {
+	list := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// summarize : fill in the indexed fields of e from record, whose keys are
+// read under their YAML names: name or title, facility_status, the city,
+// region, country and GPS coordinates of the top-level location, and every
+// manufacturing_process(es) value. The record's id and the ids it defines
+// are recorded too.
+func summarize(e *Entry, record interface{}) error {
+	tree, err := codec.ToTree(record, codec.YAML)
+	if err != nil {
+		return err
+	}
+	root, _ := tree.(codec.Map)
+	e.Name = text(root, "name")
+	if e.Name == "" {
+		e.Name = text(root, "title")
+	}
+	e.Status = text(root, "facility_status")
+	e.URI = text(root, "id")
+	e.Defines = definitions(record)
+	if location, ok := get(root, "location").(codec.Map); ok {
+		address, _ := get(location, "address").(codec.Map)
+		gps, _ := get(location, "gps").(codec.Map)
+		l := Location{
+			City:      text(address, "city"),
+			Region:    text(address, "region"),
+			Country:   text(address, "country"),
+			Latitude:  number(gps, "latitude"),
+			Longitude: number(gps, "longitude"),
+		}
+		if l != (Location{}) {
+			e.Location = &l
+		}
+	}
+	seen := map[string]bool{}
+	collectProcesses(tree, false, seen)
+	for p := range seen {
+		e.Processes = append(e.Processes, p)
+	}
+	sort.Strings(e.Processes)
+	return nil
+}
+
+// processKeys : the keys whose values name manufacturing processes.
+var processKeys = map[string]bool{
+	"manufacturing_process":   true,
+	"manufacturing_processes": true,
+	"manufacturing-processes": true,
+}
+
+func collectProcesses(tree interface{}, inProcess bool, seen map[string]bool) {
+	switch t := tree.(type) {
+	case codec.Map:
+		for _, item := range t {
+			collectProcesses(item.Value, processKeys[item.Key], seen)
+		}
+	case []interface{}:
+		for _, v := range t {
+			collectProcesses(v, inProcess, seen)
+		}
+	case string:
+		if inProcess && t != "" {
+			seen[t] = true
+		}
+	}
+}
+
+func get(m codec.Map, key string) interface{} {
+	v, _ := m.Get(key)
+	return v
+}
+
+func text(m codec.Map, key string) string {
+	s, _ := get(m, key).(string)
+	return s
+}
+
+func number(m codec.Map, key string) float64 {
+	switch v := get(m, key).(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
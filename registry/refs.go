@@ -0,0 +1,187 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// A referable type is a struct with string "id" and "ref" keys, such as
+// Agent. A value of such a type whose ref is set is a reference: it stands
+// for the value, defined in some record of the registry, whose id is that
+// ref. A value with an id and no ref is a definition.
+
+// referable : return the indices of the id and ref fields of struct type t,
+// if it is referable.
+func referable(t reflect.Type) (id, ref []int, ok bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+	for _, f := range spec.Fields(t, "yaml") {
+		if f.Type.Kind() != reflect.String {
+			continue
+		}
+		switch f.Key {
+		case "id":
+			id = f.Index
+		case "ref":
+			ref = f.Index
+		}
+	}
+	return id, ref, id != nil && ref != nil
+}
+
+// walkReferable : call fn with every referable value in v and its dotted
+// YAML path, outermost first.
+func walkReferable(v reflect.Value, path string, fn func(v reflect.Value, id, ref, path string)) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkReferable(v.Elem(), path, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkReferable(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case reflect.Struct:
+		if id, ref, ok := referable(v.Type()); ok {
+			fn(v, v.FieldByIndex(id).String(), v.FieldByIndex(ref).String(), path)
+		}
+		for _, f := range spec.Fields(v.Type(), "yaml") {
+			fieldPath := f.Key
+			if path != "" {
+				fieldPath = path + "." + f.Key
+			}
+			walkReferable(v.FieldByIndex(f.Index), fieldPath, fn)
+		}
+	}
+}
+
+// definitions : return the ids of the referable values record defines.
+func definitions(record interface{}) []string {
+	var ids []string
+	walkReferable(reflect.ValueOf(record), "", func(_ reflect.Value, id, ref, _ string) {
+		if id != "" && ref == "" {
+			ids = append(ids, id)
+		}
+	})
+	return ids
+}
+
+// RefError : a reference that could not be resolved.
+type RefError struct {
+	// Path : dotted YAML path of the reference in its record, e.g. "owner".
+	Path string `json:"path"`
+	Ref  string `json:"ref"`
+	// Reason : why it could not be resolved, e.g. no record defines it.
+	Reason string `json:"reason"`
+}
+
+func (e RefError) Error() string {
+	return e.Path + ": " + e.Reason
+}
+
+// RefErrors : the references in a record that could not be resolved.
+type RefErrors []RefError
+
+func (e RefErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "unresolved references: " + strings.Join(msgs, "; ")
+}
+
+// Resolve : replace every reference in record, a pointer to a typed record,
+// with a copy of the value it refers to, e.g. owner: {ref: agent:bristol-makers}
+// with the Agent whose id is agent:bristol-makers. Where several records
+// define the same id, the one with the first identifier is used. If some
+// references could not be resolved, the error is a RefErrors listing them;
+// the others are resolved regardless.
+func (r *Registry) Resolve(record interface{}) error {
+	v := reflect.ValueOf(record)
+	if v.Kind() != reflect.Ptr {
+		return fmt.Errorf("registry: Resolve needs a pointer, not a %T", record)
+	}
+	defined := r.definers()
+	records := map[string]interface{}{}
+	var unresolved RefErrors
+	walkReferable(v, "", func(v reflect.Value, _, ref, path string) {
+		if ref == "" {
+			return
+		}
+		if err := r.resolve(v, ref, defined, records); err != nil {
+			unresolved = append(unresolved, RefError{Path: path, Ref: ref, Reason: err.Error()})
+		}
+	})
+	if len(unresolved) > 0 {
+		return unresolved
+	}
+	return nil
+}
+
+// definers : return the identifier of the record that defines each
+// referable id, the first in order of identifier where several do. The map
+// is built once for each state of the index and must not be modified.
+func (r *Registry) definers() map[string]string {
+	r.mu.RLock()
+	defined := r.defined
+	r.mu.RUnlock()
+	if defined != nil {
+		return defined
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.defined == nil {
+		r.defined = map[string]string{}
+		for _, e := range sortedEntries(r.entries) {
+			for _, id := range e.Defines {
+				if _, ok := r.defined[id]; !ok {
+					r.defined[id] = e.ID
+				}
+			}
+		}
+	}
+	return r.defined
+}
+
+// resolve : set v to the value whose id is ref. records caches the records
+// already read, by identifier.
+func (r *Registry) resolve(v reflect.Value, ref string, defined map[string]string, records map[string]interface{}) error {
+	recordID, ok := defined[ref]
+	if !ok {
+		return fmt.Errorf("no record defines %q", ref)
+	}
+	record, ok := records[recordID]
+	if !ok {
+		var err error
+		if record, err = r.Get(recordID); err != nil {
+			return fmt.Errorf("%s: %v", recordID, err)
+		}
+		records[recordID] = record
+	}
+	var target reflect.Value
+	walkReferable(reflect.ValueOf(record), "", func(w reflect.Value, id, wref, _ string) {
+		if !target.IsValid() && id == ref && wref == "" {
+			target = w
+		}
+	})
+	if !target.IsValid() {
+		return fmt.Errorf("%s no longer defines %q", recordID, ref)
+	}
+	// The types may come from different specifications, e.g. okw.Agent and
+	// okt.Agent, so the value is copied through its YAML encoding.
+	data, err := codec.Marshal(target.Interface(), codec.YAML)
+	if err != nil {
+		return err
+	}
+	resolved := reflect.New(v.Type())
+	if err := codec.Unmarshal(data, codec.YAML, resolved.Interface()); err != nil {
+		return err
+	}
+	v.Set(resolved.Elem())
+	return nil
+}
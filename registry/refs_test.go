@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
+)
+
+// owned : return a facility whose owner is the Agent with the given id, and
+// which refers to it if ref is set or else defines it, named name.
+func owned(id, name string, ref bool) *okw.OKW {
+	r := &okw.OKW{Name: name}
+	if ref {
+		r.Owner.Ref = id
+	} else {
+		r.Owner.ID, r.Owner.Name = id, name
+	}
+	return r
+}
+
+// resolveOwner : resolve the owner of a facility referring to id, returning
+// its name or the error.
+func resolveOwner(r *Registry, id string) (string, error) {
+	record := owned(id, "", true)
+	if err := r.Resolve(record); err != nil {
+		return "", err
+	}
+	return record.Owner.Name, nil
+}
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, record := range map[string]*okw.OKW{
+		"okw/b": owned("agent:makers", "Makers B", false),
+		"okw/c": owned("agent:makers", "Makers C", false),
+		"okw/d": owned("agent:makers", "", true),
+	} {
+		if err := r.Put(id, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(when, want, wantErr string) {
+		t.Helper()
+		got, err := resolveOwner(r, "agent:makers")
+		switch {
+		case wantErr != "":
+			if _, ok := err.(RefErrors); !ok || !strings.Contains(err.Error(), wantErr) {
+				t.Errorf("%s: error %v, want RefErrors containing %q", when, err, wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", when, err)
+		case got != want:
+			t.Errorf("%s: owner %q, want %q", when, got, want)
+		}
+	}
+
+	// The record with the first identifier defines it; references do not.
+	check("at first", "Makers B", "")
+	check("again", "Makers B", "")
+	if err := r.Put("okw/a", owned("agent:makers", "Makers A", false)); err != nil {
+		t.Fatal(err)
+	}
+	check("after Put", "Makers A", "")
+	if err := r.Delete("okw/a"); err != nil {
+		t.Fatal(err)
+	}
+	check("after Delete", "Makers B", "")
+
+	// Files changed by other means are seen after Refresh.
+	path := filepath.Join(dir, "okw", "b.yaml")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	check("after removing a file", "Makers C", "")
+	if err := codec.WriteFile(filepath.Join(dir, "okw", "c.yaml"), owned("agent:other", "Other", false)); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "okw", "c.yaml"), later, later)
+	if _, err := r.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	check("after editing a file", "", `no record defines "agent:makers"`)
+	if name, err := resolveOwner(r, "agent:other"); err != nil || name != "Other" {
+		t.Errorf("after editing a file, agent:other = %q, %v, want Other", name, err)
+	}
+}
@@ -0,0 +1,293 @@
+// Package registry stores Open Knowledge Framework records as files in a
+// directory tree, addressed by stable identifiers, and keeps an on-disk
+// index of the fields most often searched on.
+//
+// A record's identifier is its file's path relative to the registry root,
+// without the extension and with forward slashes, e.g. "okw/kigali-makerspace"
+// for okw/kigali-makerspace.yaml. The kind of each file is inferred from its
+// path as document.Detect does, so records are usually kept in a directory
+// per kind. Files may also be added, changed or removed by other tools;
+// Refresh brings the index up to date with them.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+)
+
+// IndexPath : the index file, relative to the registry root. Directories
+// whose names start with a dot are not searched for records.
+const IndexPath = ".okf/index.json"
+
+// ErrNotFound : no record has the requested identifier.
+var ErrNotFound = errors.New("record not found")
+
+// Registry : a directory tree of record files and its index. It is safe for
+// concurrent use.
+type Registry struct {
+	root    string
+	mu      sync.RWMutex
+	entries map[string]Entry
+	// defined : the identifier of the record that defines each referable
+	// id, built from entries when Resolve first needs it, and dropped
+	// whenever they change.
+	defined map[string]string
+}
+
+// Open : return the registry rooted at the directory root, creating the
+// directory if needed, with its index brought up to date.
+func Open(root string) (*Registry, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	entries, err := readIndex(filepath.Join(root, IndexPath))
+	if err != nil {
+		return nil, err
+	}
+	r := &Registry{root: root, entries: entries}
+	if _, err := r.Refresh(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Root : return the directory the registry is rooted at.
+func (r *Registry) Root() string {
+	return r.root
+}
+
+// Changes : the identifiers Refresh found added, updated or removed.
+type Changes struct {
+	Added   []string `json:"added,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty : whether nothing changed.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Updated) == 0 && len(c.Removed) == 0
+}
+
+// Refresh : walk the registry root and update the index incrementally. Only
+// files whose size or modification time differ from the index are decoded
+// again. Where two files share an identifier (e.g. a.yaml and a.json) the
+// first in lexical order is used and the other is ignored.
+func (r *Registry) Refresh() (Changes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var changes Changes
+	found := map[string]bool{}
+	err := filepath.Walk(r.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != r.root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := codec.FormatFromPath(path); err != nil {
+			return nil
+		}
+		if _, err := document.Detect(path); err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(r.root, path)
+		if err != nil {
+			return err
+		}
+		id := idOf(rel)
+		if found[id] {
+			return nil
+		}
+		found[id] = true
+		old, ok := r.entries[id]
+		if ok && old.Path == filepath.ToSlash(rel) && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+			return nil
+		}
+		r.entries[id] = r.load(id, rel, info)
+		if ok {
+			changes.Updated = append(changes.Updated, id)
+		} else {
+			changes.Added = append(changes.Added, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return changes, err
+	}
+	for id := range r.entries {
+		if !found[id] {
+			delete(r.entries, id)
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+	sort.Strings(changes.Removed)
+	if changes.Empty() {
+		if _, err := os.Stat(filepath.Join(r.root, IndexPath)); err == nil {
+			return changes, nil
+		}
+	}
+	r.defined = nil
+	return changes, r.save()
+}
+
+// load : return the index entry of the file rel, decoding it.
+func (r *Registry) load(id, rel string, info os.FileInfo) Entry {
+	e := Entry{ID: id, Path: filepath.ToSlash(rel), ModTime: info.ModTime(), Size: info.Size()}
+	record, k, err := r.decode(rel)
+	e.Kind = k.Name
+	if err == nil {
+		err = summarize(&e, record)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// decode : decode the file rel into a record of the kind its path implies,
+// upgrading older versions of the specification.
+func (r *Registry) decode(rel string) (interface{}, document.Kind, error) {
+	path := filepath.Join(r.root, rel)
+	k, err := document.Detect(path)
+	if err != nil {
+		return nil, k, err
+	}
+	f, err := codec.FormatFromPath(path)
+	if err != nil {
+		return nil, k, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, k, err
+	}
+	record := k.New()
+	if _, _, err := document.Migrations().Decode(k.Name, data, f, record); err != nil {
+		return nil, k, fmt.Errorf("%s: %v", rel, err)
+	}
+	return record, k, nil
+}
+
+func (r *Registry) save() error {
+	return writeIndex(filepath.Join(r.root, IndexPath), r.entries)
+}
+
+// Get : return the record with the given identifier, as a pointer to its
+// typed record, e.g. *okw.OKW.
+func (r *Registry) Get(id string) (interface{}, error) {
+	r.mu.RLock()
+	e, ok := r.entries[id]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	record, _, err := r.decode(filepath.FromSlash(e.Path))
+	return record, err
+}
+
+// Lookup : return the index entry of the record with the given identifier.
+func (r *Registry) Lookup(id string) (Entry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[id]
+	return e, ok
+}
+
+// List : return the index entries of every record, sorted by identifier.
+func (r *Registry) List() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedEntries(r.entries)
+}
+
+// Put : store record under the given identifier, replacing the file of an
+// existing record in its format, or creating <id>.yaml. The kind implied by
+// the identifier's path must match the record's type.
+func (r *Registry) Put(id string, record interface{}) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rel := filepath.FromSlash(id) + codec.YAML.Ext()
+	if e, ok := r.entries[id]; ok {
+		rel = filepath.FromSlash(e.Path)
+	}
+	path := filepath.Join(r.root, rel)
+	k, err := document.Detect(path)
+	if err != nil {
+		return err
+	}
+	if want, got := reflect.TypeOf(k.New()).Elem(), indirect(reflect.TypeOf(record)); want != got {
+		return fmt.Errorf("%s: %s records must be %v, not %v", id, k.Name, want, got)
+	}
+	if err := codec.WriteFile(path, record); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	e := Entry{ID: id, Kind: k.Name, Path: filepath.ToSlash(rel), ModTime: info.ModTime(), Size: info.Size()}
+	if err := summarize(&e, record); err != nil {
+		e.Error = err.Error()
+	}
+	r.entries[id] = e
+	r.defined = nil
+	return r.save()
+}
+
+// Delete : remove the record with the given identifier.
+func (r *Registry) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := os.Remove(filepath.Join(r.root, filepath.FromSlash(e.Path))); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(r.entries, id)
+	r.defined = nil
+	return r.save()
+}
+
+// idOf : return the identifier of the file at rel, a path relative to the
+// registry root.
+func idOf(rel string) string {
+	return filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+}
+
+// checkID : return an error if id cannot name a file inside the registry.
+func checkID(id string) error {
+	clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(id)))
+	if id == "" || clean != id || strings.HasPrefix(id, "../") || id == ".." || filepath.IsAbs(filepath.FromSlash(id)) {
+		return fmt.Errorf("invalid record identifier %q", id)
+	}
+	for _, part := range strings.Split(id, "/") {
+		if strings.HasPrefix(part, ".") {
+			return fmt.Errorf("invalid record identifier %q", id)
+		}
+	}
+	return nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
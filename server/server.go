@@ -0,0 +1,623 @@
+// Package server serves Open Knowledge Framework records over HTTP, with an
+// endpoint per kind of record on top of a pluggable Store:
+//
+//	GET    /                  the collections and their sizes
+//	GET    /facilities        list OKW records, ?offset=0&limit=50
+//	GET    /facilities?near=lat,lon&radius=50km
+//	                          list the records within 50 km, nearest first
+//	GET    /facilities?bbox=south,west,north,east
+//	                          list the records inside a bounding box
+//	GET    /facilities?q=status:Active
+//	                          list the records that match a query
+//	GET    /facilities?text=laser+cutting
+//	                          list the records that contain words, best first
+//	POST   /facilities        add a record under a new identifier
+//	GET    /facilities/{id}   get a record
+//	GET    /facilities/{id}?resolve=true
+//	                          get a record with its references resolved
+//	PUT    /facilities/{id}   add or replace a record
+//	DELETE /facilities/{id}   remove a record
+//
+// and likewise /carriers for OKT and /designs for OKH records. Records are
+// read and written as JSON or YAML, chosen by the Content-Type and Accept
+// headers, and are validated before they are stored. Responses carry an
+// ETag, and PUT and DELETE honour If-Match and If-None-Match, so that clients
+// can update records without overwriting each other's changes.
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/fulltext"
+	"github.com/helpfulengineering/open-knowledge-framework/query"
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+	"github.com/helpfulengineering/open-knowledge-framework/validation"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	// DefaultLimit : the number of records listed when no limit is given.
+	DefaultLimit = 50
+	// MaxLimit : the largest number of records listed at once.
+	MaxLimit = 500
+	// MaxBodySize : the largest request body accepted, in bytes.
+	MaxBodySize = 10 << 20
+	// DefaultRadius : the radius of a search near a point when none is given.
+	DefaultRadius = query.DefaultRadius
+)
+
+// mediaTypes : the media types of each format, the first being the one
+// responses are labelled with.
+var mediaTypes = map[codec.Format][]string{
+	codec.JSON: {"application/json"},
+	codec.YAML: {"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+}
+
+// Server : an http.Handler serving the records of a Store.
+type Server struct {
+	// RequireMatch : refuse, with 428 Precondition Required, to replace or
+	// delete a record unless the request has an If-Match header.
+	RequireMatch bool
+
+	store    Store
+	validate *validator.Validate
+}
+
+// New : return a Server for the records of store.
+func New(store Store) *Server {
+	return &Server{store: store, validate: validation.New()}
+}
+
+// errorBody : the body of an error response.
+type errorBody struct {
+	Error string `json:"error" yaml:"error"`
+	// Validation : why the record in the request is invalid.
+	Validation *validation.Result `json:"validation,omitempty" yaml:"validation,omitempty"`
+}
+
+// listBody : the body of a listing. Next is the URL of the following page,
+// if there is one.
+type listBody struct {
+	Items  []listItem `json:"items" yaml:"items"`
+	Total  int        `json:"total" yaml:"total"`
+	Offset int        `json:"offset" yaml:"offset"`
+	Limit  int        `json:"limit" yaml:"limit"`
+	Next   string     `json:"next,omitempty" yaml:"next,omitempty"`
+}
+
+// listItem : a listed record and, in a search near a point, its distance
+// from the point in kilometres, and in a full-text search its score.
+type listItem struct {
+	Item     `yaml:",inline"`
+	Distance *float64 `json:"distance_km,omitempty" yaml:"distance_km,omitempty"`
+	Score    *float64 `json:"score,omitempty" yaml:"score,omitempty"`
+}
+
+// listQuery : what a listing is narrowed to: the records that match a query,
+// if it is not nil, and that contain the words of text, if it is not empty.
+type listQuery struct {
+	query query.Node
+	text  string
+}
+
+// collection : a collection as listed at the root.
+type collection struct {
+	Name  string `json:"name" yaml:"name"`
+	Kind  string `json:"kind" yaml:"kind"`
+	Title string `json:"title" yaml:"title"`
+	Total int    `json:"total" yaml:"total"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotAcceptable)
+		fmt.Fprintln(w, "not acceptable: records are served as application/json or application/yaml")
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] == "" {
+		s.serveRoot(w, r, format)
+		return
+	}
+	k, ok := kindOf(parts[0])
+	if !ok || len(parts) > 2 {
+		s.fail(w, format, http.StatusNotFound, "no such collection", nil)
+		return
+	}
+	if len(parts) == 1 {
+		s.serveCollection(w, r, format, k)
+		return
+	}
+	if err := checkID(parts[1]); err != nil {
+		s.fail(w, format, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+	s.serveRecord(w, r, format, k, parts[1])
+}
+
+func (s *Server) serveRoot(w http.ResponseWriter, r *http.Request, format codec.Format) {
+	if !allow(w, r, "GET", "HEAD") {
+		return
+	}
+	var collections []collection
+	for _, k := range document.Kinds() {
+		_, total, err := s.store.List(k.Name, 0, 0)
+		if err != nil {
+			s.fail(w, format, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		collections = append(collections, collection{Name: k.Collection, Kind: k.Name, Title: k.Title, Total: total})
+	}
+	s.write(w, format, http.StatusOK, map[string][]collection{"collections": collections})
+}
+
+func (s *Server) serveCollection(w http.ResponseWriter, r *http.Request, format codec.Format, k document.Kind) {
+	if !allow(w, r, "GET", "HEAD", "POST") {
+		return
+	}
+	if r.Method == "POST" {
+		record, ok := s.decode(w, r, format, k)
+		if !ok {
+			return
+		}
+		id := newID()
+		item, _, err := s.store.Put(k.Name, id, record, Condition{IfNoneMatch: "*"})
+		if err != nil {
+			s.storeFailed(w, format, err)
+			return
+		}
+		w.Header().Set("Location", "/"+k.Collection+"/"+id)
+		s.writeItem(w, format, http.StatusCreated, item)
+		return
+	}
+	offset, err := intParam(r, "offset", 0, 0)
+	if err == nil {
+		var limit int
+		if limit, err = intParam(r, "limit", DefaultLimit, 1); err == nil {
+			var q *listQuery
+			if q, err = queryParams(r); err == nil {
+				s.list(w, r, format, k, offset, limit, q)
+				return
+			}
+		}
+	}
+	s.fail(w, format, http.StatusBadRequest, err.Error(), nil)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, format codec.Format, k document.Kind, offset, limit int, q *listQuery) {
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	var (
+		items []listItem
+		total int
+		err   error
+	)
+	if q == nil {
+		var page []Item
+		page, total, err = s.store.List(k.Name, offset, limit)
+		for _, item := range page {
+			items = append(items, listItem{Item: item})
+		}
+	} else {
+		items, total, err = s.search(k, offset, limit, q)
+	}
+	if err != nil {
+		s.fail(w, format, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	body := listBody{Items: items, Total: total, Offset: offset, Limit: limit}
+	if body.Items == nil {
+		body.Items = []listItem{}
+	}
+	if offset+len(items) < total {
+		next := r.URL.Query()
+		next.Set("offset", strconv.Itoa(offset+len(items)))
+		next.Set("limit", strconv.Itoa(limit))
+		body.Next = "/" + k.Collection + "?" + next.Encode()
+		w.Header().Set("Link", "<"+body.Next+`>; rel="next"`)
+	}
+	s.write(w, format, http.StatusOK, body)
+}
+
+// search : return the page of records of kind that q matches, and the
+// number matched, from the store's own indexes if it is a Searcher and a
+// TextSearcher. For other stores, every record of kind is indexed afresh,
+// since they may change by other means.
+func (s *Server) search(k document.Kind, offset, limit int, q *listQuery) ([]listItem, int, error) {
+	ss, ok := s.store.(Searcher)
+	if !ok {
+		ss = &scanSearcher{store: s.store}
+	}
+	results, err := ss.Search(k.Name, q.query)
+	if err != nil {
+		return nil, 0, err
+	}
+	if q.text != "" {
+		ts, ok := s.store.(TextSearcher)
+		if !ok {
+			ts = &scanSearcher{store: s.store}
+		}
+		hits, err := ts.SearchText(k.Name, q.text)
+		if err != nil {
+			return nil, 0, err
+		}
+		results = query.Rank(results, hits)
+	}
+	total := len(results)
+	if offset > len(results) {
+		offset = len(results)
+	}
+	results = results[offset:]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	page := make([]listItem, len(results))
+	for i, r := range results {
+		etag, err := ETag(r.Record)
+		if err != nil {
+			return nil, 0, err
+		}
+		page[i] = listItem{Item: Item{ID: r.ID, ETag: etag, Record: r.Record}, Distance: r.Distance, Score: r.Score}
+	}
+	return page, total, nil
+}
+
+// scanSearcher : a Searcher and TextSearcher for a store that is neither,
+// indexing every record of the kind for each search.
+type scanSearcher struct {
+	store Store
+}
+
+// Search : see Searcher.
+func (ss *scanSearcher) Search(kind string, n query.Node) ([]query.Result, error) {
+	all, _, err := ss.store.List(kind, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	ix := query.NewIndex()
+	for _, item := range all {
+		if err := ix.Add(item.ID, kind, item.Record); err != nil {
+			return nil, err
+		}
+	}
+	return ix.Search(n), nil
+}
+
+// SearchText : see TextSearcher.
+func (ss *scanSearcher) SearchText(kind, text string) ([]fulltext.Hit, error) {
+	all, _, err := ss.store.List(kind, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	ix := fulltext.NewIndex(nil)
+	for _, item := range all {
+		if err := ix.Add(item.ID, kind, item.Record); err != nil {
+			return nil, err
+		}
+	}
+	return ix.Search(text), nil
+}
+
+func (s *Server) serveRecord(w http.ResponseWriter, r *http.Request, format codec.Format, k document.Kind, id string) {
+	if !allow(w, r, "GET", "HEAD", "PUT", "DELETE") {
+		return
+	}
+	cond := Condition{IfMatch: r.Header.Get("If-Match"), IfNoneMatch: r.Header.Get("If-None-Match")}
+	switch r.Method {
+	case "GET", "HEAD":
+		item, err := s.store.Get(k.Name, id)
+		if err != nil {
+			s.storeFailed(w, format, err)
+			return
+		}
+		if cond.IfNoneMatch != "" && matches(cond.IfNoneMatch, item.ETag) {
+			w.Header().Set("ETag", item.ETag)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if !s.resolve(w, r, format, &item) {
+			return
+		}
+		s.writeItem(w, format, http.StatusOK, item)
+	case "PUT":
+		if s.RequireMatch && cond.IfMatch == "" && cond.IfNoneMatch != "*" {
+			s.fail(w, format, http.StatusPreconditionRequired, "If-Match is required to replace a record; use If-None-Match: * to create one", nil)
+			return
+		}
+		record, ok := s.decode(w, r, format, k)
+		if !ok {
+			return
+		}
+		item, created, err := s.store.Put(k.Name, id, record, cond)
+		if err != nil {
+			s.storeFailed(w, format, err)
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+			w.Header().Set("Location", "/"+k.Collection+"/"+id)
+		}
+		s.writeItem(w, format, status, item)
+	case "DELETE":
+		if s.RequireMatch && cond.IfMatch == "" {
+			s.fail(w, format, http.StatusPreconditionRequired, "If-Match is required to delete a record", nil)
+			return
+		}
+		if err := s.store.Delete(k.Name, id, cond); err != nil {
+			s.storeFailed(w, format, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// resolve : resolve the references in item's record if r asks for it with
+// ?resolve=true. References that cannot be resolved are left as they are and
+// named in a Warning header. It writes the error response and returns false
+// if r cannot be served.
+func (s *Server) resolve(w http.ResponseWriter, r *http.Request, format codec.Format, item *Item) bool {
+	param := r.URL.Query().Get("resolve")
+	if param == "" {
+		return true
+	}
+	on, err := strconv.ParseBool(param)
+	if err != nil {
+		s.fail(w, format, http.StatusBadRequest, fmt.Sprintf("invalid resolve %q: want true or false", param), nil)
+		return false
+	}
+	if !on {
+		return true
+	}
+	resolver, ok := s.store.(Resolver)
+	if !ok {
+		s.fail(w, format, http.StatusNotImplemented, "this store cannot resolve references", nil)
+		return false
+	}
+	record, err := resolver.Resolve(item.Record)
+	if refs, ok := err.(registry.RefErrors); ok {
+		w.Header().Set("Warning", fmt.Sprintf("299 okf %q", refs.Error()))
+	} else if err != nil {
+		s.storeFailed(w, format, err)
+		return false
+	}
+	item.Record = record
+	return true
+}
+
+// decode : read the record in the body of r, upgrading older versions of
+// the specification, and validate it. It writes the error response and
+// returns false if the record cannot be stored.
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, format codec.Format, k document.Kind) (interface{}, bool) {
+	in, err := contentFormat(r.Header.Get("Content-Type"))
+	if err != nil {
+		s.fail(w, format, http.StatusUnsupportedMediaType, err.Error(), nil)
+		return nil, false
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		status := http.StatusBadRequest
+		if len(data) >= MaxBodySize {
+			status = http.StatusRequestEntityTooLarge
+		}
+		s.fail(w, format, status, err.Error(), nil)
+		return nil, false
+	}
+	record := k.New()
+	result := validation.ValidateData(s.validate, k, data, in, record)
+	if result.Status != validation.Valid {
+		result.Path = r.URL.Path
+		s.fail(w, format, http.StatusUnprocessableEntity, "invalid "+k.Name+" record", &result)
+		return nil, false
+	}
+	return record, true
+}
+
+func (s *Server) writeItem(w http.ResponseWriter, format codec.Format, status int, item Item) {
+	w.Header().Set("ETag", item.ETag)
+	s.write(w, format, status, item.Record)
+}
+
+// storeFailed : write the response for err, returned by the store.
+func (s *Server) storeFailed(w http.ResponseWriter, format codec.Format, err error) {
+	switch err {
+	case ErrNotFound:
+		s.fail(w, format, http.StatusNotFound, err.Error(), nil)
+	case ErrPreconditionFailed:
+		s.fail(w, format, http.StatusPreconditionFailed, "precondition failed: the stored record does not match If-Match, or matches If-None-Match", nil)
+	default:
+		s.fail(w, format, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
+
+func (s *Server) fail(w http.ResponseWriter, format codec.Format, status int, message string, result *validation.Result) {
+	s.write(w, format, status, errorBody{Error: message, Validation: result})
+}
+
+func (s *Server) write(w http.ResponseWriter, format codec.Format, status int, v interface{}) {
+	data, err := codec.Marshal(v, format)
+	if err != nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", mediaTypes[format][0])
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// allow : whether r's method is one of methods, writing a 405 response with
+// an Allow header if not.
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	return false
+}
+
+// kindOf : return the kind whose collection is named name.
+func kindOf(name string) (document.Kind, bool) {
+	for _, k := range document.Kinds() {
+		if k.Collection == name {
+			return k, true
+		}
+	}
+	return document.Kind{}, false
+}
+
+// checkID : return an error unless id can name a record: letters, digits,
+// ".", "_" and "-", not starting with a dot.
+func checkID(id string) error {
+	if id == "" || id[0] == '.' {
+		return fmt.Errorf("invalid record identifier %q", id)
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return fmt.Errorf("invalid record identifier %q", id)
+		}
+	}
+	return nil
+}
+
+// newID : return a random version 4 UUID, the identifier of a posted record.
+func newID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// intParam : return the query parameter name of r as an integer of at least
+// min, or def if it is missing.
+func intParam(r *http.Request, name string, def, min int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("%s must be an integer of at least %d", name, min)
+	}
+	return n, nil
+}
+
+// queryParams : return the search given by the q, near, radius, bbox and
+// text query parameters of r, or nil if it has none. radius defaults to
+// DefaultRadius.
+func queryParams(r *http.Request) (*listQuery, error) {
+	values := r.URL.Query()
+	text := strings.TrimSpace(values.Get("text"))
+	n, err := query.Build(values.Get("q"), values.Get("near"), values.Get("radius"), values.Get("bbox"))
+	if err != nil {
+		return nil, err
+	}
+	if n == nil && text == "" {
+		return nil, nil
+	}
+	return &listQuery{query: n, text: text}, nil
+}
+
+// contentFormat : return the format of a request body of media type
+// header. A body without a type is taken to be JSON.
+func contentFormat(header string) (codec.Format, error) {
+	if header == "" {
+		return codec.JSON, nil
+	}
+	t, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", fmt.Errorf("invalid Content-Type %q", header)
+	}
+	if f, ok := formatOf(t); ok {
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported Content-Type %q: send application/json or application/yaml", t)
+}
+
+func formatOf(mediaType string) (codec.Format, bool) {
+	if strings.HasSuffix(mediaType, "+json") {
+		return codec.JSON, true
+	}
+	for f, types := range mediaTypes {
+		for _, t := range types {
+			if mediaType == t {
+				return f, true
+			}
+		}
+	}
+	return "", false
+}
+
+// negotiate : return the format of the response to a request with the
+// Accept header accept: the acceptable format of highest quality, JSON when
+// both are equally acceptable, and false if neither is.
+func negotiate(accept string) (codec.Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return codec.JSON, true
+	}
+	// A media range that names a format takes precedence over a wildcard.
+	quality, wildcard := map[codec.Format]float64{}, map[codec.Format]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch t {
+		case "*/*", "application/*":
+			wildcard[codec.JSON] = maxFloat(wildcard[codec.JSON], q)
+			wildcard[codec.YAML] = maxFloat(wildcard[codec.YAML], q)
+		case "text/*":
+			wildcard[codec.YAML] = maxFloat(wildcard[codec.YAML], q)
+		default:
+			if f, ok := formatOf(t); ok {
+				if old, seen := quality[f]; !seen || q > old {
+					quality[f] = q
+				}
+			}
+		}
+	}
+	for f, q := range wildcard {
+		if _, ok := quality[f]; !ok {
+			quality[f] = q
+		}
+	}
+	switch {
+	case quality[codec.JSON] == 0 && quality[codec.YAML] == 0:
+		return "", false
+	case quality[codec.YAML] > quality[codec.JSON]:
+		return codec.YAML, true
+	}
+	return codec.JSON, true
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,459 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/fulltext"
+	"github.com/helpfulengineering/open-knowledge-framework/query"
+	"github.com/helpfulengineering/open-knowledge-framework/registry"
+)
+
+var (
+	// ErrNotFound : no record of the kind has the requested identifier.
+	ErrNotFound = errors.New("record not found")
+	// ErrPreconditionFailed : a Condition does not hold for the stored record.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Store : where the server keeps records. Records are pointers to typed
+// records, e.g. *okw.OKW, and are not modified once stored. Implementations
+// must be safe for concurrent use, and must check a Condition and apply the
+// change it guards in one step, so that concurrent writers cannot both
+// succeed.
+type Store interface {
+	// Get : return the record of kind with the given identifier.
+	Get(kind, id string) (Item, error)
+	// List : return at most limit records of kind, or all of them if limit
+	// is negative, sorted by identifier and skipping the first offset, and
+	// the number of records of kind.
+	List(kind string, offset, limit int) ([]Item, int, error)
+	// Put : store record under the given identifier if cond holds, and
+	// return it and whether it is new.
+	Put(kind, id string, record interface{}, cond Condition) (Item, bool, error)
+	// Delete : remove the record with the given identifier if cond holds.
+	Delete(kind, id string, cond Condition) error
+}
+
+// Searcher : a Store that keeps an index of its records for queries, which
+// the server then uses rather than indexing every record for each search.
+type Searcher interface {
+	// Search : return the records of kind that n matches, as
+	// query.Index.Search does, under their identifiers in the store.
+	Search(kind string, n query.Node) ([]query.Result, error)
+}
+
+// TextSearcher : a Store that keeps a full-text index of its records, which
+// the server then uses rather than indexing every record for each search.
+type TextSearcher interface {
+	// SearchText : return the records of kind that contain the words of
+	// text, best first, as fulltext.Index.Search does.
+	SearchText(kind, text string) ([]fulltext.Hit, error)
+}
+
+// Resolver : a Store that can resolve the references in its records, such
+// as owner: {ref: agent:bristol-makers}, to the values other records define,
+// which the server does on GET /{collection}/{id}?resolve=true.
+type Resolver interface {
+	// Resolve : return a copy of record, as returned by Get, with its
+	// references resolved. If some could not be, the copy is returned
+	// with the error, a registry.RefErrors, and those references left as
+	// they are.
+	Resolve(record interface{}) (interface{}, error)
+}
+
+// Item : a stored record.
+type Item struct {
+	ID string `json:"id" yaml:"id"`
+	// ETag : the record's entity tag, quoted as in HTTP headers.
+	ETag   string      `json:"etag" yaml:"etag"`
+	Record interface{} `json:"record" yaml:"record"`
+}
+
+// ETag : return the entity tag of record, a hash of its JSON encoding, so
+// that it changes exactly when the record does, whatever the store.
+func ETag(record interface{}) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// Condition : the If-Match and If-None-Match headers of a request. An empty
+// field imposes nothing.
+type Condition struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// Check : return ErrPreconditionFailed unless cond holds for current, the
+// stored record, or nil if there is none.
+func (cond Condition) Check(current *Item) error {
+	etag := ""
+	if current != nil {
+		etag = current.ETag
+	}
+	if cond.IfMatch != "" && (current == nil || !matches(cond.IfMatch, etag)) {
+		return ErrPreconditionFailed
+	}
+	if cond.IfNoneMatch != "" && current != nil && matches(cond.IfNoneMatch, etag) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// matches : whether header, "*" or a list of entity tags, matches etag.
+// Weak tags are compared by their opaque part.
+func matches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// page : return the part of items that List returns for offset and limit.
+func page(items []Item, offset, limit int) []Item {
+	if offset > len(items) {
+		offset = len(items)
+	}
+	if end := offset + limit; limit >= 0 && end < len(items) {
+		return items[offset:end]
+	}
+	return items[offset:]
+}
+
+// MemoryStore : a Store that keeps records in memory, e.g. for tests or
+// demonstrations, with a query and a full-text index of each kind, updated
+// as records are put and deleted. Its zero value is empty and ready to use.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	items map[string]map[string]Item
+	query map[string]*query.Index
+	text  map[string]*fulltext.Index
+}
+
+// NewMemoryStore : return an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Get : see Store.
+func (s *MemoryStore) Get(kind, id string) (Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.items[kind][id]
+	if !ok {
+		return Item{}, ErrNotFound
+	}
+	return item, nil
+}
+
+// List : see Store.
+func (s *MemoryStore) List(kind string, offset, limit int) ([]Item, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := make([]Item, 0, len(s.items[kind]))
+	for _, item := range s.items[kind] {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return page(items, offset, limit), len(items), nil
+}
+
+// Put : see Store.
+func (s *MemoryStore) Put(kind, id string, record interface{}, cond Condition) (Item, bool, error) {
+	etag, err := ETag(record)
+	if err != nil {
+		return Item{}, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.items[kind][id]
+	if err := cond.Check(itemOrNil(current, ok)); err != nil {
+		return Item{}, false, err
+	}
+	if s.items == nil {
+		s.items = map[string]map[string]Item{}
+		s.query = map[string]*query.Index{}
+		s.text = map[string]*fulltext.Index{}
+	}
+	if s.items[kind] == nil {
+		s.items[kind] = map[string]Item{}
+		s.query[kind] = query.NewIndex()
+		s.text[kind] = fulltext.NewIndex(nil)
+	}
+	if err := s.query[kind].Add(id, kind, record); err != nil {
+		return Item{}, false, err
+	}
+	// Both indexes read the record's YAML encoding, so if the first could
+	// the second can.
+	if err := s.text[kind].Add(id, kind, record); err != nil {
+		return Item{}, false, err
+	}
+	item := Item{ID: id, ETag: etag, Record: record}
+	s.items[kind][id] = item
+	return item, !ok, nil
+}
+
+// Delete : see Store.
+func (s *MemoryStore) Delete(kind, id string, cond Condition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.items[kind][id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := cond.Check(&current); err != nil {
+		return err
+	}
+	delete(s.items[kind], id)
+	s.query[kind].Remove(id)
+	s.text[kind].Remove(id)
+	return nil
+}
+
+// Search : see Searcher.
+func (s *MemoryStore) Search(kind string, n query.Node) ([]query.Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ix, ok := s.query[kind]
+	if !ok {
+		return nil, nil
+	}
+	return ix.Search(n), nil
+}
+
+// SearchText : see TextSearcher.
+func (s *MemoryStore) SearchText(kind, text string) ([]fulltext.Hit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ix, ok := s.text[kind]
+	if !ok {
+		return nil, nil
+	}
+	return ix.Search(text), nil
+}
+
+func itemOrNil(item Item, ok bool) *Item {
+	if !ok {
+		return nil
+	}
+	return &item
+}
+
+// DirStore : a Store backed by a registry.Registry, keeping each record of
+// kind k with identifier id in the file <root>/k/id.yaml, or in the file of
+// another format already there. Files changed by other tools are picked up
+// when records are listed.
+type DirStore struct {
+	// mu : serialises writes, so that a Condition is checked against the
+	// record it guards.
+	mu  sync.Mutex
+	reg *registry.Registry
+	// textMu : guards text, the full-text index, which is opened by the
+	// first search.
+	textMu sync.Mutex
+	text   *fulltext.Registry
+	// queryMu : guards query, the query index of each kind, and indexed,
+	// the registry entry of each record as it was when it was indexed.
+	queryMu sync.Mutex
+	query   map[string]*query.Index
+	indexed map[string]registry.Entry
+}
+
+// NewDirStore : return a DirStore rooted at the directory root, creating it
+// if needed.
+func NewDirStore(root string) (*DirStore, error) {
+	reg, err := registry.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	return &DirStore{reg: reg}, nil
+}
+
+// Get : see Store.
+func (s *DirStore) Get(kind, id string) (Item, error) {
+	record, err := s.reg.Get(kind + "/" + id)
+	if err == registry.ErrNotFound {
+		return Item{}, ErrNotFound
+	}
+	if err != nil {
+		return Item{}, err
+	}
+	etag, err := ETag(record)
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{ID: id, ETag: etag, Record: record}, nil
+}
+
+// List : see Store. Files that cannot be decoded are left out.
+func (s *DirStore) List(kind string, offset, limit int) ([]Item, int, error) {
+	if _, err := s.reg.Refresh(); err != nil {
+		return nil, 0, err
+	}
+	var ids []Item
+	for _, e := range s.reg.List() {
+		id := strings.TrimPrefix(e.ID, kind+"/")
+		if e.Kind == kind && e.Error == "" && id != e.ID && !strings.Contains(id, "/") {
+			ids = append(ids, Item{ID: id})
+		}
+	}
+	items := page(ids, offset, limit)
+	for i := range items {
+		item, err := s.Get(kind, items[i].ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		items[i] = item
+	}
+	return items, len(ids), nil
+}
+
+// Put : see Store.
+func (s *DirStore) Put(kind, id string, record interface{}, cond Condition) (Item, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, err := s.Get(kind, id)
+	exists := err == nil
+	if err != nil && err != ErrNotFound {
+		return Item{}, false, err
+	}
+	if err := cond.Check(itemOrNil(current, exists)); err != nil {
+		return Item{}, false, err
+	}
+	if err := s.reg.Put(kind+"/"+id, record); err != nil {
+		return Item{}, false, err
+	}
+	// Read the record back, since the entity tag must be that of the record
+	// as later requests decode it, e.g. with empty lists rather than none.
+	item, err := s.Get(kind, id)
+	return item, !exists, err
+}
+
+// Delete : see Store.
+func (s *DirStore) Delete(kind, id string, cond Condition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, err := s.Get(kind, id)
+	if err != nil {
+		return err
+	}
+	if err := cond.Check(&current); err != nil {
+		return err
+	}
+	return s.reg.Delete(kind + "/" + id)
+}
+
+// Resolve : see Resolver. References are resolved through the registry.
+func (s *DirStore) Resolve(record interface{}) (interface{}, error) {
+	data, err := codec.Marshal(record, codec.JSON)
+	if err != nil {
+		return nil, err
+	}
+	resolved := reflect.New(reflect.TypeOf(record).Elem()).Interface()
+	if err := codec.Unmarshal(data, codec.JSON, resolved); err != nil {
+		return nil, err
+	}
+	return resolved, s.reg.Resolve(resolved)
+}
+
+// Search : see Searcher. The query indexes are kept in memory and are
+// brought up to date before each search, reading only the records whose
+// files changed since they were indexed, as those Put and Delete write.
+func (s *DirStore) Search(kind string, n query.Node) ([]query.Result, error) {
+	s.queryMu.Lock()
+	defer s.queryMu.Unlock()
+	if err := s.syncQuery(); err != nil {
+		return nil, err
+	}
+	ix, ok := s.query[kind]
+	if !ok {
+		return nil, nil
+	}
+	return ix.Search(n), nil
+}
+
+// syncQuery : bring the query indexes up to date with the registry, after
+// refreshing it, as fulltext.Registry.Sync does. Records are indexed under
+// their identifiers in the store; files in deeper directories are left out,
+// as List leaves them out. The caller holds queryMu.
+func (s *DirStore) syncQuery() error {
+	if _, err := s.reg.Refresh(); err != nil {
+		return err
+	}
+	if s.query == nil {
+		s.query = map[string]*query.Index{}
+		s.indexed = map[string]registry.Entry{}
+	}
+	current := map[string]bool{}
+	for _, e := range s.reg.List() {
+		id := strings.TrimPrefix(e.ID, e.Kind+"/")
+		if e.Error != "" || id == e.ID || strings.Contains(id, "/") {
+			continue
+		}
+		current[e.ID] = true
+		if old, ok := s.indexed[e.ID]; ok && old.ModTime.Equal(e.ModTime) && old.Size == e.Size {
+			continue
+		}
+		record, err := s.reg.Get(e.ID)
+		if err != nil {
+			return err
+		}
+		ix, ok := s.query[e.Kind]
+		if !ok {
+			ix = query.NewIndex()
+			s.query[e.Kind] = ix
+		}
+		if err := ix.Add(id, e.Kind, record); err != nil {
+			return err
+		}
+		s.indexed[e.ID] = e
+	}
+	for regID, e := range s.indexed {
+		if !current[regID] {
+			s.query[e.Kind].Remove(strings.TrimPrefix(regID, e.Kind+"/"))
+			delete(s.indexed, regID)
+		}
+	}
+	return nil
+}
+
+// SearchText : see TextSearcher. The full-text index is kept in the
+// registry, next to its own index, and is brought up to date before each
+// search.
+func (s *DirStore) SearchText(kind, text string) ([]fulltext.Hit, error) {
+	s.textMu.Lock()
+	defer s.textMu.Unlock()
+	if s.text == nil {
+		ix, err := fulltext.Open(s.reg, nil)
+		if err != nil {
+			return nil, err
+		}
+		s.text = ix
+	}
+	if _, err := s.text.Sync(); err != nil {
+		return nil, err
+	}
+	var hits []fulltext.Hit
+	for _, h := range s.text.Search(text) {
+		id := strings.TrimPrefix(h.ID, kind+"/")
+		if h.Kind == kind && id != h.ID && !strings.Contains(id, "/") {
+			h.ID = id
+			hits = append(hits, h)
+		}
+	}
+	return hits, nil
+}
@@ -0,0 +1,179 @@
+// Package skeleton writes empty YAML records annotated with the
+// specification's documentation of each field, for contributors to fill in
+// like a form.
+package skeleton
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// width : the column at which comments are wrapped.
+const width = 80
+
+// Generate : return an empty YAML record of v's type. Every key is preceded by
+// comments giving its type, whether it is required, its allowed values and
+// the definition, format and note found in docs. Lists hold one empty element
+// to show their shape. Strings already set in v, such as its spec_version,
+// are written as they are. The result decodes into v's type.
+func Generate(v interface{}, title string, docs spec.Docs) []byte {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	t := rv.Type()
+	g := &generator{docs: docs, visiting: map[reflect.Type]bool{}}
+	if title != "" {
+		fmt.Fprintf(&g.buf, "# %s\n", title)
+		g.comment("", "Fill in the values and delete the keys that do not apply. Keys marked required must be given.")
+		g.buf.WriteString("---\n")
+	}
+	g.fields(t, rv, "", "")
+	return g.buf.Bytes()
+}
+
+type generator struct {
+	buf      bytes.Buffer
+	docs     spec.Docs
+	visiting map[reflect.Type]bool
+}
+
+// fields : write the fields of struct type t, found at path, indented by
+// indent. v is the struct's value, or the zero Value where there is none.
+func (g *generator) fields(t reflect.Type, v reflect.Value, path, indent string) {
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+	for i, f := range spec.Fields(t, "yaml") {
+		fieldPath := f.Key
+		if path != "" {
+			fieldPath = path + "." + f.Key
+		}
+		if i > 0 && indent == "" {
+			g.buf.WriteString("\n")
+		}
+		g.annotate(f, fieldPath, indent)
+		var fv reflect.Value
+		if v.IsValid() && len(f.Index) == 1 {
+			fv = v.Field(f.Index[0])
+		}
+		g.value(f.Key+":", f.Type, fv, fieldPath, indent)
+	}
+}
+
+// annotate : write the comments documenting field f.
+func (g *generator) annotate(f spec.Field, path, indent string) {
+	doc := g.docs[path]
+	summary := strings.TrimLeft(doc.Type, "*")
+	if summary == "" {
+		summary = strings.TrimLeft(f.Type.String(), "*")
+	}
+	if f.Required() {
+		summary += ", required"
+	}
+	g.comment(indent, summary)
+	t := f.Type
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if options, ok := spec.EnumOptions(t); ok && len(options) > 0 {
+		g.comment(indent, "One of: "+strings.Join(options, ", "))
+	}
+	if f.Type.Kind() == reflect.Ptr && scalar(t) {
+		g.comment(indent, "Leave empty if unknown.")
+	}
+	for _, part := range []struct{ label, text string }{
+		{"Definition", doc.Definition},
+		{"Format", doc.Format},
+		{"Note", doc.Note},
+	} {
+		if part.text != "" {
+			g.comment(indent, part.label+": "+part.text)
+		}
+	}
+}
+
+// value : write prefix (a "key:" or "-") followed by an empty value of type t,
+// or by v if it is a non-empty string.
+func (g *generator) value(prefix string, t reflect.Type, v reflect.Value, path, indent string) {
+	if t.Kind() == reflect.Ptr {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+			if v.IsValid() && !v.IsNil() {
+				v = v.Elem()
+			} else {
+				v = reflect.Value{}
+			}
+		}
+		if scalar(t) {
+			// An empty value is null: unknown rather than zero.
+			fmt.Fprintf(&g.buf, "%s%s\n", indent, prefix)
+			return
+		}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if g.visiting[t] || len(spec.Fields(t, "yaml")) == 0 {
+			fmt.Fprintf(&g.buf, "%s%s {}\n", indent, prefix)
+			return
+		}
+		fmt.Fprintf(&g.buf, "%s%s\n", indent, prefix)
+		g.fields(t, v, path, indent+"  ")
+	case reflect.Slice, reflect.Array:
+		elem := t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Interface {
+			fmt.Fprintf(&g.buf, "%s%s []\n", indent, prefix)
+			return
+		}
+		fmt.Fprintf(&g.buf, "%s%s\n", indent, prefix)
+		g.value("-", elem, reflect.Value{}, path, indent+"  ")
+	case reflect.Map:
+		fmt.Fprintf(&g.buf, "%s%s {}\n", indent, prefix)
+	case reflect.String:
+		s := ""
+		if v.IsValid() {
+			s = v.String()
+		}
+		fmt.Fprintf(&g.buf, "%s%s %s\n", indent, prefix, strconv.Quote(s))
+	case reflect.Bool:
+		fmt.Fprintf(&g.buf, "%s%s false\n", indent, prefix)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		fmt.Fprintf(&g.buf, "%s%s 0\n", indent, prefix)
+	default:
+		fmt.Fprintf(&g.buf, "%s%s null\n", indent, prefix)
+	}
+}
+
+// scalar : whether t is encoded as a single YAML value.
+func scalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map, reflect.Interface:
+		return false
+	}
+	return true
+}
+
+// comment : write text as comment lines indented by indent, wrapped at width.
+func (g *generator) comment(indent, text string) {
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(indent)+2+len(line)+1+len(word) > width {
+			fmt.Fprintf(&g.buf, "%s# %s\n", indent, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	fmt.Fprintf(&g.buf, "%s# %s\n", indent, line)
+}
@@ -0,0 +1,97 @@
+package spec
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FieldDoc : the specification's documentation of a field, as written in the
+// "Definition: ... | Format: ... | Note: ..." doc comments of the Go types.
+type FieldDoc struct {
+	// Type : the field's Go type as written in the source, e.g. "[]Agent".
+	Type       string
+	Definition string
+	Format     string
+	Note       string
+}
+
+// Docs : field documentation keyed by YAML path, e.g. "location.what_3_words".
+// List elements add no path segment, so "affiliations.name" documents the
+// name of every affiliation.
+type Docs map[string]FieldDoc
+
+var indexPattern = regexp.MustCompile(`\[\d*\]`)
+
+// Lookup : return the documentation of the field at path, which may contain
+// list indices such as "customer_reviews[0].rating".
+func (d Docs) Lookup(path string) (FieldDoc, bool) {
+	doc, ok := d[indexPattern.ReplaceAllString(path, "")]
+	return doc, ok
+}
+
+// Children : return the sorted paths directly below path, or the top-level
+// paths if path is empty. Like Lookup, path may contain list indices.
+func (d Docs) Children(path string) []string {
+	path = indexPattern.ReplaceAllString(path, "")
+	var children []string
+	for p := range d {
+		rest := p
+		if path != "" {
+			if !strings.HasPrefix(p, path+".") {
+				continue
+			}
+			rest = p[len(path)+1:]
+		}
+		if !strings.Contains(rest, ".") {
+			children = append(children, p)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Description : the documentation as a single paragraph per part, e.g. for
+// a JSON Schema description.
+func (f FieldDoc) Description() string {
+	parts := []string{}
+	if f.Definition != "" {
+		parts = append(parts, f.Definition)
+	}
+	if f.Format != "" {
+		parts = append(parts, "Format: "+f.Format)
+	}
+	if f.Note != "" {
+		parts = append(parts, "Note: "+f.Note)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+var docLabel = regexp.MustCompile(`(?:^|\s|\|)\s*(Definition|Format|Note)\s*:\s*`)
+
+// ParseDoc : split comment text of the form "Definition: ... | Format: ... |
+// Note: ..." into its parts. Text before the first label is taken as the
+// definition.
+func ParseDoc(text string) FieldDoc {
+	var doc FieldDoc
+	set := func(label, value string) {
+		value = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(value), "|"))
+		switch label {
+		case "Definition", "":
+			doc.Definition = value
+		case "Format":
+			doc.Format = value
+		case "Note":
+			doc.Note = value
+		}
+	}
+	label, start := "", 0
+	for _, m := range docLabel.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > start || label != "" {
+			set(label, text[start:m[0]])
+		}
+		label, start = text[m[2]:m[3]], m[1]
+	}
+	set(label, text[start:])
+	return doc
+}
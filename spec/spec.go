@@ -0,0 +1,194 @@
+// Package spec inspects the Go types that define the specifications, so that
+// generators and validators see the same field names and enum values as the
+// YAML and JSON encoders.
+package spec
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field : a serialised field of a struct type.
+type Field struct {
+	reflect.StructField
+	// Key : name of the field in the serialised record.
+	Key string
+	// OmitEmpty : whether the tag asks for zero values to be omitted.
+	OmitEmpty bool
+	// tag : the struct tag Key was read from.
+	tag string
+}
+
+// Fields : return the serialised fields of struct type t as named by tag
+// ("yaml" or "json"). Inline and embedded structs are flattened, and each
+// Field's Index is the full index path from t.
+func Fields(t reflect.Type, tag string) []Field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, opts := parseTag(sf.Tag.Get(tag))
+		if name == "-" {
+			continue
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && (opts["inline"] || (sf.Anonymous && name == "")) {
+			for _, inner := range Fields(ft, tag) {
+				inner.Index = append([]int{i}, inner.Index...)
+				fields = append(fields, inner)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		sf.Index = []int{i}
+		fields = append(fields, Field{StructField: sf, Key: Key(sf, tag), OmitEmpty: opts["omitempty"], tag: tag})
+	}
+	return fields
+}
+
+// Key : return the serialised name of sf under tag, or "-" if it is not
+// serialised. Untagged fields get the encoder's default name: the lower-cased
+// field name for YAML and the field name itself for JSON.
+func Key(sf reflect.StructField, tag string) string {
+	name, _ := parseTag(sf.Tag.Get(tag))
+	if name != "" {
+		return name
+	}
+	if tag == "yaml" {
+		return strings.ToLower(sf.Name)
+	}
+	return sf.Name
+}
+
+func parseTag(tag string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+	opts := map[string]bool{}
+	for _, o := range parts[1:] {
+		opts[o] = true
+	}
+	return parts[0], opts
+}
+
+// Required : whether the field's validate tag marks it as required.
+func (f Field) Required() bool {
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// Legacy : the keys the field was serialised under before its name was
+// corrected, as listed in its comma separated legacy tag. Decoders accept
+// them in place of Key. A key written as tag=key, e.g. json=logitude, was
+// only misspelt in that format and is a legacy key under that tag only.
+func (f Field) Legacy() []string {
+	tag := f.Tag.Get("legacy")
+	if tag == "" {
+		return nil
+	}
+	var keys []string
+	for _, key := range strings.Split(tag, ",") {
+		if i := strings.Index(key, "="); i >= 0 {
+			if key[:i] != f.tag {
+				continue
+			}
+			key = key[i+1:]
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+type enumOptioner interface {
+	EnumOptions() []string
+}
+
+// EnumOptions : return the allowed values of t if it declares an EnumOptions
+// method. The bool reports whether t is an enum type at all; the options may
+// be empty for enums whose values are not yet specified.
+func EnumOptions(t reflect.Type) ([]string, bool) {
+	if !t.Implements(reflect.TypeOf((*enumOptioner)(nil)).Elem()) {
+		return nil, false
+	}
+	return reflect.Zero(t).Interface().(enumOptioner).EnumOptions(), true
+}
+
+// NamedTypes : return the named types reachable from t through struct
+// fields, pointers, slices, arrays and maps, t itself included. Fields whose
+// tag key is "-" are not followed; predeclared types such as string are not
+// returned.
+func NamedTypes(t reflect.Type, tag string) []reflect.Type {
+	seen := map[reflect.Type]bool{}
+	var types []reflect.Type
+	var walk func(reflect.Type)
+	walk = func(t reflect.Type) {
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		if t.Name() != "" && t.PkgPath() != "" {
+			types = append(types, t)
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			walk(t.Elem())
+		case reflect.Map:
+			walk(t.Key())
+			walk(t.Elem())
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				sf := t.Field(i)
+				if sf.PkgPath != "" || Key(sf, tag) == "-" {
+					continue
+				}
+				walk(sf.Type)
+			}
+		}
+	}
+	walk(t)
+	return types
+}
+
+// TypeMap : return the named types reachable from root's type, excluding the
+// root itself, as zero values keyed by type name. Interface types are given
+// as typed nil pointers, since their zero value carries no type. This is the
+// type map the specification packages hand to DAML generators.
+func TypeMap(root interface{}) map[string]interface{} {
+	t := reflect.TypeOf(root)
+	m := map[string]interface{}{}
+	for _, nt := range NamedTypes(t, "daml") {
+		switch {
+		case nt == t:
+		case nt.Kind() == reflect.Interface:
+			m[nt.Name()] = reflect.Zero(reflect.PtrTo(nt)).Interface()
+		default:
+			m[nt.Name()] = reflect.Zero(nt).Interface()
+		}
+	}
+	return m
+}
+
+// TypeOf : return the type of a TypeMap value, undoing the pointer wrapping
+// of interface types.
+func TypeOf(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+		return t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,273 @@
+// Package staleness finds records that have not been verified recently and
+// groups them by the Agent to contact about them, so that they can be
+// checked again, e.g. by volunteers calling each contact in turn.
+//
+// A record is verified as of the verified timestamp of its metadata block.
+// Records that have never been verified are always stale.
+package staleness
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+)
+
+// Contact : how to reach the Agent, or OKH Person, who is a record's contact.
+type Contact struct {
+	ID            string `json:"id,omitempty"`
+	Ref           string `json:"ref,omitempty"`
+	Name          string `json:"name,omitempty"`
+	ContactPerson string `json:"contact_person,omitempty"`
+	Affiliation   string `json:"affiliation,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Landline      string `json:"landline,omitempty"`
+	Mobile        string `json:"mobile,omitempty"`
+	WhatsApp      string `json:"whatsapp,omitempty"`
+	Website       string `json:"website,omitempty"`
+}
+
+// Key : the key records are grouped by: the Agent's id, or the id it refers
+// to, or else its name and email address, so that a contact defined once and
+// referred to elsewhere forms a single group.
+func (c Contact) Key() string {
+	switch {
+	case c.ID != "":
+		return c.ID
+	case c.Ref != "":
+		return c.Ref
+	case c.Name == "" && c.Email == "":
+		return ""
+	}
+	return strings.ToLower(c.Name) + " <" + strings.ToLower(c.Email) + ">"
+}
+
+// Details : return the contact's non-empty details as "label: value" pairs,
+// in a fixed order.
+func (c Contact) Details() []string {
+	var details []string
+	for _, d := range []struct{ label, value string }{
+		{"contact person", c.ContactPerson},
+		{"affiliation", c.Affiliation},
+		{"email", c.Email},
+		{"landline", c.Landline},
+		{"mobile", c.Mobile},
+		{"whatsapp", c.WhatsApp},
+		{"website", c.Website},
+		{"ref", c.Ref},
+	} {
+		if d.value != "" {
+			details = append(details, d.label+": "+d.value)
+		}
+	}
+	return details
+}
+
+func (c Contact) String() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.Ref != "":
+		return c.Ref
+	}
+	return "(no contact)"
+}
+
+// Record : what the report needs to know about one record.
+type Record struct {
+	// Path : where the record was read from.
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	// Name : the record's name, or title for kinds that have one instead.
+	Name string `json:"name,omitempty"`
+	// Status : the facility status, for kinds that have one.
+	Status string `json:"status,omitempty"`
+	// Verified : the record's metadata.verified value, as written.
+	Verified string  `json:"verified,omitempty"`
+	Contact  Contact `json:"-"`
+	// Age : whole days since the record was verified, when it has been.
+	Age int `json:"age_days,omitempty"`
+	// Problem : why the record counts as stale regardless of its age, e.g.
+	// "never verified".
+	Problem string `json:"problem,omitempty"`
+}
+
+// Inspect : return the Record of record, a typed record of the given kind
+// read from path. Its keys are read under their YAML names.
+func Inspect(path, kind string, record interface{}) (Record, error) {
+	tree, err := codec.ToTree(record, codec.YAML)
+	if err != nil {
+		return Record{}, err
+	}
+	root, _ := tree.(codec.Map)
+	r := Record{Path: path, Kind: kind, Name: text(root, "name"), Status: text(root, "facility_status")}
+	if r.Name == "" {
+		r.Name = text(root, "title")
+	}
+	metadata, _ := get(root, "metadata").(codec.Map)
+	r.Verified = text(metadata, "verified")
+	contact, _ := get(root, "contact").(codec.Map)
+	details, _ := get(contact, "contact").(codec.Map)
+	r.Contact = Contact{
+		ID:            text(contact, "id"),
+		Ref:           text(contact, "ref"),
+		Name:          text(contact, "name"),
+		ContactPerson: text(contact, "contact_person"),
+		Affiliation:   text(contact, "affiliation"),
+		Email:         first(text(details, "email"), text(contact, "email")),
+		Landline:      text(details, "landline"),
+		Mobile:        text(details, "mobile"),
+		WhatsApp:      text(details, "whatsapp"),
+		Website:       text(contact, "website"),
+	}
+	return r, nil
+}
+
+// Group : the stale records that share a contact.
+type Group struct {
+	Contact Contact  `json:"contact"`
+	Records []Record `json:"records"`
+}
+
+// Report : the stale records among those checked, grouped by contact.
+type Report struct {
+	// Now : the time ages were measured from.
+	Now time.Time `json:"now"`
+	// Threshold : records verified longer ago than this many days are stale.
+	Threshold int `json:"threshold_days"`
+	// Checked : the number of records checked.
+	Checked int     `json:"checked"`
+	Stale   int     `json:"stale"`
+	Groups  []Group `json:"groups,omitempty"`
+}
+
+// Check : return the records not verified within threshold of now, grouped
+// by contact. Groups are sorted by contact name, with the group of records
+// without a contact last, and the records of a group oldest first. A group's
+// contact details are taken from the first of all the records, in path
+// order, that defines the contact rather than refers to it, stale or not.
+func Check(records []Record, now time.Time, threshold time.Duration) Report {
+	report := Report{Now: now, Threshold: int(threshold / day), Checked: len(records)}
+	sorted := append([]Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	defined := map[string]Contact{}
+	for _, r := range sorted {
+		if c := r.Contact; c.ID != "" && c.Ref == "" {
+			if _, ok := defined[c.ID]; !ok {
+				defined[c.ID] = c
+			}
+		}
+	}
+	index := map[string]int{}
+	for _, r := range sorted {
+		if !stale(&r, now, threshold) {
+			continue
+		}
+		report.Stale++
+		key := r.Contact.Key()
+		i, ok := index[key]
+		if !ok {
+			i = len(report.Groups)
+			index[key] = i
+			contact := r.Contact
+			if c, ok := defined[key]; ok {
+				contact = c
+			}
+			report.Groups = append(report.Groups, Group{Contact: contact})
+		}
+		g := &report.Groups[i]
+		g.Records = append(g.Records, r)
+	}
+	for _, g := range report.Groups {
+		sort.SliceStable(g.Records, func(i, j int) bool { return older(g.Records[i], g.Records[j]) })
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i].Contact, report.Groups[j].Contact
+		if (a.Key() == "") != (b.Key() == "") {
+			return b.Key() == ""
+		}
+		return strings.ToLower(a.String()) < strings.ToLower(b.String())
+	})
+	return report
+}
+
+const day = 24 * time.Hour
+
+// stale : whether r was not verified within threshold of now, filling in
+// its Age or Problem.
+func stale(r *Record, now time.Time, threshold time.Duration) bool {
+	if r.Verified == "" {
+		r.Problem = "never verified"
+		return true
+	}
+	verified, err := ParseTime(r.Verified)
+	if err != nil {
+		r.Problem = "verified date not understood"
+		return true
+	}
+	age := now.Sub(verified)
+	if age < 0 {
+		age = 0
+	}
+	r.Age = int(age / day)
+	return age > threshold
+}
+
+// older : whether a should be verified before b: records with a problem
+// come first, then the longest unverified.
+func older(a, b Record) bool {
+	if (a.Problem != "") != (b.Problem != "") {
+		return a.Problem != ""
+	}
+	return a.Age > b.Age
+}
+
+// ParseTime : parse an RFC 3339 date and time, or an ISO 8601 date taken as
+// midnight UTC, as metadata timestamps are written.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// ParseAge : parse a threshold such as "90d", "6w" or "72h". Days and weeks
+// are 24 and 168 hours; other units are those of time.ParseDuration.
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": day, "w": 7 * day} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func get(m codec.Map, key string) interface{} {
+	v, _ := m.Get(key)
+	return v
+}
+
+func text(m codec.Map, key string) string {
+	s, _ := get(m, key).(string)
+	return s
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -1,11 +0,0 @@
-package main
-
-import (
-	"github.com/helpfulengineering/open-knowledge-framework/templates/okt"
-	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
-)
-
-func main() {
-	okw.Sample("samples/okw")
-	okt.Sample("samples/okt")
-}
@@ -0,0 +1,309 @@
+// Code generated by okfdoc -type OKH; DO NOT EDIT.
+
+package okh
+
+import "github.com/helpfulengineering/open-knowledge-framework/spec"
+
+// FieldDocs : documentation of the fields of OKH, keyed by YAML path.
+var FieldDocs = spec.Docs{
+	"bom": {
+		Type:       "string",
+		Definition: "The bill of materials.",
+		Format:     "URL or path relative to the manifest.",
+	},
+	"contact": {
+		Type:       "Person",
+		Definition: "The Person to contact about the project.",
+		Format:     "Uses the Person class.",
+	},
+	"contact.affiliation": {
+		Type:       "string",
+		Definition: "Organisation the person belongs to.",
+		Format:     "Free text.",
+	},
+	"contact.email": {
+		Type:       "string",
+		Definition: "Email address of the person or organisation.",
+		Format:     "Email address.",
+	},
+	"contact.name": {
+		Type:       "string",
+		Definition: "Name of the person or organisation.",
+		Format:     "Free text.",
+	},
+	"contributors": {
+		Type:       "[]Person",
+		Definition: "The People who contributed to the project.",
+		Format:     "Uses the Person class.",
+	},
+	"contributors.affiliation": {
+		Type:       "string",
+		Definition: "Organisation the person belongs to.",
+		Format:     "Free text.",
+	},
+	"contributors.email": {
+		Type:       "string",
+		Definition: "Email address of the person or organisation.",
+		Format:     "Email address.",
+	},
+	"contributors.name": {
+		Type:       "string",
+		Definition: "Name of the person or organisation.",
+		Format:     "Free text.",
+	},
+	"date-created": {
+		Type:       "string",
+		Definition: "Date the project was started.",
+		Format:     "Recommended practice is to use ISO 8601, i.e. the format YYYY-MM-DD.",
+	},
+	"date-updated": {
+		Type:       "string",
+		Definition: "Date the manifest was last updated.",
+		Format:     "Recommended practice is to use ISO 8601, i.e. the format YYYY-MM-DD.",
+	},
+	"description": {
+		Type:       "string",
+		Definition: "Short description of the project.",
+		Format:     "Free text.",
+	},
+	"development-stage": {
+		Type:       "string",
+		Definition: "How far the hardware has been developed.",
+		Format:     "Free text, e.g. prototype or production.",
+	},
+	"documentation-language": {
+		Type:       "string",
+		Definition: "Language of the documentation.",
+		Format:     "BCP 47 language tag, for example \"en-GB\".",
+	},
+	"health-safety-notice": {
+		Type:       "string",
+		Definition: "Health and safety information for people making or using the hardware.",
+		Format:     "Free text or URL.",
+	},
+	"id": {
+		Type:       "string",
+		Definition: "A stable identifier of the record, which other records can refer to.",
+		Format:     "A URI, e.g. \"urn:uuid:6f1c4b0e-3a2d-4e5f-9a7b-8c9d0e1f2a3b\", or a UUID.",
+	},
+	"image": {
+		Type:       "string",
+		Definition: "A picture of the hardware.",
+		Format:     "URL or path relative to the manifest.",
+	},
+	"intended-use": {
+		Type:       "string",
+		Definition: "What the hardware is intended to be used for.",
+		Format:     "Free text.",
+	},
+	"keywords": {
+		Type:       "[]string",
+		Definition: "Words that describe the project.",
+		Format:     "List of words or short phrases.",
+	},
+	"license": {
+		Type:       "License",
+		Definition: "The licenses the hardware, documentation and software are published under.",
+		Format:     "Uses the License class.",
+	},
+	"license.documentation": {
+		Type:       "string",
+		Definition: "License of the documentation.",
+		Format:     "SPDX license identifier.",
+	},
+	"license.hardware": {
+		Type:       "string",
+		Definition: "License of the hardware design.",
+		Format:     "SPDX license identifier.",
+	},
+	"license.software": {
+		Type:       "string",
+		Definition: "License of the software, if any.",
+		Format:     "SPDX license identifier.",
+	},
+	"licensor": {
+		Type:       "Person",
+		Definition: "The Person who licenses the hardware and its documentation.",
+		Format:     "Uses the Person class.",
+	},
+	"licensor.affiliation": {
+		Type:       "string",
+		Definition: "Organisation the person belongs to.",
+		Format:     "Free text.",
+	},
+	"licensor.email": {
+		Type:       "string",
+		Definition: "Email address of the person or organisation.",
+		Format:     "Email address.",
+	},
+	"licensor.name": {
+		Type:       "string",
+		Definition: "Name of the person or organisation.",
+		Format:     "Free text.",
+	},
+	"made": {
+		Type:       "*bool",
+		Definition: "Whether the hardware has been made by the project.",
+		Format:     "TRUE / FALSE",
+	},
+	"made-independently": {
+		Type:       "*bool",
+		Definition: "Whether the hardware has been made by someone outside the project, from its documentation alone.",
+		Format:     "TRUE / FALSE",
+	},
+	"manifest-author": {
+		Type:       "Person",
+		Definition: "The Person who wrote the manifest.",
+		Format:     "Uses the Person class.",
+	},
+	"manifest-author.affiliation": {
+		Type:       "string",
+		Definition: "Organisation the person belongs to.",
+		Format:     "Free text.",
+	},
+	"manifest-author.email": {
+		Type:       "string",
+		Definition: "Email address of the person or organisation.",
+		Format:     "Email address.",
+	},
+	"manifest-author.name": {
+		Type:       "string",
+		Definition: "Name of the person or organisation.",
+		Format:     "Free text.",
+	},
+	"manifest-language": {
+		Type:       "string",
+		Definition: "Language of the manifest.",
+		Format:     "BCP 47 language tag, for example \"en-GB\".",
+	},
+	"manufacturing-instructions": {
+		Type:       "string",
+		Definition: "Instructions for making the hardware.",
+		Format:     "URL or path relative to the manifest.",
+	},
+	"manufacturing-processes": {
+		Type:       "[]string",
+		Definition: "Manufacturing processes needed to make the hardware.",
+		Format:     "Provide the Wikipedia URL for each manufacturing process.",
+	},
+	"metadata": {
+		Type:       "*Metadata",
+		Definition: "Provenance of the record: who published it, when, under what license and from where.",
+		Format:     "Uses the Metadata class.",
+	},
+	"metadata.changelog": {
+		Type:       "[]string",
+		Definition: "Notes on the changes made to the record.",
+		Format:     "List of free text entries, newest last.",
+	},
+	"metadata.created": {
+		Type:       "string",
+		Definition: "When the record was first published.",
+		Format:     "RFC 3339 date and time, e.g. \"2024-03-01T09:30:00Z\", or an ISO 8601 date, i.e. YYYY-MM-DD.",
+	},
+	"metadata.creator": {
+		Type:       "*Person",
+		Definition: "The Person who published the record.",
+		Format:     "Uses the Person class.",
+	},
+	"metadata.creator.affiliation": {
+		Type:       "string",
+		Definition: "Organisation the person belongs to.",
+		Format:     "Free text.",
+	},
+	"metadata.creator.email": {
+		Type:       "string",
+		Definition: "Email address of the person or organisation.",
+		Format:     "Email address.",
+	},
+	"metadata.creator.name": {
+		Type:       "string",
+		Definition: "Name of the person or organisation.",
+		Format:     "Free text.",
+	},
+	"metadata.data-license": {
+		Type:       "string",
+		Definition: "The license the record itself is published under.",
+		Format:     "SPDX license identifier or expression, e.g. \"CC-BY-4.0\".",
+	},
+	"metadata.modified": {
+		Type:       "string",
+		Definition: "When the record was last changed.",
+		Format:     "RFC 3339 date and time, or an ISO 8601 date.",
+	},
+	"metadata.source": {
+		Type:       "string",
+		Definition: "Where the record was obtained from, e.g. the directory it was imported from.",
+		Format:     "URL.",
+	},
+	"metadata.verified": {
+		Type:       "string",
+		Definition: "When the record was last checked against the project itself, e.g. by calling its contact.",
+		Format:     "RFC 3339 date and time, or an ISO 8601 date.",
+	},
+	"outer-dimensions": {
+		Type:       "Dimensions",
+		Definition: "The outer dimensions of the assembled hardware.",
+		Format:     "Uses the Dimensions class.",
+	},
+	"outer-dimensions.depth": {
+		Type: "float64",
+	},
+	"outer-dimensions.height": {
+		Type: "float64",
+	},
+	"outer-dimensions.width": {
+		Type: "float64",
+	},
+	"project-link": {
+		Type:       "string",
+		Definition: "Where the project is hosted.",
+		Format:     "URL.",
+	},
+	"spec_version": {
+		Type:       "string",
+		Definition: "The version of the specification the record follows.",
+		Format:     "Text, e.g. \"1\".",
+		Note:       "Records without it follow version 1.",
+	},
+	"standards-used": {
+		Type:       "[]Standard",
+		Definition: "Standards the hardware complies with.",
+		Format:     "Uses the Standard class.",
+	},
+	"standards-used.certificate-link": {
+		Type:       "string",
+		Definition: "Certificate of compliance, if any.",
+		Format:     "URL.",
+	},
+	"standards-used.publisher": {
+		Type:       "string",
+		Definition: "Organisation that publishes the standard.",
+		Format:     "Free text.",
+	},
+	"standards-used.reference": {
+		Type:       "string",
+		Definition: "Reference of the standard.",
+		Format:     "Free text, for example \"ISO 13485:2016\".",
+	},
+	"standards-used.standard-title": {
+		Type:       "string",
+		Definition: "Title of the standard.",
+		Format:     "Free text.",
+	},
+	"title": {
+		Type:       "string",
+		Definition: "The working title of the project.",
+		Format:     "Free text.",
+	},
+	"user-manual": {
+		Type:       "string",
+		Definition: "Instructions for using the hardware.",
+		Format:     "URL or path relative to the manifest.",
+	},
+	"version": {
+		Type:       "string",
+		Definition: "Version of the hardware described.",
+		Format:     "Recommended practice is to use semantic versioning, i.e. MAJOR.MINOR.PATCH.",
+	},
+}
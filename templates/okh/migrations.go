@@ -0,0 +1,9 @@
+package okh
+
+import "github.com/helpfulengineering/open-knowledge-framework/migration"
+
+// SpecVersion : the version of the specification that OKH implements.
+const SpecVersion = "1"
+
+// Migrations : the steps that upgrade OKH documents to SpecVersion, in order.
+var Migrations = []migration.Step{}
@@ -0,0 +1,156 @@
+package okh
+
+//go:generate go run ../../cmd/okfdoc -type OKH
+
+import (
+	"path/filepath"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/example"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+)
+
+// OKH : Definition: An Open Know How manifest, describing a piece of open source hardware and where its documentation is found.
+type OKH struct {
+	// SpecVersion : Definition: The version of the specification the record follows. | Format: Text, e.g. "1". | Note: Records without it follow version 1.
+	SpecVersion string `yaml:"spec_version" daml:"spec_version"  json:"spec_version"`
+	// ID : Definition: A stable identifier of the record, which other records can refer to. | Format: A URI, e.g. "urn:uuid:6f1c4b0e-3a2d-4e5f-9a7b-8c9d0e1f2a3b", or a UUID.
+	ID string `yaml:"id" daml:"id"  json:"id" validate:"omitempty,uri|uuid"`
+	// Title : Definition: The working title of the project. | Format: Free text.
+	Title string `yaml:"title" daml:"title"  json:"title" validate:"required"`
+	// Description : Definition: Short description of the project. | Format: Free text.
+	Description string `yaml:"description" daml:"description"  json:"description"`
+	// IntendedUse : Definition: What the hardware is intended to be used for. | Format: Free text.
+	IntendedUse string `yaml:"intended-use" daml:"intended_use"  json:"intended-use"`
+	// Keywords : Definition: Words that describe the project. | Format: List of words or short phrases.
+	Keywords []string `yaml:"keywords" daml:"keywords"  json:"keywords"`
+	// ProjectLink : Definition: Where the project is hosted. | Format: URL.
+	ProjectLink string `yaml:"project-link" daml:"project_link"  json:"project-link"`
+	// Image : Definition: A picture of the hardware. | Format: URL or path relative to the manifest.
+	Image string `yaml:"image" daml:"image"  json:"image"`
+	// Version : Definition: Version of the hardware described. | Format: Recommended practice is to use semantic versioning, i.e. MAJOR.MINOR.PATCH.
+	Version string `yaml:"version" daml:"version"  json:"version"`
+	// DevelopmentStage : Definition: How far the hardware has been developed. | Format: Free text, e.g. prototype or production.
+	DevelopmentStage string `yaml:"development-stage" daml:"development_stage"  json:"development-stage"`
+	// Made : Definition: Whether the hardware has been made by the project. | Format: TRUE / FALSE
+	Made *bool `yaml:"made" daml:"made"  json:"made"`
+	// MadeIndependently : Definition: Whether the hardware has been made by someone outside the project, from its documentation alone. | Format: TRUE / FALSE
+	MadeIndependently *bool `yaml:"made-independently" daml:"made_independently"  json:"made-independently"`
+	// DateCreated : Definition: Date the project was started. | Format: Recommended practice is to use ISO 8601, i.e. the format YYYY-MM-DD.
+	DateCreated string `yaml:"date-created" daml:"date_created"  json:"date-created"`
+	// DateUpdated : Definition: Date the manifest was last updated. | Format: Recommended practice is to use ISO 8601, i.e. the format YYYY-MM-DD.
+	DateUpdated string `yaml:"date-updated" daml:"date_updated"  json:"date-updated"`
+	// ManifestAuthor : Definition: The Person who wrote the manifest. | Format: Uses the Person class.
+	ManifestAuthor Person `yaml:"manifest-author" daml:"manifest_author"  json:"manifest-author"`
+	// ManifestLanguage : Definition: Language of the manifest. | Format: BCP 47 language tag, for example "en-GB".
+	ManifestLanguage string `yaml:"manifest-language" daml:"manifest_language"  json:"manifest-language"`
+	// DocumentationLanguage : Definition: Language of the documentation. | Format: BCP 47 language tag, for example "en-GB".
+	DocumentationLanguage string `yaml:"documentation-language" daml:"documentation_language"  json:"documentation-language"`
+	// Contact : Definition: The Person to contact about the project. | Format: Uses the Person class.
+	Contact Person `yaml:"contact" daml:"contact"  json:"contact"`
+	// Contributors : Definition: The People who contributed to the project. | Format: Uses the Person class.
+	Contributors []Person `yaml:"contributors" daml:"contributors"  json:"contributors" validate:"dive"`
+	// Licensor : Definition: The Person who licenses the hardware and its documentation. | Format: Uses the Person class.
+	Licensor Person `yaml:"licensor" daml:"licensor"  json:"licensor"`
+	// License : Definition: The licenses the hardware, documentation and software are published under. | Format: Uses the License class.
+	License License `yaml:"license" daml:"license"  json:"license" validate:"required"`
+	// HealthSafetyNotice : Definition: Health and safety information for people making or using the hardware. | Format: Free text or URL.
+	HealthSafetyNotice string `yaml:"health-safety-notice" daml:"health_safety_notice"  json:"health-safety-notice"`
+	// BOM : Definition: The bill of materials. | Format: URL or path relative to the manifest.
+	BOM string `yaml:"bom" daml:"bom"  json:"bom"`
+	// ManufacturingInstructions : Definition: Instructions for making the hardware. | Format: URL or path relative to the manifest.
+	ManufacturingInstructions string `yaml:"manufacturing-instructions" daml:"manufacturing_instructions"  json:"manufacturing-instructions"`
+	// UserManual : Definition: Instructions for using the hardware. | Format: URL or path relative to the manifest.
+	UserManual string `yaml:"user-manual" daml:"user_manual"  json:"user-manual"`
+	// ManufacturingProcesses : Definition: Manufacturing processes needed to make the hardware. | Format: Provide the Wikipedia URL for each manufacturing process.
+	ManufacturingProcesses []string `yaml:"manufacturing-processes" daml:"manufacturing_processes"  json:"manufacturing-processes"`
+	// OuterDimensions : Definition: The outer dimensions of the assembled hardware. | Format: Uses the Dimensions class.
+	OuterDimensions Dimensions `yaml:"outer-dimensions" daml:"outer_dimensions"  json:"outer-dimensions"`
+	// StandardsUsed : Definition: Standards the hardware complies with. | Format: Uses the Standard class.
+	StandardsUsed []Standard `yaml:"standards-used" daml:"standards_used"  json:"standards-used"`
+	// Metadata : Definition: Provenance of the record: who published it, when, under what license and from where. | Format: Uses the Metadata class.
+	Metadata *Metadata `yaml:"metadata" daml:"metadata"  json:"metadata"`
+}
+
+// Metadata : Definition: Where the record comes from and how current it is. | Note: Carried unchanged through conversions, so that provenance is never lost.
+type Metadata struct {
+	// Creator : Definition: The Person who published the record. | Format: Uses the Person class.
+	Creator *Person `yaml:"creator,omitempty" daml:"creator"  json:"creator,omitempty"`
+	// Created : Definition: When the record was first published. | Format: RFC 3339 date and time, e.g. "2024-03-01T09:30:00Z", or an ISO 8601 date, i.e. YYYY-MM-DD.
+	Created string `yaml:"created" daml:"created"  json:"created" validate:"omitempty,timestamp"`
+	// Modified : Definition: When the record was last changed. | Format: RFC 3339 date and time, or an ISO 8601 date.
+	Modified string `yaml:"modified" daml:"modified"  json:"modified" validate:"omitempty,timestamp"`
+	// Verified : Definition: When the record was last checked against the project itself, e.g. by calling its contact. | Format: RFC 3339 date and time, or an ISO 8601 date.
+	Verified string `yaml:"verified" daml:"verified"  json:"verified" validate:"omitempty,timestamp"`
+	// Source : Definition: Where the record was obtained from, e.g. the directory it was imported from. | Format: URL.
+	Source string `yaml:"source" daml:"source"  json:"source" validate:"omitempty,url"`
+	// DataLicense : Definition: The license the record itself is published under. | Format: SPDX license identifier or expression, e.g. "CC-BY-4.0".
+	DataLicense string `yaml:"data-license" daml:"data_license"  json:"data-license" validate:"omitempty,spdx"`
+	// Changelog : Definition: Notes on the changes made to the record. | Format: List of free text entries, newest last.
+	Changelog []string `yaml:"changelog" daml:"changelog"  json:"changelog"`
+}
+
+// Person : Definition: A person or organisation involved in the project.
+type Person struct {
+	// Name : Definition: Name of the person or organisation. | Format: Free text.
+	Name string `yaml:"name" daml:"name"  json:"name" validate:"required"`
+	// Affiliation : Definition: Organisation the person belongs to. | Format: Free text.
+	Affiliation string `yaml:"affiliation" daml:"affiliation"  json:"affiliation"`
+	// Email : Definition: Email address of the person or organisation. | Format: Email address.
+	Email string `yaml:"email" daml:"email"  json:"email"`
+}
+
+// License : Definition: The licenses of the project. | Format: Use SPDX license identifiers, for example "CERN-OHL-S-2.0".
+type License struct {
+	// Hardware : Definition: License of the hardware design. | Format: SPDX license identifier.
+	Hardware string `yaml:"hardware" daml:"hardware"  json:"hardware" validate:"required"`
+	// Documentation : Definition: License of the documentation. | Format: SPDX license identifier.
+	Documentation string `yaml:"documentation" daml:"documentation"  json:"documentation"`
+	// Software : Definition: License of the software, if any. | Format: SPDX license identifier.
+	Software string `yaml:"software" daml:"software"  json:"software"`
+}
+
+// Dimensions : Definition: Size of the hardware. | Format: Decimal numbers. Unit: mm.
+type Dimensions struct {
+	Width  float64 `yaml:"width" daml:"width"  json:"width"`
+	Depth  float64 `yaml:"depth" daml:"depth"  json:"depth"`
+	Height float64 `yaml:"height" daml:"height"  json:"height"`
+}
+
+// Standard : Definition: A standard the hardware complies with.
+type Standard struct {
+	// StandardTitle : Definition: Title of the standard. | Format: Free text.
+	StandardTitle string `yaml:"standard-title" daml:"standard_title"  json:"standard-title"`
+	// Publisher : Definition: Organisation that publishes the standard. | Format: Free text.
+	Publisher string `yaml:"publisher" daml:"publisher"  json:"publisher"`
+	// Reference : Definition: Reference of the standard. | Format: Free text, for example "ISO 13485:2016".
+	Reference string `yaml:"reference" daml:"reference"  json:"reference"`
+	// CertificateLink : Definition: Certificate of compliance, if any. | Format: URL.
+	CertificateLink string `yaml:"certificate-link" daml:"certificate_link"  json:"certificate-link"`
+}
+
+// TypeMap : return the named types reachable from OKH, keyed by type name.
+// It is derived from the struct definitions so that it cannot drift from them.
+func TypeMap() map[string]interface{} {
+	return spec.TypeMap(OKH{})
+}
+
+// NewSample : return the sample OKH record.
+func NewSample() OKH {
+	var okh OKH
+	example.New(1).Fill(&okh)
+	okh.SpecVersion = SpecVersion
+	return okh
+}
+
+// Sample : write the sample OKH record to outputDir as okh.yaml and okh.json.
+// DAML is generated as a complete project by the damlgen package instead.
+func Sample(outputDir string) error {
+	okh := NewSample()
+	for _, f := range codec.Formats() {
+		if err := codec.WriteFile(filepath.Join(outputDir, "okh"+f.Ext()), &okh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package okt
+
+import "github.com/helpfulengineering/open-knowledge-framework/migration"
+
+// SpecVersion : the version of the specification that OKT implements.
+const SpecVersion = "2"
+
+// Migrations : the steps that upgrade OKT documents to SpecVersion, in order.
+var Migrations = []migration.Step{
+	{
+		From:    "1",
+		To:      "2",
+		Summary: "Correct the misspelt keys indentifier, logitude (JSON) and landline (Facebook)",
+		Apply:   migration.CorrectLegacyKeys(OKT{}),
+		Keys:    migration.LegacyKeys(OKT{}),
+	},
+}
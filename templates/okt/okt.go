@@ -1,31 +1,91 @@
 package okt
 
+//go:generate go run ../../cmd/okfdoc -type OKT
+
 import (
-	"encoding/json"
-	"io/ioutil"
-	"log"
 	"path/filepath"
-	"strings"
 
 	"net/url"
 
-	daml "github.com/psprings/go-daml"
-	"gopkg.in/go-playground/validator.v9"
-	"gopkg.in/yaml.v2"
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/example"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
 )
 
-const ()
+const (
+	// Active :
+	Active FacilityStatus = "Active"
+	// Planned :
+	Planned FacilityStatus = "Planned"
+	// TemporaryClosure :
+	TemporaryClosure FacilityStatus = "Temporary Closure"
+	// Closed :
+	Closed FacilityStatus = "Closed"
+	// Restricted : only certain people (e.g. staff members) can use the equipment
+	Restricted AccessType = "Restricted"
+	// RestrictedWithPublicHours : the equipment can be used by the public during limited hours
+	RestrictedWithPublicHours AccessType = "Restricted with public hours"
+	// SharedSpace : the facility is a shared workspace where access is by qualifying criteria (e.g. rental of a desk or workspace)
+	SharedSpace AccessType = "Shared space"
+	// Public : anyone may use the equipment (e.g. training may be required and other restructions may apply)
+	Public AccessType = "Public"
+	// Membership : access requires membership, which is available to the public or a certain demographic
+	Membership AccessType = "Membership"
+)
 
 func (fs FacilityStatus) IsEnum() bool {
 	return true
 }
 
+func (fs FacilityStatus) Enum() []FacilityStatus {
+	return []FacilityStatus{
+		Active,
+		Planned,
+		TemporaryClosure,
+		Closed,
+	}
+}
+
+func (fs FacilityStatus) EnumOptions() []string {
+	return []string{
+		string(Active),
+		string(Planned),
+		string(TemporaryClosure),
+		string(Closed),
+	}
+}
+
 func (at AccessType) IsEnum() bool {
 	return true
 }
 
+func (at AccessType) Enum() []AccessType {
+	return []AccessType{
+		Restricted,
+		RestrictedWithPublicHours,
+		SharedSpace,
+		Public,
+		Membership,
+	}
+}
+
+func (at AccessType) EnumOptions() []string {
+	return []string{
+		string(Restricted),
+		string(RestrictedWithPublicHours),
+		string(SharedSpace),
+		string(Public),
+		string(Membership),
+	}
+}
+
 // OKT :
 type OKT struct {
+	// SpecVersion : Definition: The version of the specification the record follows. | Format: Text, e.g. "2". | Note: Records without it follow version 1.
+	SpecVersion string `yaml:"spec_version" daml:"spec_version"  json:"spec_version"`
+	// ID : Definition: A stable identifier of the record, which other records can refer to. | Format: A URI, e.g. "urn:uuid:6f1c4b0e-3a2d-4e5f-9a7b-8c9d0e1f2a3b", or a UUID.
+	ID string `yaml:"id" daml:"id"  json:"id" validate:"omitempty,uri|uuid"`
+	// Name : Definition: Name of the carrier. | Format: Provide the name of the carrier.
 	Name string `yaml:"name" daml:"name"  json:"name" validate:"required"`
 	// Description : Definition: Description of the facility. | Format: Free text.
 	Description string   `yaml:"description" daml:"description"  json:"description"`
@@ -35,16 +95,16 @@ type OKT struct {
 	// Contact : Definition: An Agent who is the contact for enquiries about making. | Format: Uses the Agent class.
 	Contact Agent `yaml:"contact" daml:"contact"  json:"contact" validate:"required"`
 	// Affiliations : Definition: The Agent(s) who the manufacturing facility is affiliated with. | Format: Uses the Agent class.
-	Affiliations []Agent `yaml:"affiliations" daml:"affiliations"  json:"affiliations"`
+	Affiliations []Agent `yaml:"affiliations" daml:"affiliations"  json:"affiliations" validate:"dive"`
 	// FacilityStatus : Definition: Status of the facility. | Format: Use of one the following:
-	FacilityStatus FacilityStatus `yaml:"facility_status" daml:"facility_status"  json:"facility_status"`
+	FacilityStatus FacilityStatus `yaml:"facility_status" daml:"facility_status"  json:"facility_status" validate:"omitempty,enum"`
 	// OpeningHours : Definition: Hours in which the facility operates. | Format: Free text.
 	OpeningHours string `yaml:"opening_hours" daml:"opening_hours"  json:"opening_hours"`
 	// Vehicles : the vehicles which are offered by the represented carrier. | Format : []Vehicle
 	Vehicles []Vehicle `yaml:"vehicles" daml:"vehicles"  json:"vehicles"`
 	// Services : the transportation services or offerings provided by the represented carrier. | Format : []Service
 	Services []Service `yaml:"services" daml:"services"  json:"services"`
-	// AreaOfService : a list of regions defined by geographical bounding where vehicles and services are available | Format : []GeoShape
+	// AreasOfService : a list of regions defined by geographical bounding where vehicles and services are available | Format : []GeoShape
 	AreasOfService []GeoShape `yaml:"areasOfService" daml:"areasOfService"  json:"areasOfService"`
 	// Permits : a list of permits or endorsements held which allow this carrier to operate vehicles or services in a given locality or country | Format : []Permit
 	Permits []Permit `yaml:"permits" daml:"permits"  json:"permits"`
@@ -58,7 +118,27 @@ type OKT struct {
 	// Certifications : Definition: Certifications obtained by the facility. | Format: List the certifications. | Note: Knowledge of these is imperative informal manufacturing and procurement. For example, aid agencies would be able to see which manufacturing facilities have particular manufacturing licenses, such as medical manufacturing.
 	Certifications []Certification `yaml:"certifications" daml:"certifications"  json:"certifications"`
 	// CustomerReviews : Definition: Customer reviews of the facility. | Format: Free text.
-	CustomerReviews []CustomerReview `yaml:"customer_reviews" daml:"customer_reviews"  json:"customer_reviews"`
+	CustomerReviews []CustomerReview `yaml:"customer_reviews" daml:"customer_reviews"  json:"customer_reviews" validate:"dive"`
+	// Metadata : Definition: Provenance of the record: who published it, when, under what license and from where. | Format: Uses the Metadata class.
+	Metadata *Metadata `yaml:"metadata" daml:"metadata"  json:"metadata"`
+}
+
+// Metadata : Definition: Where the record comes from and how current it is. | Note: Carried unchanged through conversions, so that provenance is never lost.
+type Metadata struct {
+	// Creator : Definition: The Agent who published the record. | Format: Uses the Agent class.
+	Creator *Agent `yaml:"creator,omitempty" daml:"creator"  json:"creator,omitempty"`
+	// Created : Definition: When the record was first published. | Format: RFC 3339 date and time, e.g. "2024-03-01T09:30:00Z", or an ISO 8601 date, i.e. YYYY-MM-DD.
+	Created string `yaml:"created" daml:"created"  json:"created" validate:"omitempty,timestamp"`
+	// Modified : Definition: When the record was last changed. | Format: RFC 3339 date and time, or an ISO 8601 date.
+	Modified string `yaml:"modified" daml:"modified"  json:"modified" validate:"omitempty,timestamp"`
+	// Verified : Definition: When the record was last checked against the carrier itself, e.g. by calling its contact. | Format: RFC 3339 date and time, or an ISO 8601 date.
+	Verified string `yaml:"verified" daml:"verified"  json:"verified" validate:"omitempty,timestamp"`
+	// Source : Definition: Where the record was obtained from, e.g. the directory it was imported from. | Format: URL.
+	Source string `yaml:"source" daml:"source"  json:"source" validate:"omitempty,url"`
+	// DataLicense : Definition: The license the record itself is published under. | Format: SPDX license identifier or expression, e.g. "CC-BY-4.0".
+	DataLicense string `yaml:"data_license" daml:"data_license"  json:"data_license" validate:"omitempty,spdx"`
+	// Changelog : Definition: Notes on the changes made to the record. | Format: List of free text entries, newest last.
+	Changelog []string `yaml:"changelog" daml:"changelog"  json:"changelog"`
 }
 
 // FacilityStatus : Definition: Status of the facility. | Format: Use of one the following: Active, Planned, Temporary Closure, Closed
@@ -85,6 +165,7 @@ type Location struct {
 	GPS     GPS     `yaml:"gps" daml:"gps"  json:"gps"`
 	// Directions : Definition: Directions to manufacturing facility, person or organisation. | Format: Free text. | Note: This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.
 	Directions string `yaml:"directions" daml:"directions"  json:"directions"`
+	// What3Words : Definition: What 3 Words phrase for location. | Format: State the What 3 Words phrase. | Note: Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.
 	What3Words string `yaml:"what_3_words" daml:"what_3_words"  json:"what_3_words"`
 }
 
@@ -108,12 +189,16 @@ type Address struct {
 // GPS : Definition: The relevant GPS coordinates. | Format: Provide the relevant GPS coordinates, using Decimal Degrees.
 type GPS struct {
 	Latitude  float64 `yaml:"latitude" daml:"latitude"  json:"latitude"`
-	Longitude float64 `yaml:"longitude" daml:"longitude"  json:"logitude"`
+	Longitude float64 `yaml:"longitude" daml:"longitude"  json:"longitude" legacy:"json=logitude"`
 }
 
-// Agent :
+// Agent : Definition: A person or organisation. | Note: An Agent defined in one record can be used in others by giving only its ref, so that it is defined once.
 type Agent struct {
-	Name     string   `yaml:"name" daml:"name"  json:"name" validate:"required"`
+	// ID : Definition: A stable identifier of the Agent, which other records can refer to. | Format: A URI, e.g. "agent:bristol-makers" or "urn:uuid:...", or a UUID.
+	ID string `yaml:"id" daml:"id"  json:"id" validate:"omitempty,uri|uuid"`
+	// Ref : Definition: The ID of an Agent defined elsewhere, which this Agent stands for. | Format: The ID of the Agent. | Note: When given, the other keys are left empty.
+	Ref      string   `yaml:"ref" daml:"ref"  json:"ref" validate:"omitempty,uri|uuid"`
+	Name     string   `yaml:"name" daml:"name"  json:"name" validate:"required_without=Ref"`
 	Location Location `yaml:"location" daml:"location"  json:"location"`
 	// ContactPerson : Definition: An Agent who is the key point of contact for a manufacturing facility or organisation. | Format: Provide the name of the Agent.
 	ContactPerson string `yaml:"contact_person" daml:"contact_person"  json:"contact_person"`
@@ -149,7 +234,7 @@ type Contact struct {
 
 // SocialMedia :
 type SocialMedia struct {
-	Facebook  string   `yaml:"landline" daml:"landline"  json:"landline"`
+	Facebook  string   `yaml:"facebook" daml:"facebook"  json:"facebook" legacy:"landline"`
 	Twitter   string   `yaml:"twitter" daml:"twitter"  json:"twitter"`
 	Instagram string   `yaml:"instagram" daml:"instagram"  json:"instagram"`
 	OtherURLs []string `yaml:"other_urls" daml:"other_urls"  json:"other_urls"`
@@ -188,27 +273,10 @@ type Equipment struct {
 	Condition string `yaml:"condition" daml:"condition"  json:"condition"`
 }
 
+// TypeMap : return the named types reachable from OKT, keyed by type name.
+// It is derived from the struct definitions so that it cannot drift from them.
 func TypeMap() map[string]interface{} {
-	// var intr interface{}
-	return map[string]interface{}{
-		"FacilityStatus": FacilityStatus(""),
-		"Location":       Location{},
-		"What3Words":     What3Words{},
-		"Address":        Address{},
-		"GPS":            GPS{},
-		"Agent":          Agent{},
-		"URL":            URL(url.URL{}),
-		"Contact":        Contact{},
-		"SocialMedia":    SocialMedia{},
-		"Skill":          Skill(""),
-		"Equipment":      Equipment{},
-		"Vehicles":       []Vehicle{},
-		"Material":       Material{},
-		"Services":       []Service{},
-		"Permits":        []Permit{},
-		"AreasOfService": []GeoShape{},
-		"Certification":  Certification(""),
-	}
+	return spec.TypeMap(OKT{})
 }
 
 type Material struct {
@@ -241,7 +309,7 @@ func (c Certification) EnumOptions() []string {
 }
 
 type CustomerReview struct {
-	Identifier string `yaml:"indentifier" daml:"indentifier" json:"indentifier"`
+	Identifier string `yaml:"identifier" daml:"identifier" json:"identifier" legacy:"indentifier"`
 	Rating     int    `yaml:"rating" daml:"rating"  json:"rating" validate:"gte=1,lte=5"`
 	Body       string `yaml:"body" daml:"body"  json:"body"`
 }
@@ -266,35 +334,22 @@ type Vehicle struct {
 
 }
 
-func writeFile(filename string, content []byte) error {
-	return ioutil.WriteFile(filename, content, 0644)
+// NewSample : return the sample OKT record.
+func NewSample() OKT {
+	var okt OKT
+	example.New(1).Fill(&okt)
+	okt.SpecVersion = SpecVersion
+	return okt
 }
 
-func Sample(outputDir string) {
-	v := validator.New()
-	_ = v.RegisterValidation("what3words", func(fl validator.FieldLevel) bool {
-		return len(strings.Split(fl.Field().String(), ".")) == 3
-	})
-
-	okt := OKT{
-		Contact: Agent{
-			Name:    "Some Person",
-			Website: "https://example.com",
-		},
-	}
-	yamlSample, err := yaml.Marshal(&okt)
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	writeFile(filepath.Join(outputDir, "okt.yaml"), yamlSample)
-	jsonSample, err := json.Marshal(&okt)
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	writeFile(filepath.Join(outputDir, "okt.json"), jsonSample)
-	damlSample, err := daml.Marshal(okt, TypeMap)
-	if err != nil {
-		log.Fatalf("error: %v", err)
+// Sample : write the sample OKT record to outputDir as okt.yaml and okt.json.
+// DAML is generated as a complete project by the damlgen package instead.
+func Sample(outputDir string) error {
+	okt := NewSample()
+	for _, f := range codec.Formats() {
+		if err := codec.WriteFile(filepath.Join(outputDir, "okt"+f.Ext()), &okt); err != nil {
+			return err
+		}
 	}
-	writeFile(filepath.Join(outputDir, "src/main/daml/OKT.daml"), damlSample)
+	return nil
 }
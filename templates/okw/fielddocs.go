@@ -0,0 +1,988 @@
+// Code generated by okfdoc -type OKW; DO NOT EDIT.
+
+package okw
+
+import "github.com/helpfulengineering/open-knowledge-framework/spec"
+
+// FieldDocs : documentation of the fields of OKW, keyed by YAML path.
+var FieldDocs = spec.Docs{
+	"access_type": {
+		Type:       "AccessType",
+		Definition: "How the manufacturing equipment is accessed.",
+		Format:     "Use one of the following: Restricted (only certain people (e.g. staff members) can use the equipment) Restricted with public hours (the equipment can be used by the public during limited hours) Shared space (the facility is a shared workspace where access is by qualifying criteria (e.g. rental of a desk or workspace)) Public (anyone may use the equipment (e.g. training may be required and other restructions may apply)) Membership (access requires membership, which is available to the public or a certain demographic)",
+		Note:       "For facilities, use this field on a general-terms basis (i.e. if most equipment is available to members, but certain equipment requires staff to operate use Membership). This field can also be used as a property of individual equipment where a facility has different aspect types for different equipment.",
+	},
+	"affiliations": {
+		Type:       "[]Agent",
+		Definition: "The Agent(s) who the manufacturing facility is affiliated with.",
+		Format:     "Uses the Agent class.",
+	},
+	"affiliations.contact": {
+		Type: "Contact",
+	},
+	"affiliations.contact.email": {
+		Type: "string",
+	},
+	"affiliations.contact.fax": {
+		Type:       "string",
+		Definition: "A fax number to contact the facility, person or organisation.",
+		Format:     "Provide the fax number.",
+	},
+	"affiliations.contact.landline": {
+		Type:       "string",
+		Definition: "A landline telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"affiliations.contact.mobile": {
+		Type:       "string",
+		Definition: "A mobile telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"affiliations.contact.whatsapp": {
+		Type: "string",
+	},
+	"affiliations.contact_person": {
+		Type:       "string",
+		Definition: "An Agent who is the key point of contact for a manufacturing facility or organisation.",
+		Format:     "Provide the name of the Agent.",
+	},
+	"affiliations.id": {
+		Type:       "string",
+		Definition: "A stable identifier of the Agent, which other records can refer to.",
+		Format:     "A URI, e.g. \"agent:bristol-makers\" or \"urn:uuid:...\", or a UUID.",
+	},
+	"affiliations.location": {
+		Type:       "Location",
+		Definition: "Location of the facility.",
+		Format:     "Uses the Location class.",
+	},
+	"affiliations.location.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"affiliations.location.address.city": {
+		Type: "string",
+	},
+	"affiliations.location.address.country": {
+		Type: "string",
+	},
+	"affiliations.location.address.district": {
+		Type: "string",
+	},
+	"affiliations.location.address.number": {
+		Type: "string",
+	},
+	"affiliations.location.address.postcode": {
+		Type: "string",
+	},
+	"affiliations.location.address.region": {
+		Type: "string",
+	},
+	"affiliations.location.address.street": {
+		Type: "string",
+	},
+	"affiliations.location.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"affiliations.location.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"affiliations.location.gps.latitude": {
+		Type: "float64",
+	},
+	"affiliations.location.gps.longitude": {
+		Type: "float64",
+	},
+	"affiliations.location.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"affiliations.name": {
+		Type: "string",
+	},
+	"affiliations.ref": {
+		Type:       "string",
+		Definition: "The ID of an Agent defined elsewhere, which this Agent stands for.",
+		Format:     "The ID of the Agent.",
+		Note:       "When given, the other keys are left empty.",
+	},
+	"affiliations.social_media": {
+		Type: "SocialMedia",
+	},
+	"affiliations.social_media.facebook": {
+		Type: "string",
+	},
+	"affiliations.social_media.instagram": {
+		Type: "string",
+	},
+	"affiliations.social_media.other_urls": {
+		Type: "[]string",
+	},
+	"affiliations.social_media.twitter": {
+		Type: "string",
+	},
+	"affiliations.website": {
+		Type: "string",
+	},
+	"backup_generator": {
+		Type:       "*bool",
+		Definition: "Whether a manufacturing facility has a backup generator.",
+		Format:     "TRUE / FALSE",
+		Note:       "Knowledge of this is particiularly useful in places where there are frequent power outages.",
+	},
+	"certifications": {
+		Type:       "[]Certification",
+		Definition: "Certifications obtained by the facility.",
+		Format:     "List the certifications.",
+		Note:       "Knowledge of these is imperative informal manufacturing and procurement. For example, aid agencies would be able to see which manufacturing facilities have particular manufacturing licenses, such as medical manufacturing.",
+	},
+	"contact": {
+		Type:       "Agent",
+		Definition: "An Agent who is the contact for enquiries about making.",
+		Format:     "Uses the Agent class.",
+	},
+	"contact.contact": {
+		Type: "Contact",
+	},
+	"contact.contact.email": {
+		Type: "string",
+	},
+	"contact.contact.fax": {
+		Type:       "string",
+		Definition: "A fax number to contact the facility, person or organisation.",
+		Format:     "Provide the fax number.",
+	},
+	"contact.contact.landline": {
+		Type:       "string",
+		Definition: "A landline telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"contact.contact.mobile": {
+		Type:       "string",
+		Definition: "A mobile telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"contact.contact.whatsapp": {
+		Type: "string",
+	},
+	"contact.contact_person": {
+		Type:       "string",
+		Definition: "An Agent who is the key point of contact for a manufacturing facility or organisation.",
+		Format:     "Provide the name of the Agent.",
+	},
+	"contact.id": {
+		Type:       "string",
+		Definition: "A stable identifier of the Agent, which other records can refer to.",
+		Format:     "A URI, e.g. \"agent:bristol-makers\" or \"urn:uuid:...\", or a UUID.",
+	},
+	"contact.location": {
+		Type:       "Location",
+		Definition: "Location of the facility.",
+		Format:     "Uses the Location class.",
+	},
+	"contact.location.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"contact.location.address.city": {
+		Type: "string",
+	},
+	"contact.location.address.country": {
+		Type: "string",
+	},
+	"contact.location.address.district": {
+		Type: "string",
+	},
+	"contact.location.address.number": {
+		Type: "string",
+	},
+	"contact.location.address.postcode": {
+		Type: "string",
+	},
+	"contact.location.address.region": {
+		Type: "string",
+	},
+	"contact.location.address.street": {
+		Type: "string",
+	},
+	"contact.location.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"contact.location.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"contact.location.gps.latitude": {
+		Type: "float64",
+	},
+	"contact.location.gps.longitude": {
+		Type: "float64",
+	},
+	"contact.location.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"contact.name": {
+		Type: "string",
+	},
+	"contact.ref": {
+		Type:       "string",
+		Definition: "The ID of an Agent defined elsewhere, which this Agent stands for.",
+		Format:     "The ID of the Agent.",
+		Note:       "When given, the other keys are left empty.",
+	},
+	"contact.social_media": {
+		Type: "SocialMedia",
+	},
+	"contact.social_media.facebook": {
+		Type: "string",
+	},
+	"contact.social_media.instagram": {
+		Type: "string",
+	},
+	"contact.social_media.other_urls": {
+		Type: "[]string",
+	},
+	"contact.social_media.twitter": {
+		Type: "string",
+	},
+	"contact.website": {
+		Type: "string",
+	},
+	"customer_reviews": {
+		Type:       "[]CustomerReview",
+		Definition: "Customer reviews of the facility.",
+		Format:     "Free text.",
+	},
+	"customer_reviews.body": {
+		Type: "string",
+	},
+	"customer_reviews.identifier": {
+		Type: "string",
+	},
+	"customer_reviews.rating": {
+		Type: "int",
+	},
+	"date_founded": {
+		Type:       "string",
+		Definition: "Date the facility was founded.",
+		Format:     "Recommended practice is to use ISO 8601, i.e. the format YYYY-MM-DD.",
+		Note:       "It is acceptable to include only the Year (YYYY) or year and month (YYYY-MM).",
+	},
+	"description": {
+		Type:       "string",
+		Definition: "Description of the facility.",
+		Format:     "Free text.",
+	},
+	"equipment": {
+		Type:       "Equipment",
+		Definition: "The equipment available for use at the manufacturing facility.",
+		Format:     "List the equipment available using the Equipment class.",
+	},
+	"equipment.condition": {
+		Type:       "string",
+		Definition: "The condition of the piece of equipment.",
+		Format:     "State the condition of the piece of equipment.",
+		Note:       "This provides a user with information surrounding the quality of a piece of equipment/tool, and whether it can complete the task they need it for.",
+	},
+	"equipment.equipment_type": {
+		Type:       "string",
+		Definition: "Classification of Equipment.",
+		Format:     "Provide the Wikipedia URL for the relevant Equipment Type.",
+		Note:       "For instructions how to do this, please see section 3.5.",
+	},
+	"equipment.location": {
+		Type:       "Location",
+		Definition: "Location of the equipment.",
+		Format:     "Uses Location class.",
+	},
+	"equipment.location.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"equipment.location.address.city": {
+		Type: "string",
+	},
+	"equipment.location.address.country": {
+		Type: "string",
+	},
+	"equipment.location.address.district": {
+		Type: "string",
+	},
+	"equipment.location.address.number": {
+		Type: "string",
+	},
+	"equipment.location.address.postcode": {
+		Type: "string",
+	},
+	"equipment.location.address.region": {
+		Type: "string",
+	},
+	"equipment.location.address.street": {
+		Type: "string",
+	},
+	"equipment.location.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"equipment.location.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"equipment.location.gps.latitude": {
+		Type: "float64",
+	},
+	"equipment.location.gps.longitude": {
+		Type: "float64",
+	},
+	"equipment.location.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"equipment.make": {
+		Type:       "string",
+		Definition: "Make of the piece of equipment.",
+		Format:     "Provide the make of the model.",
+		Note:       "Provides detailed information about a piece of equipment/tool. For example, you can design generically for a 3D printer, or you can design for a specific make or model of 3D printer.",
+	},
+	"equipment.manufacturing_process": {
+		Type:       "string",
+		Definition: "Manufacturing process the Equipment is capable of.",
+		Format:     "Provide the Wikipedia URL for the relevant manufacturing process.",
+		Note:       "For instructions how to do this, please see section 3.5.",
+	},
+	"equipment.model": {
+		Type:       "string",
+		Definition: "Model of the piece of Equipment.",
+		Format:     "Provide the name of the model.",
+	},
+	"equipment.serial_number": {
+		Type:       "string",
+		Definition: "Serial number of the piece of Equipment.",
+		Format:     "Provide the serial number of the Equipment.",
+	},
+	"equipment.skills_required": {
+		Type:       "[]Skill",
+		Definition: "Identified as future work.",
+	},
+	"facility_status": {
+		Type:       "FacilityStatus",
+		Definition: "Status of the facility.",
+		Format:     "Use of one the following:",
+	},
+	"id": {
+		Type:       "string",
+		Definition: "A stable identifier of the record, which other records can refer to.",
+		Format:     "A URI, e.g. \"urn:uuid:6f1c4b0e-3a2d-4e5f-9a7b-8c9d0e1f2a3b\", or a UUID.",
+	},
+	"loading_dock": {
+		Type:       "*bool",
+		Definition: "Whether a manufacturing facility has a loading dock.",
+		Format:     "TRUE / FALSE",
+	},
+	"location": {
+		Type:       "Location",
+		Definition: "Location of the facility.",
+		Format:     "Uses the Location class.",
+	},
+	"location.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"location.address.city": {
+		Type: "string",
+	},
+	"location.address.country": {
+		Type: "string",
+	},
+	"location.address.district": {
+		Type: "string",
+	},
+	"location.address.number": {
+		Type: "string",
+	},
+	"location.address.postcode": {
+		Type: "string",
+	},
+	"location.address.region": {
+		Type: "string",
+	},
+	"location.address.street": {
+		Type: "string",
+	},
+	"location.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"location.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"location.gps.latitude": {
+		Type: "float64",
+	},
+	"location.gps.longitude": {
+		Type: "float64",
+	},
+	"location.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"maintenance_schedule": {
+		Type:       "string",
+		Definition: "The maintenance schedule of a manufacturing facility.",
+		Format:     "Free text.",
+	},
+	"manufacturing_processes": {
+		Type:       "string",
+		Definition: "Manufacturing process the Equipment is capable of.",
+		Format:     "Provide the Wikipedia URL for the relevant manufacturing process.",
+		Note:       "For instructions how to do this, please see section 3.5.",
+	},
+	"metadata": {
+		Type:       "*Metadata",
+		Definition: "Provenance of the record: who published it, when, under what license and from where.",
+		Format:     "Uses the Metadata class.",
+	},
+	"metadata.changelog": {
+		Type:       "[]string",
+		Definition: "Notes on the changes made to the record.",
+		Format:     "List of free text entries, newest last.",
+	},
+	"metadata.created": {
+		Type:       "string",
+		Definition: "When the record was first published.",
+		Format:     "RFC 3339 date and time, e.g. \"2024-03-01T09:30:00Z\", or an ISO 8601 date, i.e. YYYY-MM-DD.",
+	},
+	"metadata.creator": {
+		Type:       "*Agent",
+		Definition: "The Agent who published the record.",
+		Format:     "Uses the Agent class.",
+	},
+	"metadata.creator.contact": {
+		Type: "Contact",
+	},
+	"metadata.creator.contact.email": {
+		Type: "string",
+	},
+	"metadata.creator.contact.fax": {
+		Type:       "string",
+		Definition: "A fax number to contact the facility, person or organisation.",
+		Format:     "Provide the fax number.",
+	},
+	"metadata.creator.contact.landline": {
+		Type:       "string",
+		Definition: "A landline telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"metadata.creator.contact.mobile": {
+		Type:       "string",
+		Definition: "A mobile telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"metadata.creator.contact.whatsapp": {
+		Type: "string",
+	},
+	"metadata.creator.contact_person": {
+		Type:       "string",
+		Definition: "An Agent who is the key point of contact for a manufacturing facility or organisation.",
+		Format:     "Provide the name of the Agent.",
+	},
+	"metadata.creator.id": {
+		Type:       "string",
+		Definition: "A stable identifier of the Agent, which other records can refer to.",
+		Format:     "A URI, e.g. \"agent:bristol-makers\" or \"urn:uuid:...\", or a UUID.",
+	},
+	"metadata.creator.location": {
+		Type:       "Location",
+		Definition: "Location of the facility.",
+		Format:     "Uses the Location class.",
+	},
+	"metadata.creator.location.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"metadata.creator.location.address.city": {
+		Type: "string",
+	},
+	"metadata.creator.location.address.country": {
+		Type: "string",
+	},
+	"metadata.creator.location.address.district": {
+		Type: "string",
+	},
+	"metadata.creator.location.address.number": {
+		Type: "string",
+	},
+	"metadata.creator.location.address.postcode": {
+		Type: "string",
+	},
+	"metadata.creator.location.address.region": {
+		Type: "string",
+	},
+	"metadata.creator.location.address.street": {
+		Type: "string",
+	},
+	"metadata.creator.location.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"metadata.creator.location.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"metadata.creator.location.gps.latitude": {
+		Type: "float64",
+	},
+	"metadata.creator.location.gps.longitude": {
+		Type: "float64",
+	},
+	"metadata.creator.location.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"metadata.creator.name": {
+		Type: "string",
+	},
+	"metadata.creator.ref": {
+		Type:       "string",
+		Definition: "The ID of an Agent defined elsewhere, which this Agent stands for.",
+		Format:     "The ID of the Agent.",
+		Note:       "When given, the other keys are left empty.",
+	},
+	"metadata.creator.social_media": {
+		Type: "SocialMedia",
+	},
+	"metadata.creator.social_media.facebook": {
+		Type: "string",
+	},
+	"metadata.creator.social_media.instagram": {
+		Type: "string",
+	},
+	"metadata.creator.social_media.other_urls": {
+		Type: "[]string",
+	},
+	"metadata.creator.social_media.twitter": {
+		Type: "string",
+	},
+	"metadata.creator.website": {
+		Type: "string",
+	},
+	"metadata.data_license": {
+		Type:       "string",
+		Definition: "The license the record itself is published under.",
+		Format:     "SPDX license identifier or expression, e.g. \"CC-BY-4.0\".",
+	},
+	"metadata.modified": {
+		Type:       "string",
+		Definition: "When the record was last changed.",
+		Format:     "RFC 3339 date and time, or an ISO 8601 date.",
+	},
+	"metadata.source": {
+		Type:       "string",
+		Definition: "Where the record was obtained from, e.g. the directory it was imported from.",
+		Format:     "URL.",
+	},
+	"metadata.verified": {
+		Type:       "string",
+		Definition: "When the record was last checked against the facility itself, e.g. by calling its contact.",
+		Format:     "RFC 3339 date and time, or an ISO 8601 date.",
+	},
+	"name": {
+		Type:       "string",
+		Definition: "Name of the facility.",
+		Format:     "Provide the name of the facility.",
+	},
+	"opening_hours": {
+		Type:       "string",
+		Definition: "Hours in which the facility operates.",
+		Format:     "Free text.",
+	},
+	"owner": {
+		Type:       "Agent",
+		Definition: "An Agent who owns or manages the facility.",
+		Format:     "Uses the Agent class.",
+	},
+	"owner.contact": {
+		Type: "Contact",
+	},
+	"owner.contact.email": {
+		Type: "string",
+	},
+	"owner.contact.fax": {
+		Type:       "string",
+		Definition: "A fax number to contact the facility, person or organisation.",
+		Format:     "Provide the fax number.",
+	},
+	"owner.contact.landline": {
+		Type:       "string",
+		Definition: "A landline telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"owner.contact.mobile": {
+		Type:       "string",
+		Definition: "A mobile telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"owner.contact.whatsapp": {
+		Type: "string",
+	},
+	"owner.contact_person": {
+		Type:       "string",
+		Definition: "An Agent who is the key point of contact for a manufacturing facility or organisation.",
+		Format:     "Provide the name of the Agent.",
+	},
+	"owner.id": {
+		Type:       "string",
+		Definition: "A stable identifier of the Agent, which other records can refer to.",
+		Format:     "A URI, e.g. \"agent:bristol-makers\" or \"urn:uuid:...\", or a UUID.",
+	},
+	"owner.location": {
+		Type:       "Location",
+		Definition: "Location of the facility.",
+		Format:     "Uses the Location class.",
+	},
+	"owner.location.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"owner.location.address.city": {
+		Type: "string",
+	},
+	"owner.location.address.country": {
+		Type: "string",
+	},
+	"owner.location.address.district": {
+		Type: "string",
+	},
+	"owner.location.address.number": {
+		Type: "string",
+	},
+	"owner.location.address.postcode": {
+		Type: "string",
+	},
+	"owner.location.address.region": {
+		Type: "string",
+	},
+	"owner.location.address.street": {
+		Type: "string",
+	},
+	"owner.location.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"owner.location.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"owner.location.gps.latitude": {
+		Type: "float64",
+	},
+	"owner.location.gps.longitude": {
+		Type: "float64",
+	},
+	"owner.location.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"owner.name": {
+		Type: "string",
+	},
+	"owner.ref": {
+		Type:       "string",
+		Definition: "The ID of an Agent defined elsewhere, which this Agent stands for.",
+		Format:     "The ID of the Agent.",
+		Note:       "When given, the other keys are left empty.",
+	},
+	"owner.social_media": {
+		Type: "SocialMedia",
+	},
+	"owner.social_media.facebook": {
+		Type: "string",
+	},
+	"owner.social_media.instagram": {
+		Type: "string",
+	},
+	"owner.social_media.other_urls": {
+		Type: "[]string",
+	},
+	"owner.social_media.twitter": {
+		Type: "string",
+	},
+	"owner.website": {
+		Type: "string",
+	},
+	"partner_funder": {
+		Type:       "Agent",
+		Definition: "The Agent which partners or funds the facility.",
+		Format:     "Uses the Agent class.",
+	},
+	"partner_funder.contact": {
+		Type: "Contact",
+	},
+	"partner_funder.contact.email": {
+		Type: "string",
+	},
+	"partner_funder.contact.fax": {
+		Type:       "string",
+		Definition: "A fax number to contact the facility, person or organisation.",
+		Format:     "Provide the fax number.",
+	},
+	"partner_funder.contact.landline": {
+		Type:       "string",
+		Definition: "A landline telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"partner_funder.contact.mobile": {
+		Type:       "string",
+		Definition: "A mobile telephone number to contact the facility, person or organisation.",
+		Format:     "Provide the telephone number.",
+	},
+	"partner_funder.contact.whatsapp": {
+		Type: "string",
+	},
+	"partner_funder.contact_person": {
+		Type:       "string",
+		Definition: "An Agent who is the key point of contact for a manufacturing facility or organisation.",
+		Format:     "Provide the name of the Agent.",
+	},
+	"partner_funder.id": {
+		Type:       "string",
+		Definition: "A stable identifier of the Agent, which other records can refer to.",
+		Format:     "A URI, e.g. \"agent:bristol-makers\" or \"urn:uuid:...\", or a UUID.",
+	},
+	"partner_funder.location": {
+		Type:       "Location",
+		Definition: "Location of the facility.",
+		Format:     "Uses the Location class.",
+	},
+	"partner_funder.location.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"partner_funder.location.address.city": {
+		Type: "string",
+	},
+	"partner_funder.location.address.country": {
+		Type: "string",
+	},
+	"partner_funder.location.address.district": {
+		Type: "string",
+	},
+	"partner_funder.location.address.number": {
+		Type: "string",
+	},
+	"partner_funder.location.address.postcode": {
+		Type: "string",
+	},
+	"partner_funder.location.address.region": {
+		Type: "string",
+	},
+	"partner_funder.location.address.street": {
+		Type: "string",
+	},
+	"partner_funder.location.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"partner_funder.location.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"partner_funder.location.gps.latitude": {
+		Type: "float64",
+	},
+	"partner_funder.location.gps.longitude": {
+		Type: "float64",
+	},
+	"partner_funder.location.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"partner_funder.name": {
+		Type: "string",
+	},
+	"partner_funder.ref": {
+		Type:       "string",
+		Definition: "The ID of an Agent defined elsewhere, which this Agent stands for.",
+		Format:     "The ID of the Agent.",
+		Note:       "When given, the other keys are left empty.",
+	},
+	"partner_funder.social_media": {
+		Type: "SocialMedia",
+	},
+	"partner_funder.social_media.facebook": {
+		Type: "string",
+	},
+	"partner_funder.social_media.instagram": {
+		Type: "string",
+	},
+	"partner_funder.social_media.other_urls": {
+		Type: "[]string",
+	},
+	"partner_funder.social_media.twitter": {
+		Type: "string",
+	},
+	"partner_funder.website": {
+		Type: "string",
+	},
+	"road_access": {
+		Type:       "*bool",
+		Definition: "Whether a manufacturing facility has road access.",
+		Format:     "TRUE / FALSE",
+	},
+	"size_floor_size": {
+		Type:       "*int",
+		Definition: "The size or floor size of a manufacturing facility.",
+		Format:     "Integer. Unit: square metres (sqm).",
+		Note:       "This helps a prospective user gauge the scale of a manufacturing facility.",
+	},
+	"spec_version": {
+		Type:       "string",
+		Definition: "The version of the specification the record follows.",
+		Format:     "Text, e.g. \"2\".",
+		Note:       "Records without it follow version 1.",
+	},
+	"storage_capacity": {
+		Type: "string",
+	},
+	"typical_batch_size": {
+		Type:       "TypicalBatchSize",
+		Definition: "Typical batch size output.",
+		Format:     "Use one of the following:",
+	},
+	"typical_materials": {
+		Type:       "[]Material",
+		Definition: "Typical materials used by the facility.",
+		Format:     "Uses the Materials class.",
+	},
+	"typical_materials.brand": {
+		Type: "string",
+	},
+	"typical_materials.definedmaterialtype": {
+		Type: "MaterialType",
+	},
+	"typical_materials.manufacturer": {
+		Type: "string",
+	},
+	"typical_materials.material_type": {
+		Type:       "string",
+		Definition: "Type of material.",
+		Format:     "Provide the Wikiepedia URL for the relevant material type.",
+		Note:       "For instructions how to do this, please see section 3.5.",
+	},
+	"typical_materials.supplierlocation": {
+		Type:       "Location",
+		Definition: "Location of the facility.",
+		Format:     "Uses the Location class.",
+	},
+	"typical_materials.supplierlocation.address": {
+		Type:       "Address",
+		Definition: "Address relating to a manufacturing facility, person or organisation.",
+		Format:     "Use the defined Address sub-properties",
+	},
+	"typical_materials.supplierlocation.address.city": {
+		Type: "string",
+	},
+	"typical_materials.supplierlocation.address.country": {
+		Type: "string",
+	},
+	"typical_materials.supplierlocation.address.district": {
+		Type: "string",
+	},
+	"typical_materials.supplierlocation.address.number": {
+		Type: "string",
+	},
+	"typical_materials.supplierlocation.address.postcode": {
+		Type: "string",
+	},
+	"typical_materials.supplierlocation.address.region": {
+		Type: "string",
+	},
+	"typical_materials.supplierlocation.address.street": {
+		Type: "string",
+	},
+	"typical_materials.supplierlocation.directions": {
+		Type:       "string",
+		Definition: "Directions to manufacturing facility, person or organisation.",
+		Format:     "Free text.",
+		Note:       "This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.",
+	},
+	"typical_materials.supplierlocation.gps": {
+		Type:       "GPS",
+		Definition: "The relevant GPS coordinates.",
+		Format:     "Provide the relevant GPS coordinates, using Decimal Degrees.",
+	},
+	"typical_materials.supplierlocation.gps.latitude": {
+		Type: "float64",
+	},
+	"typical_materials.supplierlocation.gps.longitude": {
+		Type: "float64",
+	},
+	"typical_materials.supplierlocation.what_3_words": {
+		Type:       "string",
+		Definition: "What 3 Words phrase for location.",
+		Format:     "State the What 3 Words phrase.",
+		Note:       "Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.",
+	},
+	"typical_products": {
+		Type:       "[]string",
+		Definition: "Typical products produced by the facility.",
+		Format:     "List the typical products produced.",
+	},
+	"uninterrupted_power_supply": {
+		Type:       "*bool",
+		Definition: "Whether a manufacturing facility has an uninterrupted power supply.",
+		Format:     "TRUE / FALSE",
+	},
+	"wheelchair_accessibility": {
+		Type:       "*bool",
+		Definition: "Whether the manufacturing facility is wheelchair accessible.",
+		Format:     "Free text.",
+	},
+}
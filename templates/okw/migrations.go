@@ -0,0 +1,17 @@
+package okw
+
+import "github.com/helpfulengineering/open-knowledge-framework/migration"
+
+// SpecVersion : the version of the specification that OKW implements.
+const SpecVersion = "2"
+
+// Migrations : the steps that upgrade OKW documents to SpecVersion, in order.
+var Migrations = []migration.Step{
+	{
+		From:    "1",
+		To:      "2",
+		Summary: "Correct the misspelt keys wheelchair_acessibility, indentifier, logitude (JSON) and landline (Facebook)",
+		Apply:   migration.CorrectLegacyKeys(OKW{}),
+		Keys:    migration.LegacyKeys(OKW{}),
+	},
+}
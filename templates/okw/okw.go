@@ -1,19 +1,15 @@
 package okw
 
+//go:generate go run ../../cmd/okfdoc -type OKW
+
 import (
-	"encoding/json"
-	"io/ioutil"
-	"log"
 	"path/filepath"
-	"strings"
 
 	"net/url"
 
-	"reflect"
-
-	daml "github.com/psprings/go-daml"
-	"gopkg.in/go-playground/validator.v9"
-	"gopkg.in/yaml.v2"
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/example"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
 )
 
 type Student struct {
@@ -92,6 +88,11 @@ func (at AccessType) EnumOptions() []string {
 
 // OKW :
 type OKW struct {
+	// SpecVersion : Definition: The version of the specification the record follows. | Format: Text, e.g. "2". | Note: Records without it follow version 1.
+	SpecVersion string `yaml:"spec_version" daml:"spec_version"  json:"spec_version"`
+	// ID : Definition: A stable identifier of the record, which other records can refer to. | Format: A URI, e.g. "urn:uuid:6f1c4b0e-3a2d-4e5f-9a7b-8c9d0e1f2a3b", or a UUID.
+	ID string `yaml:"id" daml:"id"  json:"id" validate:"omitempty,uri|uuid"`
+	// Name : Definition: Name of the facility. | Format: Provide the name of the facility.
 	Name     string   `yaml:"name" daml:"name"  json:"name" validate:"required"`
 	Location Location `yaml:"location" daml:"location"  json:"location" validate:"required"`
 	// Owner : Definition: An Agent who owns or manages the facility. | Format: Uses the Agent class.
@@ -99,9 +100,9 @@ type OKW struct {
 	// Contact : Definition: An Agent who is the contact for enquiries about making. | Format: Uses the Agent class.
 	Contact Agent `yaml:"contact" daml:"contact"  json:"contact" validate:"required"`
 	// Affiliations : Definition: The Agent(s) who the manufacturing facility is affiliated with. | Format: Uses the Agent class.
-	Affiliations []Agent `yaml:"affiliations" daml:"affiliations"  json:"affiliations"`
+	Affiliations []Agent `yaml:"affiliations" daml:"affiliations"  json:"affiliations" validate:"dive"`
 	// FacilityStatus : Definition: Status of the facility. | Format: Use of one the following:
-	FacilityStatus FacilityStatus `yaml:"facility_status" daml:"facility_status"  json:"facility_status"`
+	FacilityStatus FacilityStatus `yaml:"facility_status" daml:"facility_status"  json:"facility_status" validate:"omitempty,enum"`
 	// OpeningHours : Definition: Hours in which the facility operates. | Format: Free text.
 	OpeningHours string `yaml:"opening_hours" daml:"opening_hours"  json:"opening_hours"`
 	// Description : Definition: Description of the facility. | Format: Free text.
@@ -117,9 +118,9 @@ type OKW struct {
 	// Public (anyone may use the equipment (e.g. training may be required and other restructions may apply))
 	// Membership (access requires membership, which is available to the public or a certain demographic)
 	// Note: For facilities, use this field on a general-terms basis (i.e. if most equipment is available to members, but certain equipment requires staff to operate use Membership). This field can also be used as a property of individual equipment where a facility has different aspect types for different equipment.
-	AccessType AccessType `yaml:"access_type" daml:"access_type"  json:"access_type"`
+	AccessType AccessType `yaml:"access_type" daml:"access_type"  json:"access_type" validate:"omitempty,enum"`
 	// WheelchairAcessibility : Definition: Whether the manufacturing facility is wheelchair accessible. | Format: Free text.
-	WheelchairAcessibility bool `yaml:"wheelchair_acessibility" daml:"wheelchair_acessibility"  json:"wheelchair_acessibility"`
+	WheelchairAcessibility *bool `yaml:"wheelchair_accessibility" daml:"wheelchair_accessibility"  json:"wheelchair_accessibility" legacy:"wheelchair_acessibility"`
 	// Equipment : Definition: The equipment available for use at the manufacturing facility. | Format: List the equipment available using the Equipment class.
 	Equipment Equipment `yaml:"equipment" daml:"equipment"  json:"equipment"`
 	// ManufacturingProcesses : Definition: Manufacturing process the Equipment is capable of. | Format: Provide the Wikipedia URL for the relevant manufacturing process. | Note: For instructions how to do this, please see section 3.5.
@@ -128,7 +129,7 @@ type OKW struct {
 	// TypicalBatchSize : Definition: Typical batch size output. | Format:  Use one of the following:
 	TypicalBatchSize TypicalBatchSize `yaml:"typical_batch_size" daml:"typical_batch_size"  json:"typical_batch_size"`
 	// SizeFloorSize : Definition: The size or floor size of a manufacturing facility. | Format: Integer. Unit: square metres (sqm). | Note: This helps a prospective user gauge the scale of a manufacturing facility.
-	SizeFloorSize int `yaml:"size_floor_size" daml:"size_floor_size"  json:"size_floor_size"`
+	SizeFloorSize *int `yaml:"size_floor_size" daml:"size_floor_size"  json:"size_floor_size" validate:"omitempty,gte=0"`
 	// StorageCapacity :
 	StorageCapacity string `yaml:"storage_capacity" daml:"storage_capacity"  json:"storage_capacity"`
 	// TypicalMaterials : Definition: Typical materials used by the facility. | Format: Uses the Materials class.
@@ -136,13 +137,13 @@ type OKW struct {
 	// Certifications : Definition: Certifications obtained by the facility. | Format: List the certifications. | Note: Knowledge of these is imperative informal manufacturing and procurement. For example, aid agencies would be able to see which manufacturing facilities have particular manufacturing licenses, such as medical manufacturing.
 	Certifications []Certification `yaml:"certifications" daml:"certifications"  json:"certifications"`
 	// BackupGenerator : Definition: Whether a manufacturing facility has a backup generator. | Format: TRUE / FALSE | Note: Knowledge of this is particiularly useful in places where there are frequent power outages.
-	BackupGenerator bool `yaml:"backup_generator" daml:"backup_generator"  json:"backup_generator"`
+	BackupGenerator *bool `yaml:"backup_generator" daml:"backup_generator"  json:"backup_generator"`
 	// UninterruptedPowerSupply : Definition: Whether a manufacturing facility has an uninterrupted power supply. | Format: TRUE / FALSE
-	UninterruptedPowerSupply bool `yaml:"uninterrupted_power_supply" daml:"uninterrupted_power_supply"  json:"uninterrupted_power_supply"`
+	UninterruptedPowerSupply *bool `yaml:"uninterrupted_power_supply" daml:"uninterrupted_power_supply"  json:"uninterrupted_power_supply"`
 	// RoadAccess : Definition: Whether a manufacturing facility has road access. | Format: TRUE / FALSE
-	RoadAccess bool `yaml:"road_access" daml:"road_access"  json:"road_access"`
+	RoadAccess *bool `yaml:"road_access" daml:"road_access"  json:"road_access"`
 	// LoadingDock : Definition: Whether a manufacturing facility has a loading dock. | Format: TRUE / FALSE
-	LoadingDock bool `yaml:"loading_dock" daml:"loading_dock"  json:"loading_dock"`
+	LoadingDock *bool `yaml:"loading_dock" daml:"loading_dock"  json:"loading_dock"`
 	// MaintenanceSchedule : Definition: The maintenance schedule of a manufacturing facility. | Format: Free text.
 	MaintenanceSchedule string `yaml:"maintenance_schedule" daml:"maintenance_schedule"  json:"maintenance_schedule"`
 	// TypicalProducts : Definition: Typical products produced by the facility. | Format: List the typical products produced.
@@ -150,7 +151,27 @@ type OKW struct {
 	// PartnerFunder : Definition: The Agent which partners or funds the facility. | Format: Uses the Agent class.
 	PartnerFunder Agent `yaml:"partner_funder" daml:"partner_funder"  json:"partner_funder"`
 	// CustomerReviews : Definition: Customer reviews of the facility. | Format: Free text.
-	CustomerReviews []CustomerReview `yaml:"customer_reviews" daml:"customer_reviews"  json:"customer_reviews"`
+	CustomerReviews []CustomerReview `yaml:"customer_reviews" daml:"customer_reviews"  json:"customer_reviews" validate:"dive"`
+	// Metadata : Definition: Provenance of the record: who published it, when, under what license and from where. | Format: Uses the Metadata class.
+	Metadata *Metadata `yaml:"metadata" daml:"metadata"  json:"metadata"`
+}
+
+// Metadata : Definition: Where the record comes from and how current it is. | Note: Carried unchanged through conversions, so that provenance is never lost.
+type Metadata struct {
+	// Creator : Definition: The Agent who published the record. | Format: Uses the Agent class.
+	Creator *Agent `yaml:"creator,omitempty" daml:"creator"  json:"creator,omitempty"`
+	// Created : Definition: When the record was first published. | Format: RFC 3339 date and time, e.g. "2024-03-01T09:30:00Z", or an ISO 8601 date, i.e. YYYY-MM-DD.
+	Created string `yaml:"created" daml:"created"  json:"created" validate:"omitempty,timestamp"`
+	// Modified : Definition: When the record was last changed. | Format: RFC 3339 date and time, or an ISO 8601 date.
+	Modified string `yaml:"modified" daml:"modified"  json:"modified" validate:"omitempty,timestamp"`
+	// Verified : Definition: When the record was last checked against the facility itself, e.g. by calling its contact. | Format: RFC 3339 date and time, or an ISO 8601 date.
+	Verified string `yaml:"verified" daml:"verified"  json:"verified" validate:"omitempty,timestamp"`
+	// Source : Definition: Where the record was obtained from, e.g. the directory it was imported from. | Format: URL.
+	Source string `yaml:"source" daml:"source"  json:"source" validate:"omitempty,url"`
+	// DataLicense : Definition: The license the record itself is published under. | Format: SPDX license identifier or expression, e.g. "CC-BY-4.0".
+	DataLicense string `yaml:"data_license" daml:"data_license"  json:"data_license" validate:"omitempty,spdx"`
+	// Changelog : Definition: Notes on the changes made to the record. | Format: List of free text entries, newest last.
+	Changelog []string `yaml:"changelog" daml:"changelog"  json:"changelog"`
 }
 
 // FacilityStatus : Definition: Status of the facility. | Format: Use of one the following: Active, Planned, Temporary Closure, Closed
@@ -177,6 +198,7 @@ type Location struct {
 	GPS     GPS     `yaml:"gps" daml:"gps"  json:"gps"`
 	// Directions : Definition: Directions to manufacturing facility, person or organisation. | Format: Free text. | Note: This qualitative data field may be helpful for a difficult to find location, or in an area where the standard address format is irrelevant.
 	Directions string `yaml:"directions" daml:"directions"  json:"directions"`
+	// What3Words : Definition: What 3 Words phrase for location. | Format: State the What 3 Words phrase. | Note: Often informal settlements, or developing countries do not have street addresses, and communicating GPS coordinates can be tricky and error-prone. What 3 Words is an alternative geospatial address system.
 	What3Words string `yaml:"what_3_words" daml:"what_3_words"  json:"what_3_words"`
 }
 
@@ -200,12 +222,16 @@ type Address struct {
 // GPS : Definition: The relevant GPS coordinates. | Format: Provide the relevant GPS coordinates, using Decimal Degrees.
 type GPS struct {
 	Latitude  float64 `yaml:"latitude" daml:"latitude"  json:"latitude"`
-	Longitude float64 `yaml:"longitude" daml:"longitude"  json:"logitude"`
+	Longitude float64 `yaml:"longitude" daml:"longitude"  json:"longitude" legacy:"json=logitude"`
 }
 
-// Agent :
+// Agent : Definition: A person or organisation. | Note: An Agent defined in one record can be used in others by giving only its ref, so that it is defined once.
 type Agent struct {
-	Name     string   `yaml:"name" daml:"name"  json:"name" validate:"required"`
+	// ID : Definition: A stable identifier of the Agent, which other records can refer to. | Format: A URI, e.g. "agent:bristol-makers" or "urn:uuid:...", or a UUID.
+	ID string `yaml:"id" daml:"id"  json:"id" validate:"omitempty,uri|uuid"`
+	// Ref : Definition: The ID of an Agent defined elsewhere, which this Agent stands for. | Format: The ID of the Agent. | Note: When given, the other keys are left empty.
+	Ref      string   `yaml:"ref" daml:"ref"  json:"ref" validate:"omitempty,uri|uuid"`
+	Name     string   `yaml:"name" daml:"name"  json:"name" validate:"required_without=Ref"`
 	Location Location `yaml:"location" daml:"location"  json:"location"`
 	// ContactPerson : Definition: An Agent who is the key point of contact for a manufacturing facility or organisation. | Format: Provide the name of the Agent.
 	ContactPerson string `yaml:"contact_person" daml:"contact_person"  json:"contact_person"`
@@ -245,7 +271,7 @@ type Contact struct {
 
 // SocialMedia :
 type SocialMedia struct {
-	Facebook  string   `yaml:"landline" daml:"landline"  json:"landline"`
+	Facebook  string   `yaml:"facebook" daml:"facebook"  json:"facebook" legacy:"landline"`
 	Twitter   string   `yaml:"twitter" daml:"twitter"  json:"twitter"`
 	Instagram string   `yaml:"instagram" daml:"instagram"  json:"instagram"`
 	OtherURLs []string `yaml:"other_urls" daml:"other_urls"  json:"other_urls"`
@@ -284,35 +310,10 @@ type Equipment struct {
 	Condition string `yaml:"condition" daml:"condition"  json:"condition"`
 }
 
+// TypeMap : return the named types reachable from OKW, keyed by type name.
+// It is derived from the struct definitions so that it cannot drift from them.
 func TypeMap() map[string]interface{} {
-	// var intr interface{}
-	return map[string]interface{}{
-		"FacilityStatus": FacilityStatus(""),
-		// "FacilityStatus": "",
-		"AccessType": AccessType(""),
-		// "AccessType": "",
-		"TypicalBatchSize": TypicalBatchSize(""),
-		// "TypicalBatchSize":    "",
-		"Location":            Location{},
-		"What3Words":          What3Words{},
-		"Address":             Address{},
-		"GPS":                 GPS{},
-		"Agent":               Agent{},
-		"URL":                 URL(url.URL{}),
-		"Contact":             Contact{},
-		"SocialMedia":         SocialMedia{},
-		"Skill":               Skill(""),
-		"Equipment":           Equipment{},
-		"EquipmentProperties": EquipmentProperties{},
-		"Material":            Material{},
-		"MaterialType":        MaterialType(""),
-		"CircularEconomy":     CircularEconomy{},
-		"HumanCapacity":       HumanCapacity{},
-		"InnovationSpace":     InnovationSpace{},
-		"LearningResource":    LearningResource{},
-		"CustomerReview":      CustomerReview{},
-		"Certification":       Certification(""),
-	}
+	return spec.TypeMap(OKW{})
 }
 
 type EquipmentProperties struct {
@@ -390,59 +391,29 @@ func (c Certification) EnumOptions() []string {
 }
 
 type CustomerReview struct {
-	Identifier string `yaml:"indentifier" daml:"indentifier" json:"indentifier"`
+	Identifier string `yaml:"identifier" daml:"identifier" json:"identifier" legacy:"indentifier"`
 	Rating     int    `yaml:"rating" daml:"rating"  json:"rating" validate:"gte=1,lte=5"`
 	Body       string `yaml:"body" daml:"body"  json:"body"`
 }
 
-func writeFile(filename string, content []byte) error {
-	return ioutil.WriteFile(filename, content, 0644)
-}
-
-func Sample(outputDir string) {
-	v := validator.New()
-	_ = v.RegisterValidation("what3words", func(fl validator.FieldLevel) bool {
-		return len(strings.Split(fl.Field().String(), ".")) == 3
-	})
-
-	foo := Active
-	r := reflect.ValueOf(foo)
-	enum := r.MethodByName("Enum")
-	log.Printf("Is Enum?: %s", enum.IsValid())
-
-	// u, err := url.Parse("https://google.com")
-	// println(u.String() + " WOAH!")
-	// var okw OKW
-	// okw := OKW{
-	// 	Owner: Agent{
-	// 		Website: url.URL{
-	// 			Scheme: "https",
-	// 			Host:   "google.com",
-	// 		},
-	// 	},
-	// }
-	okw := OKW{
-		Contact: Agent{
-			Name:    "Some Person",
-			Website: "https://example.com",
-		},
-	}
-	yamlSample, err := yaml.Marshal(&okw)
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	writeFile(filepath.Join(outputDir, "okw.yaml"), yamlSample)
-	jsonSample, err := json.Marshal(&okw)
-	if err != nil {
-		log.Fatalf("error: %v", err)
-	}
-	writeFile(filepath.Join(outputDir, "okw.json"), jsonSample)
-	damlSample, err := daml.Marshal(okw, TypeMap)
-	if err != nil {
-		log.Fatalf("error: %v", err)
+// NewSample : return the sample OKW record.
+func NewSample() OKW {
+	var okw OKW
+	example.New(1).Fill(&okw)
+	okw.SpecVersion = SpecVersion
+	return okw
+}
+
+// Sample : write the sample OKW record to outputDir as okw.yaml and okw.json.
+// DAML is generated as a complete project by the damlgen package instead.
+func Sample(outputDir string) error {
+	okw := NewSample()
+	for _, f := range codec.Formats() {
+		if err := codec.WriteFile(filepath.Join(outputDir, "okw"+f.Ext()), &okw); err != nil {
+			return err
+		}
 	}
-	// writeFile("okw.daml", damlSample)
-	writeFile(filepath.Join(outputDir, "src/main/daml/OKW.daml"), damlSample)
+	return nil
 }
 
 // # Open Know Where
@@ -0,0 +1,209 @@
+package validation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/migration"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// Status : outcome of validating a file.
+type Status string
+
+const (
+	// Valid : the file decoded and satisfies every constraint.
+	Valid Status = "valid"
+	// Invalid : the file could not be decoded, or violates a constraint.
+	Invalid Status = "invalid"
+	// Unreadable : the file could not be read, or its kind is unknown.
+	Unreadable Status = "unreadable"
+)
+
+// Result : the outcome of validating one file.
+type Result struct {
+	Path       string      `json:"path" yaml:"path"`
+	Kind       string      `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Status     Status      `json:"status" yaml:"status"`
+	Violations []Violation `json:"violations,omitempty" yaml:"violations,omitempty"`
+	// Warnings : problems that do not make the file invalid, such as legacy keys.
+	Warnings []Warning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	// Error : why the file is unreadable or could not be decoded.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Summary : counts of results by status.
+type Summary struct {
+	Files      int `json:"files"`
+	Valid      int `json:"valid"`
+	Invalid    int `json:"invalid"`
+	Unreadable int `json:"unreadable"`
+}
+
+// Summarize : count results by status.
+func Summarize(results []Result) Summary {
+	s := Summary{Files: len(results)}
+	for _, r := range results {
+		switch r.Status {
+		case Valid:
+			s.Valid++
+		case Invalid:
+			s.Invalid++
+		case Unreadable:
+			s.Unreadable++
+		}
+	}
+	return s
+}
+
+// KindFunc : return the kind of the record stored at path.
+type KindFunc func(path string) (document.Kind, error)
+
+// CollectFiles : expand roots into the record files to validate. Files are
+// taken as given; directories are walked for files with a supported
+// extension whose kind can be inferred by kindOf. The result is sorted.
+func CollectFiles(roots []string, kindOf KindFunc) ([]string, error) {
+	var files []string
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err != nil || !info.IsDir() {
+			// Let ValidateFiles report the problem against the path.
+			files = append(files, root)
+			continue
+		}
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path != root && info.Name()[0] == '.' {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if _, err := codec.FormatFromPath(path); err != nil {
+				return nil
+			}
+			if _, err := kindOf(path); err != nil {
+				return nil
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ValidateFile : decode the file at path as a record of the kind given by
+// kindOf and validate it.
+func ValidateFile(v *validator.Validate, path string, kindOf KindFunc) Result {
+	r := Result{Path: path}
+	k, err := kindOf(path)
+	if err != nil {
+		r.Status, r.Error = Unreadable, err.Error()
+		return r
+	}
+	r.Kind = k.Name
+	format, err := codec.FormatFromPath(path)
+	if err != nil {
+		r.Status, r.Error = Unreadable, err.Error()
+		return r
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		r.Status, r.Error = Unreadable, err.Error()
+		return r
+	}
+	r = ValidateData(v, k, data, format, k.New())
+	r.Path = path
+	return r
+}
+
+// ValidateData : decode data, a document in format, into record, a pointer
+// to a new record of kind k, upgrading older versions of the specification,
+// and validate it. The result's Path is left empty.
+func ValidateData(v *validator.Validate, k document.Kind, data []byte, format codec.Format, record interface{}) Result {
+	r := Result{Kind: k.Name}
+	report, deprecations, err := document.Migrations().Decode(k.Name, data, format, record)
+	if err != nil {
+		r.Status, r.Error = Invalid, err.Error()
+		return r
+	}
+	if report.Changed() {
+		r.Warnings = append(r.Warnings, Warning{
+			Path:    migration.Key,
+			Message: fmt.Sprintf("record follows version %s of the specification, the latest is %s; run okf migrate", report.From, report.To),
+			Line:    Locate(data, format, migration.Key),
+		})
+		for _, s := range report.Steps {
+			for _, c := range s.Changes {
+				r.Warnings = append(r.Warnings, Warning{
+					Path:    c.Path,
+					Message: fmt.Sprintf("%s by the upgrade to version %s", c.Message, s.To),
+					Line:    Locate(data, format, c.Path),
+				})
+			}
+		}
+	}
+	for _, d := range deprecations {
+		r.Warnings = append(r.Warnings, Warning{
+			Path:    d.Path,
+			Message: fmt.Sprintf("key %q is deprecated, use %q", d.Key, d.Replacement),
+			Line:    Locate(data, format, d.Path),
+		})
+	}
+	violations, err := Check(v, record)
+	if err != nil {
+		r.Status, r.Error = Invalid, err.Error()
+		return r
+	}
+	for i := range violations {
+		violations[i].Line = Locate(data, format, violations[i].Path)
+		if doc, ok := k.Docs.Lookup(violations[i].Path); ok {
+			violations[i].Definition = doc.Definition
+		}
+	}
+	r.Violations = violations
+	r.Status = Valid
+	if len(violations) > 0 {
+		r.Status = Invalid
+	}
+	return r
+}
+
+// ValidateFiles : validate paths with at most workers files in flight at once.
+// Results are returned in the order of paths.
+func ValidateFiles(paths []string, workers int, kindOf KindFunc) []Result {
+	if workers < 1 {
+		workers = 1
+	}
+	v := New()
+	results := make([]Result, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = ValidateFile(v, paths[i], kindOf)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+)
+
+// Locate : return the line of the deepest key of path found in data, or the
+// line of its nearest ancestor if the key itself is absent (e.g. a missing
+// required field). Keys are searched for in order, each after the previous
+// one, so this is a textual approximation rather than a parse; 0 means no
+// key of the path was found.
+func Locate(data []byte, format codec.Format, path string) int {
+	offset, line := 0, 0
+	for _, key := range strings.Split(path, ".") {
+		if i := strings.IndexByte(key, '['); i >= 0 {
+			key = key[:i]
+		}
+		var token []byte
+		switch format {
+		case codec.JSON:
+			token = []byte(`"` + key + `"`)
+		default:
+			token = []byte(key + ":")
+		}
+		i := indexKey(data[offset:], token)
+		if i < 0 {
+			break
+		}
+		offset += i + len(token)
+		line = bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	return line
+}
+
+// indexKey : index of the first occurrence of token in data that starts a
+// key, i.e. is preceded only by indentation (or "- " / "{" / ",") on its line.
+func indexKey(data, token []byte) int {
+	for start := 0; ; {
+		i := bytes.Index(data[start:], token)
+		if i < 0 {
+			return -1
+		}
+		i += start
+		prefix := data[bytes.LastIndexByte(data[:i], '\n')+1 : i]
+		if len(bytes.Trim(prefix, " \t-{,")) == 0 {
+			return i
+		}
+		start = i + len(token)
+	}
+}
@@ -0,0 +1,234 @@
+package validation
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// WriteText : write a human readable report of results to w.
+func WriteText(w io.Writer, results []Result) error {
+	for _, r := range results {
+		switch r.Status {
+		case Valid:
+			fmt.Fprintf(w, "%s: ok\n", r.Path)
+		case Unreadable:
+			fmt.Fprintf(w, "%s: unreadable: %s\n", r.Path, r.Error)
+		case Invalid:
+			if r.Error != "" {
+				fmt.Fprintf(w, "%s: invalid: %s\n", r.Path, r.Error)
+				continue
+			}
+			fmt.Fprintf(w, "%s: invalid\n", r.Path)
+			for _, v := range r.Violations {
+				if v.Line > 0 {
+					fmt.Fprintf(w, "  line %d: %s\n", v.Line, v)
+				} else {
+					fmt.Fprintf(w, "  %s\n", v)
+				}
+				if v.Definition != "" {
+					fmt.Fprintf(w, "    %s: %s\n", v.Path, v.Definition)
+				}
+			}
+		}
+		for _, warning := range r.Warnings {
+			if warning.Line > 0 {
+				fmt.Fprintf(w, "  warning: line %d: %s\n", warning.Line, warning)
+			} else {
+				fmt.Fprintf(w, "  warning: %s\n", warning)
+			}
+		}
+	}
+	s := Summarize(results)
+	_, err := fmt.Fprintf(w, "%d files: %d valid, %d invalid, %d unreadable\n", s.Files, s.Valid, s.Invalid, s.Unreadable)
+	return err
+}
+
+// WriteJSON : write results and their summary to w as a JSON document.
+func WriteJSON(w io.Writer, results []Result) error {
+	if results == nil {
+		results = []Result{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Summary Summary  `json:"summary"`
+		Results []Result `json:"results"`
+	}{Summarize(results), results})
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit : write results to w as a JUnit XML test suite with one test
+// case per file. Invalid files are failures and unreadable files errors.
+func WriteJUnit(w io.Writer, results []Result) error {
+	s := Summarize(results)
+	suite := junitTestSuite{Name: "okf validate", Tests: s.Files, Failures: s.Invalid, Errors: s.Unreadable}
+	for _, r := range results {
+		tc := junitTestCase{ClassName: r.Kind, Name: r.Path}
+		switch r.Status {
+		case Invalid:
+			tc.Failure = &junitMessage{Message: "invalid record", Body: r.Error}
+			for _, v := range r.Violations {
+				tc.Failure.Body += v.String() + "\n"
+			}
+		case Unreadable:
+			tc.Error = &junitMessage{Message: "unreadable file", Body: r.Error}
+		}
+		for _, warning := range r.Warnings {
+			tc.SystemOut += "warning: " + warning.String() + "\n"
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// SARIF 2.1.0, limited to what code scanning tools need to annotate files.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSARIF : write results to w as a SARIF 2.1.0 log. Each violation is a
+// result whose rule is the failed validate tag; files that could not be
+// decoded or read are reported under the "decode" and "read" rules, and
+// warnings at level "warning" under the "deprecated" rule.
+func WriteSARIF(w io.Writer, results []Result) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "okf",
+			InformationURI: "https://github.com/helpfulengineering/open-knowledge-framework",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]bool{}
+	add := func(path, rule, level, text string, line int) {
+		if line < 1 {
+			line = 1
+		}
+		rules[rule] = true
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  rule,
+			Level:   level,
+			Message: sarifMessage{Text: text},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(path)},
+				Region:           sarifRegion{StartLine: line},
+			}}},
+		})
+	}
+	for _, r := range results {
+		switch {
+		case r.Status == Unreadable:
+			add(r.Path, "read", "error", r.Error, 1)
+		case r.Error != "":
+			add(r.Path, "decode", "error", r.Error, 1)
+		}
+		for _, v := range r.Violations {
+			add(r.Path, v.Rule, "error", v.String(), v.Line)
+		}
+		for _, warning := range r.Warnings {
+			add(r.Path, "deprecated", "warning", warning.String(), warning.Line)
+		}
+	}
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: "okf validation rule " + id},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isSPDX : whether s is an SPDX license expression, such as "CC-BY-4.0",
+// "GPL-2.0-or-later WITH Classpath-exception-2.0" or "(MIT OR Apache-2.0)".
+// Only the syntax is checked: identifiers are not looked up in the SPDX
+// license list, so that new licenses and LicenseRef- identifiers are accepted.
+func isSPDX(s string) bool {
+	p := &spdxParser{tokens: spdxTokens(s)}
+	return len(p.tokens) > 0 && p.expression() && p.pos == len(p.tokens)
+}
+
+// spdxTokens : split s into parentheses and words.
+func spdxTokens(s string) []string {
+	var tokens []string
+	for _, word := range strings.Fields(s) {
+		for word != "" {
+			i := strings.IndexAny(word, "()")
+			switch {
+			case i < 0:
+				tokens = append(tokens, word)
+				word = ""
+			case i > 0:
+				tokens = append(tokens, word[:i])
+				word = word[i:]
+			default:
+				tokens = append(tokens, word[:1])
+				word = word[1:]
+			}
+		}
+	}
+	return tokens
+}
+
+// spdxParser : a recursive descent parser of the grammar
+//
+//	expression = term { ("AND" | "OR") term }
+//	term       = "(" expression ")" | license [ "WITH" exception ]
+type spdxParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *spdxParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *spdxParser) expression() bool {
+	if !p.term() {
+		return false
+	}
+	for op := p.next(); op == "AND" || op == "OR"; op = p.next() {
+		p.pos++
+		if !p.term() {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *spdxParser) term() bool {
+	if p.next() == "(" {
+		p.pos++
+		if !p.expression() || p.next() != ")" {
+			return false
+		}
+		p.pos++
+		return true
+	}
+	if !spdxID(strings.TrimSuffix(p.next(), "+")) {
+		return false
+	}
+	p.pos++
+	if p.next() == "WITH" {
+		p.pos++
+		if !spdxID(p.next()) {
+			return false
+		}
+		p.pos++
+	}
+	return true
+}
+
+// spdxID : whether s is a license or exception identifier: letters, digits,
+// "." and "-", optionally with a DocumentRef-...: prefix.
+func spdxID(s string) bool {
+	if i := strings.IndexByte(s, ':'); i >= 0 && strings.HasPrefix(s, "DocumentRef-") {
+		s = s[i+1:]
+	}
+	if s == "" || s == "AND" || s == "OR" || s == "WITH" {
+		return false
+	}
+	for _, r := range s {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+// Package validation checks Open Knowledge Framework records against the
+// constraints declared in their `validate` struct tags.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// New : return a validator with the framework's custom validations registered.
+// Field errors are named by their YAML keys.
+func New() *validator.Validate {
+	v := validator.New()
+	_ = v.RegisterValidation("what3words", func(fl validator.FieldLevel) bool {
+		return len(strings.Split(fl.Field().String(), ".")) == 3
+	})
+	_ = v.RegisterValidation("timestamp", func(fl validator.FieldLevel) bool {
+		return isTimestamp(fl.Field().String())
+	})
+	_ = v.RegisterValidation("spdx", func(fl validator.FieldLevel) bool {
+		return isSPDX(fl.Field().String())
+	})
+	_ = v.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+		return isOption(fl.Field())
+	})
+	v.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		return spec.Key(sf, "yaml")
+	})
+	return v
+}
+
+// Violation : a constraint a record does not satisfy.
+type Violation struct {
+	// Path : dotted YAML path of the offending field, e.g. "contact.name".
+	Path string `json:"path" yaml:"path"`
+	// Rule : the validate tag that failed, e.g. "required".
+	Rule string `json:"rule" yaml:"rule"`
+	// Param : the rule's parameter, if any, e.g. "5" for "lte=5".
+	Param   string `json:"param,omitempty" yaml:"param,omitempty"`
+	Message string `json:"message" yaml:"message"`
+	// Line : best-effort 1-based line of the field in the source file, or 0 if unknown.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+	// Definition : the specification's definition of the field, if documented.
+	Definition string `json:"definition,omitempty" yaml:"definition,omitempty"`
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// Warning : a problem that does not make a record invalid.
+type Warning struct {
+	// Path : dotted path of the offending key, e.g. "social_media.landline".
+	Path    string `json:"path" yaml:"path"`
+	Message string `json:"message" yaml:"message"`
+	// Line : best-effort 1-based line of the key in the source file, or 0 if unknown.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+func (w Warning) String() string {
+	return w.Path + ": " + w.Message
+}
+
+// Check : validate record with v and return its violations, if any.
+func Check(v *validator.Validate, record interface{}) ([]Violation, error) {
+	err := v.Struct(record)
+	if err == nil {
+		return nil, nil
+	}
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return nil, err
+	}
+	violations := make([]Violation, len(fieldErrors))
+	for i, fe := range fieldErrors {
+		path := fe.Namespace()
+		if i := strings.IndexByte(path, '.'); i >= 0 {
+			// Drop the root type name.
+			path = path[i+1:]
+		}
+		violations[i] = Violation{
+			Path:    path,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(fe),
+		}
+	}
+	return violations, nil
+}
+
+// isTimestamp : whether s is an RFC 3339 date and time or an ISO 8601
+// calendar date.
+func isTimestamp(s string) bool {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isOption : whether v is one of the values its type's EnumOptions lists.
+// A type that lists none accepts any value.
+func isOption(v reflect.Value) bool {
+	options, _ := spec.EnumOptions(v.Type())
+	if len(options) == 0 {
+		return true
+	}
+	for _, o := range options {
+		if v.String() == o {
+			return true
+		}
+	}
+	return false
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	case "required_without":
+		return "is required unless " + strings.ToLower(fe.Param()) + " is given"
+	case "uri|uuid":
+		return "must be a URI or a UUID"
+	case "what3words":
+		return "must be a What 3 Words phrase of three words separated by dots"
+	case "timestamp":
+		return "must be an RFC 3339 date and time, e.g. 2024-03-01T09:30:00Z, or a date, e.g. 2024-03-01"
+	case "spdx":
+		return "must be an SPDX license identifier or expression, e.g. CC-BY-4.0"
+	case "url":
+		return "must be a URL"
+	case "enum":
+		options, _ := spec.EnumOptions(fe.Type())
+		return "must be one of " + strings.Join(options, ", ")
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("fails the %s=%s rule", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("fails the %s rule", fe.Tag())
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/helpfulengineering/open-knowledge-framework/codec"
+	"github.com/helpfulengineering/open-knowledge-framework/document"
+	"github.com/helpfulengineering/open-knowledge-framework/spec"
+	"github.com/helpfulengineering/open-knowledge-framework/templates/okw"
+)
+
+func TestEnum(t *testing.T) {
+	v := New()
+	tests := []struct {
+		status okw.FacilityStatus
+		access okw.AccessType
+		want   []string
+	}{
+		{"", "", nil},
+		{okw.Active, okw.Public, nil},
+		{okw.TemporaryClosure, okw.RestrictedWithPublicHours, nil},
+		{"Bogus", "", []string{"facility_status: must be one of Active, Planned, Temporary Closure, Closed"}},
+		{"active", "", []string{"facility_status: must be one of Active, Planned, Temporary Closure, Closed"}},
+		{"", "Anyone", []string{"access_type: must be one of Restricted, Restricted with public hours, Shared space, Public, Membership"}},
+	}
+	for _, tt := range tests {
+		r := okw.NewSample()
+		r.FacilityStatus, r.AccessType = tt.status, tt.access
+		violations, err := Check(v, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, vi := range violations {
+			got = append(got, vi.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("status %q, access %q: violations %q, want %q", tt.status, tt.access, got, tt.want)
+		}
+	}
+}
+
+func TestEnumDocument(t *testing.T) {
+	k, err := document.Lookup("okw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := codec.Marshal(okw.NewSample(), codec.YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), "facility_status: ", "facility_status: Bogus # ", 1))
+	r := ValidateData(New(), k, data, codec.YAML, k.New())
+	if r.Status != Invalid || len(r.Violations) != 1 || r.Violations[0].Rule != "enum" {
+		t.Fatalf("ValidateData: %+v, want facility_status to fail the enum rule", r)
+	}
+	if r.Violations[0].Line == 0 || r.Violations[0].Definition == "" {
+		t.Errorf("violation %+v has no line or definition", r.Violations[0])
+	}
+}
+
+// TestEnumFields : every field whose type lists its values must be checked
+// against them.
+func TestEnumFields(t *testing.T) {
+	for _, k := range document.Kinds() {
+		for _, typ := range spec.NamedTypes(reflect.TypeOf(k.New()).Elem(), "yaml") {
+			if typ.Kind() != reflect.Struct {
+				continue
+			}
+			for i := 0; i < typ.NumField(); i++ {
+				f := typ.Field(i)
+				if options, _ := spec.EnumOptions(f.Type); len(options) == 0 {
+					continue
+				}
+				if !strings.Contains(","+f.Tag.Get("validate")+",", ",enum,") {
+					t.Errorf("%s: field %s.%s of enum type %s has no enum rule", k.Name, typ.Name(), f.Name, f.Type.Name())
+				}
+			}
+		}
+	}
+}